@@ -1,12 +1,26 @@
 package e2e
 
 import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -15,14 +29,28 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/guicostaarantes/psi-server/graph"
 	"github.com/guicostaarantes/psi-server/graph/resolvers"
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
+	characteristics_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/archive"
+	"github.com/guicostaarantes/psi-server/utils/calendar"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/file_storage"
 	"github.com/guicostaarantes/psi-server/utils/hash"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/logging"
 	"github.com/guicostaarantes/psi-server/utils/mail"
 	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/guicostaarantes/psi-server/utils/oidc"
 	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/otp"
 	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/table"
 	"github.com/guicostaarantes/psi-server/utils/token"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fastjson"
@@ -45,6 +73,155 @@ func gql(router *chi.Mux, query string, token string) *httptest.ResponseRecorder
 
 }
 
+func gqlWithFile(router *chi.Mux, query string, token string, variable string, fileName string, content []byte) *httptest.ResponseRecorder {
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	writer.WriteField("operations", fmt.Sprintf(`{"query": %q, "variables": {%q: null}}`, query, variable))
+	writer.WriteField("map", fmt.Sprintf(`{"0": ["variables.%s"]}`, variable))
+	part, _ := writer.CreateFormFile("0", fileName)
+	part.Write(content)
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/gql", body)
+
+	request.Header["Authorization"] = []string{token}
+	request.Header["Content-Type"] = []string{writer.FormDataContentType()}
+
+	response := httptest.NewRecorder()
+
+	router.ServeHTTP(response, request)
+
+	return response
+
+}
+
+// oidcProvider is a stand-in for an OpenID Connect identity provider. Instead of showing a login page, its authorize endpoint
+// logs in whoever is informed in the login_hint and subject parameters and redirects back with a code
+type oidcProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+	secret   string
+	codes    map[string]url.Values
+}
+
+func startOidcProvider(clientID string, secret string) *oidcProvider {
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	provider := &oidcProvider{
+		key:      key,
+		clientID: clientID,
+		secret:   secret,
+		codes:    map[string]url.Values{},
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 provider.server.URL,
+			"authorization_endpoint": provider.server.URL + "/authorize",
+			"token_endpoint":         provider.server.URL + "/token",
+			"jwks_uri":               provider.server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	})
+
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		code := fmt.Sprintf("code-%d", len(provider.codes))
+		provider.codes[code] = r.URL.Query()
+		http.Redirect(w, r, r.URL.Query().Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {r.URL.Query().Get("state")}}.Encode(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		r.ParseForm()
+
+		authorization, ok := provider.codes[r.PostForm.Get("code")]
+		delete(provider.codes, r.PostForm.Get("code"))
+
+		challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+
+		if !ok || id != provider.clientID || secret != provider.secret ||
+			r.PostForm.Get("redirect_uri") != authorization.Get("redirect_uri") ||
+			base64.RawURLEncoding.EncodeToString(challenge[:]) != authorization.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+		claims, _ := json.Marshal(map[string]interface{}{
+			"iss":            provider.server.URL,
+			"sub":            authorization.Get("subject"),
+			"aud":            provider.clientID,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          authorization.Get("nonce"),
+			"email":          authorization.Get("login_hint"),
+			"email_verified": authorization.Get("email_verified") != "false",
+		})
+
+		content := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+		digest := sha256.Sum256([]byte(content))
+		signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"id_token":     content + "." + base64.RawURLEncoding.EncodeToString(signature),
+		})
+	})
+
+	provider.server = httptest.NewServer(mux)
+
+	return provider
+
+}
+
+// login follows the authorization url as the given user would, returning the state and code sent back to the application
+func (p *oidcProvider) login(authorizationURL string, email string, emailVerified bool) (string, string) {
+	return p.loginAs(authorizationURL, "subject-"+email, email, emailVerified)
+}
+
+// loginAs is the same as login, for an account of the provider whose email is not the one it was created with
+func (p *oidcProvider) loginAs(authorizationURL string, subject string, email string, emailVerified bool) (string, string) {
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := client.Get(fmt.Sprintf("%s&login_hint=%s&subject=%s&email_verified=%t", authorizationURL, url.QueryEscape(email), url.QueryEscape(subject), emailVerified))
+	if err != nil {
+		return "", ""
+	}
+	defer response.Body.Close()
+
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil {
+		return "", ""
+	}
+
+	return location.Query().Get("state"), location.Query().Get("code")
+
+}
+
 func TestEnd2End(t *testing.T) {
 
 	os.Setenv("TZ", "UTC")
@@ -53,8 +230,35 @@ func TestEnd2End(t *testing.T) {
 
 	loggingUtil := logging.PrintLoggingUtil{}
 
-	hashUtil := hash.BcryptHashUtil{
-		Cost:        4,
+	archiveUtil := archive.ZipArchiveUtil{
+		LoggingUtil: loggingUtil,
+	}
+
+	calendarUtil := calendar.IcsCalendarUtil{
+		LoggingUtil: loggingUtil,
+	}
+
+	digestUtil := digest.HmacDigestUtil{
+		Key:         "digest-secret",
+		LoggingUtil: loggingUtil,
+	}
+
+	fileStorageUtil := file_storage.DiskFileStorageUtil{
+		BaseFolder:  t.TempDir(),
+		LoggingUtil: loggingUtil,
+	}
+
+	exportStorageUtil := file_storage.DiskFileStorageUtil{
+		BaseFolder:  t.TempDir(),
+		LoggingUtil: loggingUtil,
+	}
+
+	hashUtil := hash.Argon2idHashUtil{
+		Memory:      1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
 		LoggingUtil: loggingUtil,
 	}
 
@@ -67,6 +271,21 @@ func TestEnd2End(t *testing.T) {
 	}
 
 	matchUtil := match.RegexpMatchUtil{
+		PasswordPolicy: match.PasswordPolicy{
+			MinLength:             8,
+			RequireUppercase:      true,
+			RequireLowercase:      true,
+			RequireDigit:          true,
+			RequireSymbol:         true,
+			MaxRepeatedCharacters: 3,
+			ForbidEmail:           true,
+			Blocklist:             match.ParseBlocklist("# common passwords\nPassword123!\nQwerty123!\n"),
+		},
+		LoggingUtil: loggingUtil,
+	}
+
+	otpUtil := otp.TotpOtpUtil{
+		Issuer:      "PSI",
 		LoggingUtil: loggingUtil,
 	}
 
@@ -95,25 +314,74 @@ func TestEnd2End(t *testing.T) {
 		LoggingUtil: loggingUtil,
 	}
 
-	tokenUtil := token.RngTokenUtil{
-		Runes: "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
-		Size:  8,
+	tableUtil := table.CsvTableUtil{
+		LoggingUtil: loggingUtil,
+	}
+
+	oidcProvider := startOidcProvider("psi", "psi-secret")
+	defer oidcProvider.server.Close()
+
+	oidcUtil := oidc.HttpOidcUtil{
+		IssuerURL:    oidcProvider.server.URL,
+		ClientID:     "psi",
+		ClientSecret: "psi-secret",
+		RedirectURL:  "http://localhost:7007/oidc/callback",
+		HttpClient:   oidcProvider.server.Client(),
+		LoggingUtil:  loggingUtil,
+	}
+
+	tokenUtil := token.HmacTokenUtil{
+		Keys: map[string]string{
+			"current":  "current-secret",
+			"previous": "previous-secret",
+		},
+		CurrentKeyID: "current",
+		LoggingUtil:  loggingUtil,
 	}
 
+	_, trustedProxies, _ := net.ParseCIDR("10.0.0.0/8")
+
 	res := &resolvers.Resolver{
+		ArchiveUtil:                        archiveUtil,
+		CalendarUtil:                       calendarUtil,
+		DigestUtil:                         digestUtil,
+		ExportStorageUtil:                  exportStorageUtil,
+		FileStorageUtil:                    fileStorageUtil,
 		HashUtil:                           hashUtil,
 		IdentifierUtil:                     identifierUtil,
 		MailUtil:                           mailUtil,
 		MatchUtil:                          matchUtil,
+		OidcUtil:                           oidcUtil,
 		OrmUtil:                            &ormUtil,
+		OtpUtil:                            otpUtil,
 		SerializingUtil:                    serializingUtil,
+		TableUtil:                          tableUtil,
 		TokenUtil:                          tokenUtil,
 		MaxAffinityNumber:                  int64(5),
+		MaxAuditLogPageSize:                int64(100),
+		MaxUsersPageSize:                   int64(100),
+		AppointmentHorizonOccurrences:      int64(4),
 		ScheduleIntervalDuration:           time.Duration(604800) * time.Second,
+		FreeSlotStepDuration:               time.Duration(1800) * time.Second,
 		ExpireAuthTokenDuration:            time.Duration(1800) * time.Second,
+		ExpireRefreshTokenDuration:         time.Duration(2592000) * time.Second,
 		ExpireResetTokenDuration:           time.Duration(86400) * time.Second,
+		ExpireEmailChangeTokenDuration:     time.Duration(86400) * time.Second,
+		ExpireDataExportDuration:           time.Duration(86400) * time.Second,
+		ExpireImpersonationTokenDuration:   time.Duration(900) * time.Second,
+		ExpireInvitationDuration:           time.Duration(604800) * time.Second,
+		ExpireOidcAuthorizationDuration:    time.Duration(600) * time.Second,
 		InterruptTreatmentCooldownDuration: time.Duration(259200) * time.Second,
 		TopAffinitiesCooldownDuration:      time.Duration(86400) * time.Second,
+		LoginFailedCooldownDuration:        time.Duration(900) * time.Second,
+		LoginFailedDelayDuration:           time.Duration(60) * time.Second,
+		LoginLockedCooldownDuration:        time.Duration(1800) * time.Second,
+		MaxLoginFailuresPerUser:            int64(3),
+		MaxLoginFailuresPerIPAddress:       int64(20),
+		RecoveryCodesQuantity:              int64(10),
+		ImpersonationAllowedMutations:      []string{"revokeSession"},
+		OidcDefaultRole:                    users_models.Patient,
+		TrustedProxies:                     []*net.IPNet{trustedProxies},
 	}
 
 	os.Setenv("PSI_BOOTSTRAP_USER", "coordinator@psi.com.br|Abc123!@#")
@@ -187,7 +455,7 @@ func TestEnd2End(t *testing.T) {
 			  input: {
 				email: "jobrunner@psi.com.br"
 				password: "Xyz*()890"
-				role: JOBRUNNER
+				role: "JOBRUNNER"
 			  }
 			)
 		}`
@@ -378,6 +646,15 @@ func TestEnd2End(t *testing.T) {
 		regex := regexp.MustCompile("token=(?P<token>[^\"]+)")
 		match := regex.FindStringSubmatch(mailBody)
 
+		// the token only exists in the mail that was sent, not in the messages stored in the database
+		storedWithToken := int64(0)
+		res.OrmUtil.Db().Model(&mails_models.TransientMailMessage{}).Where("html LIKE ?", "%"+match[1]+"%").Count(&storedWithToken)
+		assert.Equal(t, int64(0), storedWithToken)
+
+		storedWithContent := int64(0)
+		res.OrmUtil.Db().Model(&mails_models.TransientMailMessage{}).Where("processed = ? AND html <> ?", true, "").Count(&storedWithContent)
+		assert.Equal(t, int64(0), storedWithContent)
+
 		query = fmt.Sprintf(`mutation {
 			resetPassword(input: {
 				token: %q,
@@ -845,6 +1122,117 @@ func TestEnd2End(t *testing.T) {
 
 	})
 
+	t.Run("should review the crp of psychologists only if coordinator", func(t *testing.T) {
+
+		profileQuery := `{
+			myPsychologistProfile {
+				verificationStatus
+				verificationReason
+			}
+		}`
+
+		response := gql(router, profileQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"verificationStatus\":\"PENDING\",\"verificationReason\":\"\"}}}", response.Body.String())
+
+		queueQuery := `{
+			psychologistsPendingVerification {
+				id
+				crp
+			}
+		}`
+
+		response = gql(router, queueQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"psychologistsPendingVerification\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, queueQuery, storedVariables["coordinator_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"psychologistsPendingVerification\":[{\"id\":%q,\"crp\":\"01/123456\"},{\"id\":%q,\"crp\":\"01/123457\"}]}}", storedVariables["psychologist_1_id"], storedVariables["psychologist_2_id"]), response.Body.String())
+
+		reviewQuery := `mutation {
+			%s(id: %q, reason: %q)
+		}`
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "rejectPsychologistVerification", storedVariables["psychologist_1_id"], "CRP not found in the registry"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"rejectPsychologistVerification\"]}],\"data\":{\"rejectPsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "rejectPsychologistVerification", storedVariables["psychologist_1_id"], " "), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"reason is required\",\"path\":[\"rejectPsychologistVerification\"]}],\"data\":{\"rejectPsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "rejectPsychologistVerification", "00000000-0000-0000-0000-000000000000", "CRP not found in the registry"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"resource not found\",\"path\":[\"rejectPsychologistVerification\"]}],\"data\":{\"rejectPsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "rejectPsychologistVerification", storedVariables["psychologist_1_id"], "CRP not found in the registry"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"rejectPsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "approvePsychologistVerification", storedVariables["psychologist_1_id"], "CRP found in the registry"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"psychologist is not pending verification\",\"path\":[\"approvePsychologistVerification\"]}],\"data\":{\"approvePsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, profileQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"verificationStatus\":\"REJECTED\",\"verificationReason\":\"CRP not found in the registry\"}}}", response.Body.String())
+
+		upsertQuery := `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Thomas Edward Patrick Brady, Jr."
+				likeName: "Tom Brady",
+				birthDate: "1977-08-03T00:00:00Z",
+				city: "Tampa - FL",
+				bio: "Hey there, my name is Tom",
+				crp: %q,
+				whatsapp: "(11) 2345-6789",
+				instagram: "@tombrady"
+			})
+		}`
+
+		response = gql(router, fmt.Sprintf(upsertQuery, "01/123456"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		response = gql(router, profileQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"verificationStatus\":\"REJECTED\",\"verificationReason\":\"CRP not found in the registry\"}}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(upsertQuery, ""), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		response = gql(router, profileQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"verificationStatus\":\"UNVERIFIED\",\"verificationReason\":\"\"}}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(upsertQuery, "01/123456"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		response = gql(router, queueQuery, storedVariables["coordinator_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"psychologistsPendingVerification\":[{\"id\":%q,\"crp\":\"01/123457\"},{\"id\":%q,\"crp\":\"01/123456\"}]}}", storedVariables["psychologist_2_id"], storedVariables["psychologist_1_id"]), response.Body.String())
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "approvePsychologistVerification", storedVariables["psychologist_1_id"], "CRP found in the registry"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"approvePsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(reviewQuery, "approvePsychologistVerification", storedVariables["psychologist_2_id"], "CRP found in the registry"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"approvePsychologistVerification\":null}}", response.Body.String())
+
+		response = gql(router, profileQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"verificationStatus\":\"VERIFIED\",\"verificationReason\":\"CRP found in the registry\"}}}", response.Body.String())
+
+		response = gql(router, queueQuery, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"psychologistsPendingVerification\":[]}}", response.Body.String())
+
+	})
+
 	t.Run("should choose own psychologist characteristic only if user is coordinator or psychologist", func(t *testing.T) {
 
 		query := `mutation {
@@ -1937,7 +2325,7 @@ func TestEnd2End(t *testing.T) {
 			  input: {
 				email: "patient2@psi.com.br"
 				password: "Xyz*()890"
-				role: PATIENT
+				role: "PATIENT"
 			  }
 			)
 		}`
@@ -2033,7 +2421,7 @@ func TestEnd2End(t *testing.T) {
 			  input: {
 				email: "patient3@psi.com.br"
 				password: "Xyz*()890"
-				role: PATIENT
+				role: "PATIENT"
 			  }
 			)
 		}`
@@ -2129,7 +2517,7 @@ func TestEnd2End(t *testing.T) {
 			  input: {
 				email: "patient4@psi.com.br"
 				password: "Xyz*()890"
-				role: PATIENT
+				role: "PATIENT"
 			  }
 			)
 		}`
@@ -2220,84 +2608,105 @@ func TestEnd2End(t *testing.T) {
 
 	t.Run("should create treatment only if coordinator or psychologist", func(t *testing.T) {
 
+		collisionMessage := "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"],\"extensions\":{\"collidingTreatmentId\":%q,\"occurrence\":%d,\"startTime\":%q,\"week\":%d,\"weekday\":%q}}],\"data\":{\"createTreatment\":null}}"
+
+		createdTreatmentID := func(index string) string {
+			response := gql(router, `{ myPsychologistProfile { treatments { id } } }`, storedVariables["psychologist_token"])
+			return fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "treatments", index, "id")
+		}
+
 		query := `mutation {
 			createTreatment(input: {
 				frequency: %d,
-				phase: %d,
+				week: %d,
+				weekday: %s,
+				startTime: %q,
 				duration: 3600,
 				priceRangeName: %q
 			})
 		}`
 
-		response := gql(router, fmt.Sprintf(query, 2, 226800, "low"), "")
+		response := gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "15:00", "low"), "")
 
 		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 226800, "low"), storedVariables["patient_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "15:00", "low"), storedVariables["patient_token"])
 
 		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 1, res.ScheduleIntervalDuration/time.Second, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 1, 1, "THURSDAY", "00:00", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"week must be smaller than the frequency\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 13, 0, "SATURDAY", "15:00", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"frequency must be at most 12\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		assert.Equal(t, "{\"errors\":[{\"message\":\"phase cannot be bigger than the schedule interval\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+		response = gql(router, fmt.Sprintf(query, 1, 0, "SATURDAY", "24:00", "low"), storedVariables["psychologist_token"])
 
-		response = gql(router, fmt.Sprintf(query, 1, 226800, "low"), storedVariables["psychologist_token"])
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid time\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 1, 0, "SATURDAY", "3:00pm", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid time\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 1, 0, "SATURDAY", "15:00", "low"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 226800, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "15:00", "low"), storedVariables["psychologist_token"])
 
-		assert.Equal(t, "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+		assert.Equal(t, fmt.Sprintf(collisionMessage, createdTreatmentID("0"), 0, "15:00", 0, "SATURDAY"), response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 228600, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "15:30", "low"), storedVariables["psychologist_token"])
 
-		assert.Equal(t, "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+		assert.Equal(t, fmt.Sprintf(collisionMessage, createdTreatmentID("0"), 0, "15:00", 0, "SATURDAY"), response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 228600+res.ScheduleIntervalDuration/time.Second, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 1, "SATURDAY", "15:30", "low"), storedVariables["psychologist_token"])
 
-		assert.Equal(t, "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+		assert.Equal(t, fmt.Sprintf(collisionMessage, createdTreatmentID("0"), 1, "15:00", 1, "SATURDAY"), response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 230400, "free"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "16:00", "free"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 230400+res.ScheduleIntervalDuration/time.Second, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 1, "SATURDAY", "16:00", "low"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 234000, "medium"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "17:00", "medium"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 237600+res.ScheduleIntervalDuration/time.Second, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 1, "SATURDAY", "18:00", "low"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 1, 239400, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 1, 0, "SATURDAY", "18:30", "low"), storedVariables["psychologist_token"])
 
-		assert.Equal(t, "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+		assert.Equal(t, fmt.Sprintf(collisionMessage, createdTreatmentID("4"), 0, "18:00", 1, "SATURDAY"), response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 241200, "medium"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "19:00", "medium"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 244800, "low"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "20:00", "low"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 248400, "free"), storedVariables["psychologist_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "21:00", "free"), storedVariables["psychologist_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 226800, "low"), storedVariables["coordinator_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "15:00", "low"), storedVariables["coordinator_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 230400, "low"), storedVariables["coordinator_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "16:00", "low"), storedVariables["coordinator_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 
-		response = gql(router, fmt.Sprintf(query, 2, 234000, "low"), storedVariables["coordinator_token"])
+		response = gql(router, fmt.Sprintf(query, 2, 0, "SATURDAY", "17:00", "low"), storedVariables["coordinator_token"])
 
 		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
 	})
@@ -2372,7 +2781,9 @@ func TestEnd2End(t *testing.T) {
 				id: %q,
 				input: {
 					frequency: 2,
-					phase: 226800,
+					week: 0,
+					weekday: SATURDAY,
+					startTime: "15:00",
 					duration: %d,
 				}
 			)
@@ -2405,7 +2816,9 @@ func TestEnd2End(t *testing.T) {
 			myPsychologistProfile {
 				treatments {
 					frequency
-					phase
+					week
+					weekday
+					startTime
 					duration
 					priceRange {
 						name
@@ -2416,11 +2829,11 @@ func TestEnd2End(t *testing.T) {
 
 		response := gql(router, query, storedVariables["psychologist_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"treatments\":[{\"frequency\":2,\"phase\":226800,\"duration\":2700,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":230400,\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":835200,\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":234000,\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":842400,\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":241200,\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":244800,\"duration\":3600,\"priceRange\":{\"name\":\"\"}}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"treatments\":[{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"15:00\",\"duration\":2700,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"16:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"16:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"17:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"18:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"19:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"20:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}}]}}}", response.Body.String())
 
 		response = gql(router, query, storedVariables["coordinator_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"treatments\":[{\"frequency\":2,\"phase\":226800,\"duration\":3000,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":230400,\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"phase\":234000,\"duration\":3600,\"priceRange\":{\"name\":\"\"}}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"treatments\":[{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"15:00\",\"duration\":3000,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"16:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}},{\"frequency\":2,\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"17:00\",\"duration\":3600,\"priceRange\":{\"name\":\"\"}}]}}}", response.Body.String())
 
 	})
 
@@ -2466,7 +2879,9 @@ func TestEnd2End(t *testing.T) {
 				id: %q,
 				input: {
 					frequency: 2,
-					phase: 326800,
+					week: 0,
+					weekday: SUNDAY,
+					startTime: "18:45",
 					duration: 3600,
 					priceRangeName: "low",
 				}
@@ -2750,7 +3165,9 @@ func TestEnd2End(t *testing.T) {
 					status
 					treatment {
 						frequency
-						phase
+						week
+						weekday
+						startTime
 					}
 				}
 			}
@@ -2763,19 +3180,17 @@ func TestEnd2End(t *testing.T) {
 		appointmentStatus = fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "1", "status")
 		assert.Equal(t, "CREATED", appointmentStatus)
 
-		appointmentFrequency := int64(fastjson.GetInt(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "0", "treatment", "frequency"))
-		appointmentPhase := int64(fastjson.GetInt(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "0", "treatment", "phase"))
-		appointmentStartString := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "0", "start")
-		appointmentStart, _ := time.Parse(time.RFC3339, appointmentStartString)
-		intervalDuration := int64(res.ScheduleIntervalDuration/time.Second) * appointmentFrequency
-		assert.Equal(t, appointmentStart.Unix()%intervalDuration, appointmentPhase)
-
-		appointmentFrequency = int64(fastjson.GetInt(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "1", "treatment", "frequency"))
-		appointmentPhase = int64(fastjson.GetInt(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "1", "treatment", "phase"))
-		appointmentStartString = fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "appointments", "1", "start")
-		appointmentStart, _ = time.Parse(time.RFC3339, appointmentStartString)
-		intervalDuration = int64(res.ScheduleIntervalDuration/time.Second) * appointmentFrequency
-		assert.Equal(t, appointmentStart.Unix()%intervalDuration, appointmentPhase)
+		// the psychologist profile is still in UTC, so the start of each appointment must match the treatment in UTC
+		for _, index := range []string{"0", "1"} {
+			appointment := fastjson.MustParseBytes(response.Body.Bytes()).Get("data", "myPsychologistProfile", "appointments", index)
+			appointmentStart, _ := time.Parse(time.RFC3339, string(appointment.GetStringBytes("start")))
+			appointmentStart = appointmentStart.UTC()
+			appointmentFrequency := appointment.GetInt64("treatment", "frequency")
+			weeksSinceEpoch := appointmentStart.Unix() / int64(res.ScheduleIntervalDuration/time.Second)
+			assert.Equal(t, appointment.GetInt64("treatment", "week"), weeksSinceEpoch%appointmentFrequency)
+			assert.Equal(t, strings.ToUpper(appointmentStart.Weekday().String()), string(appointment.GetStringBytes("treatment", "weekday")))
+			assert.Equal(t, appointmentStart.Format("15:04"), string(appointment.GetStringBytes("treatment", "startTime")))
+		}
 
 		query = `query {
 			myPatientProfile {
@@ -2785,7 +3200,9 @@ func TestEnd2End(t *testing.T) {
 					status
 					treatment {
 						frequency
-						phase
+						week
+						weekday
+						startTime
 					}
 				}
 			}
@@ -2930,7 +3347,9 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, fmt.Sprintf("{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"EDITED_BY_PSYCHOLOGIST\",\"start\":%q,\"end\":%q,\"reason\":\"I will be on vacations this day.\",\"priceRange\":{\"name\":\"medium\"}}]}}}", start, end), response.Body.String())
+		// the other sessions of the horizon are not affected
+		assert.Equal(t, 4, len(fastjson.MustParseBytes(response.Body.Bytes()).GetArray("data", "myPatientProfile", "appointments")))
+		assert.Equal(t, fmt.Sprintf("{\"status\":\"EDITED_BY_PSYCHOLOGIST\",\"start\":%q,\"end\":%q,\"reason\":\"I will be on vacations this day.\",\"priceRange\":{\"name\":\"medium\"}}", start, end), fastjson.MustParseBytes(response.Body.Bytes()).Get("data", "myPatientProfile", "appointments", "0").String())
 
 		query = `mutation {
 			processPendingMail
@@ -2982,7 +3401,7 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PATIENT\",\"reason\":\"Maybe it's better to skip this week.\"}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PATIENT\",\"reason\":\"Maybe it's better to skip this week.\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"}]}}}", response.Body.String())
 
 		query = `mutation {
 			processPendingMail
@@ -3042,7 +3461,7 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PATIENT\",\"reason\":\"Maybe it's better to skip this week.\"}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PATIENT\",\"reason\":\"Maybe it's better to skip this week.\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"}]}}}", response.Body.String())
 	})
 
 	t.Run("should edit appointment by patient", func(t *testing.T) {
@@ -3084,7 +3503,9 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, fmt.Sprintf("{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"EDITED_BY_PATIENT\",\"start\":%q,\"end\":%q,\"reason\":\"I can only do it this time in that day.\",\"priceRange\":{\"name\":\"medium\"}}]}}}", start, end), response.Body.String())
+		// the other sessions of the horizon are not affected
+		assert.Equal(t, 4, len(fastjson.MustParseBytes(response.Body.Bytes()).GetArray("data", "myPatientProfile", "appointments")))
+		assert.Equal(t, fmt.Sprintf("{\"status\":\"EDITED_BY_PATIENT\",\"start\":%q,\"end\":%q,\"reason\":\"I can only do it this time in that day.\",\"priceRange\":{\"name\":\"medium\"}}", start, end), fastjson.MustParseBytes(response.Body.Bytes()).Get("data", "myPatientProfile", "appointments", "0").String())
 
 		query = `mutation {
 			processPendingMail
@@ -3136,7 +3557,7 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PSYCHOLOGIST\",\"reason\":\"I had a problem and will not be able to do it this week.\"}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PSYCHOLOGIST\",\"reason\":\"I had a problem and will not be able to do it this week.\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"}]}}}", response.Body.String())
 
 		query = `mutation {
 			processPendingMail
@@ -3193,7 +3614,7 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PSYCHOLOGIST\",\"reason\":\"I had a problem and will not be able to do it this week.\"}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"CANCELED_BY_PSYCHOLOGIST\",\"reason\":\"I had a problem and will not be able to do it this week.\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"},{\"status\":\"CREATED\",\"reason\":\"\"}]}}}", response.Body.String())
 	})
 
 	t.Run("should cancel future appointments when patient interrupts treatment", func(t *testing.T) {
@@ -3225,7 +3646,7 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["patient_2_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"TREATMENT_INTERRUPTED_BY_PATIENT\",\"reason\":\"Synergy with psychologist was not good.\"}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"TREATMENT_INTERRUPTED_BY_PATIENT\",\"reason\":\"Synergy with psychologist was not good.\"},{\"status\":\"TREATMENT_INTERRUPTED_BY_PATIENT\",\"reason\":\"Synergy with psychologist was not good.\"},{\"status\":\"TREATMENT_INTERRUPTED_BY_PATIENT\",\"reason\":\"Synergy with psychologist was not good.\"},{\"status\":\"TREATMENT_INTERRUPTED_BY_PATIENT\",\"reason\":\"Synergy with psychologist was not good.\"}]}}}", response.Body.String())
 
 	})
 
@@ -3250,7 +3671,7 @@ func TestEnd2End(t *testing.T) {
 
 		response = gql(router, query, storedVariables["coordinator_token"])
 
-		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"TREATMENT_INTERRUPTED_BY_PSYCHOLOGIST\",\"reason\":\"Patient has not shown in last three appointments.\"}]}}}", response.Body.String())
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"appointments\":[{\"status\":\"TREATMENT_INTERRUPTED_BY_PSYCHOLOGIST\",\"reason\":\"Patient has not shown in last three appointments.\"},{\"status\":\"TREATMENT_INTERRUPTED_BY_PSYCHOLOGIST\",\"reason\":\"Patient has not shown in last three appointments.\"},{\"status\":\"TREATMENT_INTERRUPTED_BY_PSYCHOLOGIST\",\"reason\":\"Patient has not shown in last three appointments.\"},{\"status\":\"TREATMENT_INTERRUPTED_BY_PSYCHOLOGIST\",\"reason\":\"Patient has not shown in last three appointments.\"}]}}}", response.Body.String())
 
 	})
 
@@ -3484,4 +3905,4180 @@ func TestEnd2End(t *testing.T) {
 
 	})
 
+	t.Run("should keep multiple sessions open for the same user", func(t *testing.T) {
+
+		query := `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Jkl012)!@",
+				device: "Laptop"
+			}) { 
+				token 
+				refreshToken
+			} 
+		}`
+
+		response := gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+		storedVariables["no_profile_user_laptop_token"] = token
+
+		refreshToken := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "refreshToken")
+		assert.NotEqual(t, "", refreshToken)
+		storedVariables["no_profile_user_laptop_refresh_token"] = refreshToken
+
+		query = `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Jkl012)!@",
+				device: "Phone"
+			}) { 
+				token 
+				refreshToken
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		token = fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+		storedVariables["no_profile_user_phone_token"] = token
+
+		refreshToken = fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "refreshToken")
+		assert.NotEqual(t, "", refreshToken)
+		storedVariables["no_profile_user_phone_refresh_token"] = refreshToken
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_laptop_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+	})
+
+	t.Run("should refresh authentication and rotate the refresh token", func(t *testing.T) {
+
+		query := `{
+			refreshAuthentication(refreshToken: "invalid") {
+				token
+				refreshToken
+			}
+		}`
+
+		response := gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"refreshAuthentication\"]}],\"data\":null}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			refreshAuthentication(refreshToken: %q) {
+				token
+				refreshToken
+			}
+		}`, storedVariables["no_profile_user_phone_refresh_token"])
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "refreshAuthentication", "token")
+		assert.NotEqual(t, "", token)
+		assert.NotEqual(t, storedVariables["no_profile_user_phone_token"], token)
+
+		refreshToken := fastjson.GetString(response.Body.Bytes(), "data", "refreshAuthentication", "refreshToken")
+		assert.NotEqual(t, "", refreshToken)
+		assert.NotEqual(t, storedVariables["no_profile_user_phone_refresh_token"], refreshToken)
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"refreshAuthentication\"]}],\"data\":null}", response.Body.String())
+
+		storedVariables["no_profile_user_phone_token"] = token
+		storedVariables["no_profile_user_phone_refresh_token"] = refreshToken
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+	})
+
+	t.Run("should validate signed tokens by key id", func(t *testing.T) {
+
+		query := `{
+			myUser {
+				email
+			}
+		}`
+
+		tampered := strings.Split(storedVariables["no_profile_user_phone_token"], ".")
+		tampered[2] = tampered[2][1:] + tampered[2][:1]
+
+		response := gql(router, query, strings.Join(tampered, "."))
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		forgedToken, _ := tokenUtil.GenerateToken(fmt.Sprintf(`{"userId":%q,"sessionId":"any"}`, storedVariables["no_profile_user_id"]), time.Duration(60)*time.Second)
+
+		response = gql(router, query, forgedToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, `{
+			mySessions {
+				id
+				current
+			}
+		}`, storedVariables["no_profile_user_phone_token"])
+
+		sessionID := ""
+		for _, session := range fastjson.MustParse(response.Body.String()).GetArray("data", "mySessions") {
+			if session.GetBool("current") {
+				sessionID = string(session.GetStringBytes("id"))
+			}
+		}
+		assert.NotEqual(t, "", sessionID)
+
+		claims := fmt.Sprintf(`{"userId":%q,"sessionId":%q}`, storedVariables["no_profile_user_id"], sessionID)
+
+		previousKeyTokenUtil := token.HmacTokenUtil{
+			Keys:         tokenUtil.Keys,
+			CurrentKeyID: "previous",
+			LoggingUtil:  loggingUtil,
+		}
+
+		previousKeyToken, _ := previousKeyTokenUtil.GenerateToken(claims, time.Duration(60)*time.Second)
+
+		response = gql(router, query, previousKeyToken)
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+		retiredKeyTokenUtil := token.HmacTokenUtil{
+			Keys:         map[string]string{"retired": "retired-secret"},
+			CurrentKeyID: "retired",
+			LoggingUtil:  loggingUtil,
+		}
+
+		retiredKeyToken, _ := retiredKeyTokenUtil.GenerateToken(claims, time.Duration(60)*time.Second)
+
+		response = gql(router, query, retiredKeyToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		expiredToken, _ := tokenUtil.GenerateToken(claims, time.Duration(-60)*time.Second)
+
+		response = gql(router, query, expiredToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+	})
+
+	t.Run("should list own sessions", func(t *testing.T) {
+
+		query := `{
+			mySessions {
+				id
+				device
+				ipAddress
+				current
+			}
+		}`
+
+		response := gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"mySessions\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		sessions := fastjson.MustParse(response.Body.String()).GetArray("data", "mySessions")
+		assert.Equal(t, 3, len(sessions))
+
+		devices := map[string]bool{}
+		for _, session := range sessions {
+			device := string(session.GetStringBytes("device"))
+			devices[device] = session.GetBool("current")
+			assert.Equal(t, "192.0.2.1", string(session.GetStringBytes("ipAddress")))
+			if device == "Laptop" {
+				storedVariables["no_profile_user_laptop_session_id"] = string(session.GetStringBytes("id"))
+			}
+		}
+
+		assert.Equal(t, map[string]bool{"": false, "Laptop": false, "Phone": true}, devices)
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		sessions = fastjson.MustParse(response.Body.String()).GetArray("data", "mySessions")
+		for _, session := range sessions {
+			assert.NotEqual(t, storedVariables["no_profile_user_laptop_session_id"], string(session.GetStringBytes("id")))
+		}
+
+	})
+
+	t.Run("should revoke a session only if it belongs to the user", func(t *testing.T) {
+
+		query := fmt.Sprintf(`mutation {
+			revokeSession(id: %q)
+		}`, storedVariables["no_profile_user_laptop_session_id"])
+
+		response := gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"resource not found\",\"path\":[\"revokeSession\"]}],\"data\":{\"revokeSession\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, "{\"data\":{\"revokeSession\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			refreshAuthentication(refreshToken: %q) {
+				token
+			}
+		}`, storedVariables["no_profile_user_laptop_refresh_token"])
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"refreshAuthentication\"]}],\"data\":null}", response.Body.String())
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_laptop_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+		query = `{
+			mySessions {
+				device
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, 2, len(fastjson.MustParse(response.Body.String()).GetArray("data", "mySessions")))
+
+	})
+
+	t.Run("should revoke all other sessions of the user", func(t *testing.T) {
+
+		query := `mutation {
+			revokeAllOtherSessions
+		}`
+
+		response := gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, "{\"data\":{\"revokeAllOtherSessions\":null}}", response.Body.String())
+
+		query = `{
+			mySessions {
+				device
+				current
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, "{\"data\":{\"mySessions\":[{\"device\":\"Phone\",\"current\":true}]}}", response.Body.String())
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_phone_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"aaron.rodgers@psi.com.br\"}}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"patrick.mahomes@psi.com.br\"}}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			refreshAuthentication(refreshToken: %q) {
+				token
+				refreshToken
+			}
+		}`, storedVariables["no_profile_user_phone_refresh_token"])
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "refreshAuthentication", "token")
+		assert.NotEqual(t, "", token)
+
+		storedVariables["no_profile_user_token"] = token
+		storedVariables["no_profile_user_phone_token"] = token
+		storedVariables["no_profile_user_phone_refresh_token"] = fastjson.GetString(response.Body.Bytes(), "data", "refreshAuthentication", "refreshToken")
+
+	})
+
+	t.Run("should store tokens only as digests", func(t *testing.T) {
+
+		var count int64
+
+		res.OrmUtil.Db().Table("authentications").Where("refresh_token_hash = ?", storedVariables["no_profile_user_phone_refresh_token"]).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		refreshTokenHash, _ := digestUtil.Digest(storedVariables["no_profile_user_phone_refresh_token"])
+
+		res.OrmUtil.Db().Table("authentications").Where("refresh_token_hash = ?", refreshTokenHash).Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		assert.False(t, res.OrmUtil.Db().Migrator().HasColumn(&users_models.ResetPassword{}, "token"))
+
+	})
+
+	t.Run("should migrate tokens stored in plaintext", func(t *testing.T) {
+
+		type legacyResetPassword struct {
+			UserID    string `gorm:"primaryKey"`
+			Token     string `gorm:"index"`
+			IssuedAt  time.Time
+			ExpiresAt time.Time
+			Redeemed  bool
+		}
+
+		legacyToken, _ := tokenUtil.GenerateToken(storedVariables["no_profile_user_id"], time.Duration(60)*time.Second)
+
+		res.OrmUtil.Db().Migrator().DropTable(&users_models.ResetPassword{})
+		res.OrmUtil.Db().Table("reset_passwords").Migrator().CreateTable(&legacyResetPassword{})
+		res.OrmUtil.Db().Table("reset_passwords").Create(&legacyResetPassword{
+			UserID:    storedVariables["no_profile_user_id"],
+			Token:     legacyToken,
+			IssuedAt:  time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+			Redeemed:  false,
+		})
+		res.OrmUtil.Db().AutoMigrate(&users_models.ResetPassword{})
+
+		assert.True(t, res.OrmUtil.Db().Migrator().HasColumn(&users_models.ResetPassword{}, "token"))
+
+		hashErr := res.HashStoredTokensService().Execute()
+		assert.Nil(t, hashErr)
+
+		assert.False(t, res.OrmUtil.Db().Migrator().HasColumn(&users_models.ResetPassword{}, "token"))
+
+		query := fmt.Sprintf(`mutation {
+			resetPassword(input: { 
+				token: %q,
+				password: "Jkl012)!@"
+			})
+		}`, legacyToken)
+
+		response := gql(router, query, "")
+
+		assert.Equal(t, "{\"data\":{\"resetPassword\":null}}", response.Body.String())
+
+	})
+
+	t.Run("should delay and then lock authentication after failed attempts", func(t *testing.T) {
+
+		wrongQuery := `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Abc123!@#"
+			}) { 
+				token 
+			} 
+		}`
+
+		rightQuery := `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Jkl012)!@"
+			}) { 
+				token 
+			} 
+		}`
+
+		response := gql(router, wrongQuery, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, wrongQuery, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, rightQuery, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		res.OrmUtil.Db().Model(&cooldowns_models.Cooldown{}).Where("profile_id = ? AND cooldown_type = ?", storedVariables["no_profile_user_id"], cooldowns_models.LoginFailed).Update("created_at", time.Now().Add(time.Duration(-90)*time.Second))
+
+		response = gql(router, wrongQuery, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, rightQuery, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		query := `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		found := false
+		for _, mail := range *mailbox {
+			if reflect.DeepEqual(mail["to"], []string{"aaron.rodgers@psi.com.br"}) && mail["subject"] == "Acesso bloqueado ao PSI" {
+				found = true
+				break
+			}
+		}
+
+		assert.True(t, found)
+
+	})
+
+	t.Run("should list and unlock locked users only if coordinator", func(t *testing.T) {
+
+		query := `{
+			lockedUsers {
+				id
+				email
+			}
+		}`
+
+		response := gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"lockedUsers\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"lockedUsers\":[{\"id\":\"%s\",\"email\":\"aaron.rodgers@psi.com.br\"}]}}", storedVariables["no_profile_user_id"]), response.Body.String())
+
+		// the time when the lock ends is only shown to coordinators
+		response = gql(router, `{
+			lockedUsers {
+				lockedUntil
+			}
+		}`, storedVariables["coordinator_token"])
+
+		lockedUntil, lockedUntilErr := time.Parse(time.RFC3339, fastjson.GetString(response.Body.Bytes(), "data", "lockedUsers", "0", "lockedUntil"))
+		assert.Nil(t, lockedUntilErr)
+		assert.True(t, lockedUntil.After(time.Now()))
+
+		query = fmt.Sprintf(`mutation {
+			unlockUser(id: %q)
+		}`, storedVariables["no_profile_user_id"])
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"unlockUser\"]}],\"data\":{\"unlockUser\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"unlockUser\":null}}", response.Body.String())
+
+		query = `{
+			lockedUsers {
+				id
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"lockedUsers\":[]}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Jkl012)!@"
+			}) { 
+				token 
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+
+		storedVariables["no_profile_user_token"] = token
+
+	})
+
+	t.Run("should lock authentication from an ip address after failed attempts", func(t *testing.T) {
+
+		gqlFromIPAddress := func(query string, remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+			body := fmt.Sprintf(`{"query": %q}`, query)
+			request := httptest.NewRequest(http.MethodPost, "/gql", strings.NewReader(body))
+			request.RemoteAddr = remoteAddr
+			request.Header["Content-Type"] = []string{"application/json"}
+			request.Header["X-Forwarded-For"] = []string{forwardedFor}
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+			return response
+		}
+
+		for i := int64(0); i < res.MaxLoginFailuresPerIPAddress; i++ {
+			query := fmt.Sprintf(`{
+				authenticateUser(input: { 
+					email: "nobody.%d@psi.com.br", 
+					password: "Abc123!@#"
+				}) { 
+					token 
+				} 
+			}`, i)
+
+			response := gqlFromIPAddress(query, "10.0.0.2:4321", "198.51.100.7, 10.0.0.3")
+
+			assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+		}
+
+		query := `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Jkl012)!@"
+			}) { 
+				token 
+			} 
+		}`
+
+		response := gqlFromIPAddress(query, "10.0.0.2:4321", "198.51.100.7, 10.0.0.3")
+
+		assert.Contains(t, response.Body.String(), "authentication is blocked for this ip address until")
+
+		// clients that are not trusted proxies cannot choose the address that is checked
+		response = gqlFromIPAddress(query, "203.0.113.9:4321", "198.51.100.7")
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+
+		// behind a trusted proxy, the addresses that the client itself adds to the chain are ignored
+		response = gqlFromIPAddress(query, "10.0.0.2:4321", "198.51.100.8, 198.51.100.7")
+
+		assert.Contains(t, response.Body.String(), "authentication is blocked for this ip address until")
+
+		response = gql(router, query, "")
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+
+	})
+
+	t.Run("should set up two factor authentication", func(t *testing.T) {
+
+		query := `mutation {
+			setupTwoFactor {
+				secret
+				provisioningUri
+				recoveryCodes
+			}
+		}`
+
+		response := gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"setupTwoFactor\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		secret := fastjson.GetString(response.Body.Bytes(), "data", "setupTwoFactor", "secret")
+		assert.NotEqual(t, "", secret)
+		storedVariables["no_profile_user_otp_secret"] = secret
+
+		provisioningURI := fastjson.GetString(response.Body.Bytes(), "data", "setupTwoFactor", "provisioningUri")
+		assert.Equal(t, fmt.Sprintf("otpauth://totp/PSI:aaron.rodgers@psi.com.br?algorithm=SHA1&digits=6&issuer=PSI&period=30&secret=%s", secret), provisioningURI)
+
+		recoveryCodes := fastjson.MustParse(response.Body.String()).GetArray("data", "setupTwoFactor", "recoveryCodes")
+		assert.Equal(t, 10, len(recoveryCodes))
+		storedVariables["no_profile_user_recovery_code"] = string(recoveryCodes[0].GetStringBytes())
+		storedVariables["no_profile_user_second_recovery_code"] = string(recoveryCodes[1].GetStringBytes())
+
+		query = `mutation {
+			confirmTwoFactor(code: "invalid")
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid two factor code\",\"path\":[\"confirmTwoFactor\"]}],\"data\":{\"confirmTwoFactor\":null}}", response.Body.String())
+
+		// the code of the previous step is still accepted, which leaves the current and the next steps for the tests that follow
+		code, _ := otpUtil.GenerateCode(secret, time.Now().Add(time.Duration(-30)*time.Second))
+
+		query = fmt.Sprintf(`mutation {
+			confirmTwoFactor(code: %q)
+		}`, code)
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"data\":{\"confirmTwoFactor\":null}}", response.Body.String())
+
+		query = `mutation {
+			setupTwoFactor {
+				secret
+			}
+		}`
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"two factor authentication is already enabled\",\"path\":[\"setupTwoFactor\"]}],\"data\":null}", response.Body.String())
+
+	})
+
+	t.Run("should require a two factor code to log in when enabled", func(t *testing.T) {
+
+		authenticate := func(otpCode string) *httptest.ResponseRecorder {
+			query := fmt.Sprintf(`{
+				authenticateUser(input: { 
+					email: "aaron.rodgers@psi.com.br", 
+					password: "Jkl012)!@",
+					otpCode: %q
+				}) { 
+					token 
+					twoFactorPending
+				} 
+			}`, otpCode)
+
+			return gql(router, query, "")
+		}
+
+		response := authenticate("")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"two factor code required\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		response = authenticate("invalid")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid two factor code\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		code, _ := otpUtil.GenerateCode(storedVariables["no_profile_user_otp_secret"], time.Now())
+
+		response = authenticate(code)
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+		assert.False(t, fastjson.GetBool(response.Body.Bytes(), "data", "authenticateUser", "twoFactorPending"))
+
+		// a code that was already used cannot be replayed, even if it is still in the time window
+		response = authenticate(code)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid two factor code\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		response = authenticate(storedVariables["no_profile_user_recovery_code"])
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+
+		response = authenticate(storedVariables["no_profile_user_recovery_code"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid two factor code\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		code, _ = otpUtil.GenerateCode(storedVariables["no_profile_user_otp_secret"], time.Now().Add(time.Duration(30)*time.Second))
+
+		response = authenticate(code)
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+
+		storedVariables["no_profile_user_token"] = token
+
+	})
+
+	t.Run("should disable two factor authentication", func(t *testing.T) {
+
+		query := `mutation {
+			disableTwoFactor(code: "invalid")
+		}`
+
+		response := gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid two factor code\",\"path\":[\"disableTwoFactor\"]}],\"data\":{\"disableTwoFactor\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			disableTwoFactor(code: %q)
+		}`, storedVariables["no_profile_user_second_recovery_code"])
+
+		response = gql(router, query, storedVariables["no_profile_user_token"])
+
+		assert.Equal(t, "{\"data\":{\"disableTwoFactor\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "aaron.rodgers@psi.com.br", 
+				password: "Jkl012)!@"
+			}) { 
+				token 
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+
+	})
+
+	t.Run("should make two factor authentication mandatory for roles in the policy", func(t *testing.T) {
+
+		policyRes := &resolvers.Resolver{
+			DigestUtil:                   digestUtil,
+			HashUtil:                     hashUtil,
+			IdentifierUtil:               identifierUtil,
+			MailUtil:                     mailUtil,
+			MatchUtil:                    matchUtil,
+			OrmUtil:                      &ormUtil,
+			OtpUtil:                      otpUtil,
+			SerializingUtil:              serializingUtil,
+			TokenUtil:                    tokenUtil,
+			ExpireAuthTokenDuration:      res.ExpireAuthTokenDuration,
+			ExpireRefreshTokenDuration:   res.ExpireRefreshTokenDuration,
+			LoginFailedCooldownDuration:  res.LoginFailedCooldownDuration,
+			LoginFailedDelayDuration:     res.LoginFailedDelayDuration,
+			LoginLockedCooldownDuration:  res.LoginLockedCooldownDuration,
+			MaxLoginFailuresPerUser:      res.MaxLoginFailuresPerUser,
+			MaxLoginFailuresPerIPAddress: res.MaxLoginFailuresPerIPAddress,
+			RecoveryCodesQuantity:        res.RecoveryCodesQuantity,
+			TwoFactorRequiredRoles:       []users_models.Role{users_models.Psychologist},
+		}
+
+		policyRouter := graph.CreateServer(policyRes)
+
+		query := `{
+			authenticateUser(input: { 
+				email: "tom.brady@psi.com.br", 
+				password: "Def456$%^"
+			}) { 
+				token 
+				refreshToken
+				twoFactorPending
+			} 
+		}`
+
+		response := gql(policyRouter, query, "")
+
+		pendingToken := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		refreshToken := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "refreshToken")
+		assert.True(t, fastjson.GetBool(response.Body.Bytes(), "data", "authenticateUser", "twoFactorPending"))
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(policyRouter, query, pendingToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		query = `mutation {
+			setupTwoFactor {
+				secret
+			}
+		}`
+
+		response = gql(policyRouter, query, pendingToken)
+
+		secret := fastjson.GetString(response.Body.Bytes(), "data", "setupTwoFactor", "secret")
+		assert.NotEqual(t, "", secret)
+
+		code, _ := otpUtil.GenerateCode(secret, time.Now())
+
+		query = fmt.Sprintf(`mutation {
+			confirmTwoFactor(code: %q)
+		}`, code)
+
+		response = gql(policyRouter, query, pendingToken)
+
+		assert.Equal(t, "{\"data\":{\"confirmTwoFactor\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			refreshAuthentication(refreshToken: %q) {
+				token
+				twoFactorPending
+			}
+		}`, refreshToken)
+
+		response = gql(policyRouter, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "refreshAuthentication", "token")
+		assert.False(t, fastjson.GetBool(response.Body.Bytes(), "data", "refreshAuthentication", "twoFactorPending"))
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(policyRouter, query, token)
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"tom.brady@psi.com.br\"}}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			disableTwoFactor(code: %q)
+		}`, code)
+
+		response = gql(policyRouter, query, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"two factor authentication is mandatory for this role\",\"path\":[\"disableTwoFactor\"]}],\"data\":{\"disableTwoFactor\":null}}", response.Body.String())
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid two factor code\",\"path\":[\"disableTwoFactor\"]}],\"data\":{\"disableTwoFactor\":null}}", response.Body.String())
+
+		code, _ = otpUtil.GenerateCode(secret, time.Now().Add(time.Duration(30)*time.Second))
+
+		query = fmt.Sprintf(`mutation {
+			disableTwoFactor(code: %q)
+		}`, code)
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"disableTwoFactor\":null}}", response.Body.String())
+
+	})
+
+	t.Run("should log out from the session being used", func(t *testing.T) {
+
+		query := `mutation {
+			createUserWithPassword(
+			  input: {
+				email: "joe.montana@psi.com.br"
+				password: "Xyz*()890"
+				role: "PATIENT"
+			  }
+			)
+		}`
+
+		response := gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "joe.montana@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token 
+				refreshToken
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		refreshToken := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "refreshToken")
+
+		response = gql(router, query, "")
+
+		storedVariables["joe_montana_token"] = fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		query = `mutation {
+			logout
+		}`
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"logout\"]}],\"data\":{\"logout\":null}}", response.Body.String())
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"logout\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			refreshAuthentication(refreshToken: %q) {
+				token
+			}
+		}`, refreshToken)
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"refreshAuthentication\"]}],\"data\":null}", response.Body.String())
+
+		query = `{
+			mySessions {
+				current
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"mySessions\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"data\":{\"mySessions\":[{\"current\":true}]}}", response.Body.String())
+
+	})
+
+	t.Run("should change password and end other sessions", func(t *testing.T) {
+
+		query := `{
+			authenticateUser(input: { 
+				email: "joe.montana@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token
+				refreshToken
+			} 
+		}`
+
+		response := gql(router, query, "")
+
+		otherToken := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		otherRefreshToken := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "refreshToken")
+
+		query = `mutation {
+			changeMyPassword(input: {
+				currentPassword: "Wrong*()890",
+				newPassword: "Uvw&*(789"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"changeMyPassword\"]}],\"data\":{\"changeMyPassword\":null}}", response.Body.String())
+
+		query = `mutation {
+			changeMyPassword(input: {
+				currentPassword: "Xyz*()890",
+				newPassword: "weak"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"weak password\",\"path\":[\"changeMyPassword\"],\"extensions\":{\"failedRules\":[\"MIN_LENGTH\",\"UPPERCASE\",\"DIGIT\",\"SYMBOL\"]}}],\"data\":{\"changeMyPassword\":null}}", response.Body.String())
+
+		query = `mutation {
+			changeMyPassword(input: {
+				currentPassword: "Xyz*()890",
+				newPassword: "Uvw&*(789"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"data\":{\"changeMyPassword\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			refreshAuthentication(refreshToken: %q) {
+				token
+			}
+		}`, otherRefreshToken)
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"refreshAuthentication\"]}],\"data\":null}", response.Body.String())
+
+		query = `{
+			mySessions {
+				current
+			}
+		}`
+
+		response = gql(router, query, otherToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"mySessions\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"data\":{\"mySessions\":[{\"current\":true}]}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "joe.montana@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "joe.montana@psi.com.br", 
+				password: "Uvw&*(789"
+			}) { 
+				token
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+
+	})
+
+	t.Run("should change email only after confirmation sent to the new email", func(t *testing.T) {
+
+		query := `mutation {
+			changeMyEmail(input: {
+				currentPassword: "Xyz*()890",
+				newEmail: "joe.montana@psi.com"
+			})
+		}`
+
+		response := gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"changeMyEmail\"]}],\"data\":{\"changeMyEmail\":null}}", response.Body.String())
+
+		query = `mutation {
+			changeMyEmail(input: {
+				currentPassword: "Uvw&*(789",
+				newEmail: "tom.brady@psi.com.br"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"user with same email already exists\",\"path\":[\"changeMyEmail\"]}],\"data\":{\"changeMyEmail\":null}}", response.Body.String())
+
+		query = `mutation {
+			changeMyEmail(input: {
+				currentPassword: "Uvw&*(789",
+				newEmail: "joe.montana@psi.com"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"data\":{\"changeMyEmail\":null}}", response.Body.String())
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"joe.montana@psi.com.br\"}}}", response.Body.String())
+
+		query = `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		var mailBody string
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		for _, mail := range *mailbox {
+			if reflect.DeepEqual(mail["to"], []string{"joe.montana@psi.com"}) && mail["subject"] == "Confirme seu novo email no PSI" {
+				mailBody = mail["body"].(string)
+				break
+			}
+		}
+
+		regex := regexp.MustCompile("token=(?P<token>[^\"]+)")
+		match := regex.FindStringSubmatch(mailBody)
+
+		query = `mutation {
+			confirmEmailChange(token: "invalid")
+		}`
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"confirmEmailChange\"]}],\"data\":{\"confirmEmailChange\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			confirmEmailChange(token: %q)
+		}`, match[1])
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"data\":{\"confirmEmailChange\":null}}", response.Body.String())
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"confirmEmailChange\"]}],\"data\":{\"confirmEmailChange\":null}}", response.Body.String())
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, storedVariables["joe_montana_token"])
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"joe.montana@psi.com\"}}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "joe.montana@psi.com", 
+				password: "Uvw&*(789"
+			}) { 
+				token
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token"))
+
+	})
+
+	t.Run("should delete own account anonymizing personal data", func(t *testing.T) {
+
+		query := `mutation {
+			createUserWithPassword(
+			  input: {
+				email: "dan.marino@psi.com.br"
+				password: "Xyz*()890"
+				role: "PATIENT"
+			  }
+			)
+		}`
+
+		response := gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "dan.marino@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token 
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		query = `mutation {
+			upsertMyPatientProfile(input: {
+				fullName: "Daniel Constantine Marino Jr."
+				likeName: "Dan",
+				birthDate: "1961-09-15T00:00:00Z",
+				city: "Miami - FL"
+			})
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPatientProfile\":null}}", response.Body.String())
+
+		query = `mutation {
+			setMyPatientCharacteristicChoices(input: [
+				{
+					characteristicName: "has-consulted-before",
+					selectedValues: [
+						"true"
+					]
+				}
+			])
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"setMyPatientCharacteristicChoices\":null}}", response.Body.String())
+
+		query = `mutation {
+			setMyPatientPreferences(input: [
+				{
+					characteristicName: "gender",
+					selectedValue: "female",
+					weight: 4
+				}
+			])
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"setMyPatientPreferences\":null}}", response.Body.String())
+
+		query = `{
+			myPatientProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		patientID := fastjson.GetString(response.Body.Bytes(), "data", "myPatientProfile", "id")
+
+		query = `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		startDate := time.Now()
+
+		res.OrmUtil.Db().Create(&treatments_models.Treatment{
+			ID:             "dan-marino-treatment",
+			PsychologistID: psychologistID,
+			PatientID:      patientID,
+			Frequency:      1,
+			Phase:          0,
+			Duration:       3600,
+			PriceRangeName: "low",
+			Status:         treatments_models.Active,
+			StartDate:      &startDate,
+		})
+
+		res.OrmUtil.Db().Create(&appointments_models.Appointment{
+			ID:             "dan-marino-past-appointment",
+			TreatmentID:    "dan-marino-treatment",
+			PatientID:      patientID,
+			PsychologistID: psychologistID,
+			Start:          time.Now().Add(-48 * time.Hour),
+			End:            time.Now().Add(-47 * time.Hour),
+			PriceRangeName: "low",
+			Status:         appointments_models.CanceledByPatient,
+			Reason:         "Estou com crises de ansiedade",
+		})
+
+		res.OrmUtil.Db().Create(&appointments_models.Appointment{
+			ID:             "dan-marino-future-appointment",
+			TreatmentID:    "dan-marino-treatment",
+			PatientID:      patientID,
+			PsychologistID: psychologistID,
+			Start:          time.Now().Add(48 * time.Hour),
+			End:            time.Now().Add(49 * time.Hour),
+			PriceRangeName: "low",
+			Status:         appointments_models.ConfirmedByBoth,
+		})
+
+		query = `mutation {
+			deleteMyAccount(currentPassword: "Wrong*()890")
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"deleteMyAccount\"]}],\"data\":{\"deleteMyAccount\":null}}", response.Body.String())
+
+		query = `mutation {
+			deleteMyAccount(currentPassword: "Xyz*()890")
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"deleteMyAccount\":null}}", response.Body.String())
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "dan.marino@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token 
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"authenticateUser\"]}],\"data\":null}", response.Body.String())
+
+		user := users_models.User{}
+		res.OrmUtil.Db().Unscoped().Where("email = ?", "dan.marino@psi.com.br").Limit(1).Find(&user)
+		assert.Equal(t, "", user.ID)
+
+		patient := profiles_models.Patient{}
+		res.OrmUtil.Db().Unscoped().Where("id = ?", patientID).Limit(1).Find(&patient)
+		assert.Equal(t, "", patient.FullName)
+		assert.Equal(t, "", patient.LikeName)
+		assert.Equal(t, "", patient.City)
+		assert.True(t, patient.BirthDate.IsZero())
+		assert.True(t, patient.DeletedAt.Valid)
+
+		var choices int64
+		res.OrmUtil.Db().Model(&characteristics_models.CharacteristicChoice{}).Where("profile_id = ?", patientID).Count(&choices)
+		assert.Equal(t, int64(0), choices)
+
+		var preferences int64
+		res.OrmUtil.Db().Model(&characteristics_models.Preference{}).Where("profile_id = ?", patientID).Count(&preferences)
+		assert.Equal(t, int64(0), preferences)
+
+		treatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("id = ?", "dan-marino-treatment").Limit(1).Find(&treatment)
+		assert.Equal(t, treatments_models.InterruptedByPatient, treatment.Status)
+		assert.Equal(t, "", treatment.Reason)
+
+		appointments := []*appointments_models.Appointment{}
+		res.OrmUtil.Db().Where("treatment_id = ?", "dan-marino-treatment").Order("start ASC").Find(&appointments)
+		assert.Equal(t, 2, len(appointments))
+		assert.Equal(t, appointments_models.CanceledByPatient, appointments[0].Status)
+		assert.Equal(t, "", appointments[0].Reason)
+		assert.Equal(t, appointments_models.TreatmentInterruptedByPatient, appointments[1].Status)
+		assert.Equal(t, "", appointments[1].Reason)
+
+	})
+
+	t.Run("should erase another user only if coordinator", func(t *testing.T) {
+
+		query := `mutation {
+			createUserWithPassword(
+			  input: {
+				email: "steve.young@psi.com.br"
+				password: "Xyz*()890"
+				role: "PSYCHOLOGIST"
+			  }
+			)
+		}`
+
+		response := gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "steve.young@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token 
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		query = `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Jon Steven Young"
+				likeName: "Steve Young",
+				birthDate: "1961-10-11T00:00:00Z",
+				city: "San Francisco - CA",
+				bio: "Hey there, my name is Steve",
+				crp: "01/654321",
+				whatsapp: "(11) 9876-5432",
+				instagram: "@steveyoung"
+			})
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		query = `{
+			myUser {
+				id
+			}
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		userID := fastjson.GetString(response.Body.Bytes(), "data", "myUser", "id")
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		res.OrmUtil.Db().Create(&treatments_models.Treatment{
+			ID:             "steve-young-treatment",
+			PsychologistID: psychologistID,
+			Frequency:      1,
+			Phase:          0,
+			Duration:       3600,
+			PriceRangeName: "low",
+			Status:         treatments_models.Pending,
+		})
+
+		query = fmt.Sprintf(`mutation {
+			eraseUser(id: %q)
+		}`, userID)
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"eraseUser\"]}],\"data\":{\"eraseUser\":null}}", response.Body.String())
+
+		erasedAt := time.Now()
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"eraseUser\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"resource not found\",\"path\":[\"eraseUser\"]}],\"data\":{\"eraseUser\":null}}", response.Body.String())
+
+		query = `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myPsychologistProfile\"]}],\"data\":{\"myPsychologistProfile\":null}}", response.Body.String())
+
+		auditEntries := []*audits_models.AuditEntry{}
+		res.OrmUtil.Db().Where("(actor_id = ? OR target_ids = ?) AND created_at < ?", userID, userID, erasedAt).Find(&auditEntries)
+		assert.Less(t, 1, len(auditEntries))
+		for _, entry := range auditEntries {
+			assert.Equal(t, "", entry.Arguments)
+			assert.Equal(t, "", entry.IPAddress)
+		}
+
+		psychologist := profiles_models.Psychologist{}
+		res.OrmUtil.Db().Unscoped().Where("id = ?", psychologistID).Limit(1).Find(&psychologist)
+		assert.Equal(t, "", psychologist.FullName)
+		assert.Equal(t, "", psychologist.Crp)
+		assert.Equal(t, "", psychologist.Whatsapp)
+		assert.Equal(t, "", psychologist.Instagram)
+		assert.Equal(t, "", psychologist.Bio)
+		assert.True(t, psychologist.DeletedAt.Valid)
+
+		var pendingTreatments int64
+		res.OrmUtil.Db().Model(&treatments_models.Treatment{}).Where("psychologist_id = ?", psychologistID).Count(&pendingTreatments)
+		assert.Equal(t, int64(0), pendingTreatments)
+
+		user := users_models.User{}
+		res.OrmUtil.Db().Unscoped().Where("id = ?", userID).Limit(1).Find(&user)
+		assert.Equal(t, "", user.Email)
+		assert.Equal(t, "", user.Password)
+		assert.True(t, user.DeletedAt.Valid)
+
+	})
+
+	t.Run("should export own personal data through a link sent by email", func(t *testing.T) {
+
+		query := `mutation {
+			exportMyData
+		}`
+
+		response := gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"exportMyData\"]}],\"data\":{\"exportMyData\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"data\":{\"exportMyData\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"data\":{\"exportMyData\":null}}", response.Body.String())
+
+		var pendingExports int64
+		res.OrmUtil.Db().Model(&users_models.DataExport{}).Where("processed = ?", false).Count(&pendingExports)
+		assert.Equal(t, int64(1), pendingExports)
+
+		query = `mutation {
+			processPendingDataExports
+		}`
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"processPendingDataExports\"]}],\"data\":{\"processPendingDataExports\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingDataExports\":null}}", response.Body.String())
+
+		query = `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		var mailBody string
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		for _, mail := range *mailbox {
+			if reflect.DeepEqual(mail["to"], []string{"patrick.mahomes@psi.com.br"}) && mail["subject"] == "Seus dados pessoais no PSI" {
+				mailBody = mail["body"].(string)
+				break
+			}
+		}
+
+		regex := regexp.MustCompile("/meus-dados\\?token=(?P<token>[^\"]+)")
+		match := regex.FindStringSubmatch(mailBody)
+
+		download := func(path string, authorization string) *httptest.ResponseRecorder {
+			request := httptest.NewRequest(http.MethodGet, path, nil)
+			request.Header["Authorization"] = []string{authorization}
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+			return response
+		}
+
+		response = download("/exports/invalid", storedVariables["patient_token"])
+
+		assert.Equal(t, 404, response.Code)
+
+		// the token in the mail is not enough without the session of the user that requested the copy
+		response = download(fmt.Sprintf("/exports/%s", match[1]), "")
+
+		assert.Equal(t, 403, response.Code)
+
+		response = download(fmt.Sprintf("/exports/%s", match[1]), storedVariables["coordinator_token"])
+
+		assert.Equal(t, 404, response.Code)
+
+		// the copy is not stored with the public files
+		export := users_models.DataExport{}
+		res.OrmUtil.Db().Where("processed = ?", true).Limit(1).Find(&export)
+		assert.NotEqual(t, "", export.FileName)
+
+		response = download(fmt.Sprintf("/static/%s", export.FileName), storedVariables["coordinator_token"])
+
+		assert.Equal(t, 404, response.Code)
+
+		response = download(fmt.Sprintf("/exports/%s", match[1]), storedVariables["patient_token"])
+
+		assert.Equal(t, 200, response.Code)
+		assert.Equal(t, "application/zip", response.Header().Get("Content-Type"))
+
+		reader, zipErr := zip.NewReader(bytes.NewReader(response.Body.Bytes()), int64(response.Body.Len()))
+		assert.Equal(t, nil, zipErr)
+
+		contents := map[string]string{}
+		for _, file := range reader.File {
+			fileReader, _ := file.Open()
+			data, _ := io.ReadAll(fileReader)
+			contents[file.Name] = string(data)
+		}
+
+		for _, name := range []string{"agreements.json", "appointments.json", "characteristic_choices.json", "cooldowns.json", "mails.json", "patient_profile.json", "preferences.json", "sessions.json", "treatments.json", "user.json"} {
+			_, exists := contents[name]
+			assert.True(t, exists, name)
+		}
+
+		assert.Equal(t, "patrick.mahomes@psi.com.br", fastjson.GetString([]byte(contents["user.json"]), "email"))
+		assert.NotContains(t, contents["user.json"], "password")
+		assert.NotContains(t, contents["sessions.json"], storedVariables["patient_token"])
+		assert.NotEqual(t, "", fastjson.GetString([]byte(contents["patient_profile.json"]), "fullName"))
+		assert.Contains(t, contents["mails.json"], "patrick.mahomes@psi.com.br")
+
+		res.OrmUtil.Db().Model(&users_models.DataExport{}).Where("processed = ?", true).Update("expires_at", time.Now().Add(-time.Second))
+
+		response = download(fmt.Sprintf("/exports/%s", match[1]), storedVariables["patient_token"])
+
+		assert.Equal(t, 404, response.Code)
+
+		query = `mutation {
+			processPendingDataExports
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingDataExports\":null}}", response.Body.String())
+
+		var exports int64
+		res.OrmUtil.Db().Model(&users_models.DataExport{}).Count(&exports)
+		assert.Equal(t, int64(0), exports)
+
+	})
+
+	t.Run("should manage the permissions of roles only if coordinator", func(t *testing.T) {
+
+		query := `{
+			permissions
+		}`
+
+		response := gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"permissions\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		permissions := fastjson.MustParse(response.Body.String()).GetArray("data", "permissions")
+		assert.Equal(t, len(users_models.DefaultRolePermissions), len(permissions))
+
+		query = `{
+			rolePermissions(role: "PATIENT")
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"rolePermissions\":[\"account:manage\",\"affinities:read-own\",\"agreements:sign-as-patient\",\"appointments:manage-as-patient\",\"characteristics:read\",\"holidays:read\",\"patient-profiles:manage-own\",\"psychologist-profiles:read\",\"terms:read-patient\",\"treatments:assign\",\"treatments:interrupt-as-patient\",\"two-factor:setup\"]}}", response.Body.String())
+
+		characteristicsQuery := `{
+			patientCharacteristics {
+				name
+			}
+		}`
+
+		response = gql(router, characteristicsQuery, storedVariables["patient_token"])
+
+		assert.NotContains(t, response.Body.String(), "errors")
+
+		query = `mutation {
+			setRolePermissions(role: "PATIENT", permissions: ["account:manage", "unknown:permission"])
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"permission unknown:permission not found\",\"path\":[\"setRolePermissions\"]}],\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		query = `mutation {
+			setRolePermissions(role: "COORDINATOR", permissions: ["account:manage", "users:read"])
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"coordinators cannot lose the permission to manage permissions\",\"path\":[\"setRolePermissions\"]}],\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		query = `mutation {
+			setRolePermissions(role: "PATIENT", permissions: ["account:manage", "patient-profiles:manage-own"])
+		}`
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"setRolePermissions\"]}],\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		response = gql(router, characteristicsQuery, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"patientCharacteristics\"]}],\"data\":null}", response.Body.String())
+
+		seedErr := res.SeedPermissionsService().Execute()
+		assert.Equal(t, nil, seedErr)
+
+		response = gql(router, characteristicsQuery, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"patientCharacteristics\"]}],\"data\":null}", response.Body.String())
+
+		query = `mutation {
+			setRolePermissions(role: "PATIENT", permissions: ["account:manage", "affinities:read-own", "agreements:sign-as-patient", "appointments:manage-as-patient", "characteristics:read", "patient-profiles:manage-own", "psychologist-profiles:read", "terms:read-patient", "treatments:assign", "treatments:interrupt-as-patient"])
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		response = gql(router, characteristicsQuery, storedVariables["patient_token"])
+
+		assert.NotContains(t, response.Body.String(), "errors")
+
+	})
+
+	t.Run("should create roles and grant them permissions only if coordinator", func(t *testing.T) {
+
+		rolesQuery := `{
+			roles
+		}`
+
+		response := gql(router, rolesQuery, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"roles\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, rolesQuery, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"roles\":[\"COORDINATOR\",\"JOBRUNNER\",\"PATIENT\",\"PSYCHOLOGIST\"]}}", response.Body.String())
+
+		query := `mutation {
+			createRole(name: "SUPERVISOR")
+		}`
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"createRole\"]}],\"data\":{\"createRole\":null}}", response.Body.String())
+
+		response = gql(router, `mutation {
+			createRole(name: "supervisor")
+		}`, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"role names must have only uppercase letters and underscores\",\"path\":[\"createRole\"]}],\"data\":{\"createRole\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createRole\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"role already exists\",\"path\":[\"createRole\"]}],\"data\":{\"createRole\":null}}", response.Body.String())
+
+		response = gql(router, rolesQuery, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"roles\":[\"COORDINATOR\",\"JOBRUNNER\",\"PATIENT\",\"PSYCHOLOGIST\",\"SUPERVISOR\"]}}", response.Body.String())
+
+		// roles that were not registered cannot be given to users nor receive permissions
+		query = `mutation {
+			setRolePermissions(role: "RECEPTIONIST", permissions: ["account:manage"])
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"role not found\",\"path\":[\"setRolePermissions\"]}],\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		query = `mutation {
+			createUserWithPassword(input: {
+				email: "bill.cowher@psi.com.br"
+				password: "Xyz*()890"
+				role: "RECEPTIONIST"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"role not found\",\"path\":[\"createUserWithPassword\"]}],\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `mutation {
+			setRolePermissions(role: "SUPERVISOR", permissions: ["account:manage", "users:list"])
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"setRolePermissions\":null}}", response.Body.String())
+
+		query = `mutation {
+			createUserWithPassword(input: {
+				email: "bill.cowher@psi.com.br"
+				password: "Xyz*()890"
+				role: "SUPERVISOR"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: {
+				email: "bill.cowher@psi.com.br",
+				password: "Xyz*()890"
+			}) {
+				token
+			}
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		query = `{
+			myUser {
+				role
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"role\":\"SUPERVISOR\"}}}", response.Body.String())
+
+		query = `{
+			users(filter: { role: "SUPERVISOR" }, first: 10) {
+				totalCount
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"users\":{\"totalCount\":1}}}", response.Body.String())
+
+		response = gql(router, `{
+			patientCharacteristics {
+				name
+			}
+		}`, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"patientCharacteristics\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, `{
+			myUser {
+				id
+			}
+		}`, token)
+
+		query = fmt.Sprintf(`mutation {
+			eraseUser(id: %q)
+		}`, fastjson.GetString(response.Body.Bytes(), "data", "myUser", "id"))
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"eraseUser\":null}}", response.Body.String())
+
+	})
+
+	t.Run("should record mutations and reads of patient profiles in the audit log", func(t *testing.T) {
+
+		query := `mutation {
+			changeMyPassword(input: { currentPassword: "Wrong123!@#", newPassword: "Abc123!@#" })
+		}`
+
+		response := gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"incorrect credentials\",\"path\":[\"changeMyPassword\"]}],\"data\":{\"changeMyPassword\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			auditLog(filter: { actorId: %q, operation: "changeMyPassword" }, offset: 0, limit: 10) {
+				totalCount
+				entries {
+					actorRole
+					operation
+					targetIds
+					arguments
+					ipAddress
+					outcome
+					error
+				}
+			}
+		}`, storedVariables["patient_id"])
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"auditLog\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"auditLog\":{\"totalCount\":1,\"entries\":[{\"actorRole\":\"PATIENT\",\"operation\":\"changeMyPassword\",\"targetIds\":[],\"arguments\":\"{\\\"input\\\":{\\\"currentPassword\\\":\\\"[REDACTED]\\\",\\\"newPassword\\\":\\\"[REDACTED]\\\"}}\",\"ipAddress\":\"192.0.2.1\",\"outcome\":\"FAILURE\",\"error\":\"incorrect credentials\"}]}}}", response.Body.String())
+
+		query = `{
+			myPatientProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		patientProfileID := fastjson.GetString(response.Body.Bytes(), "data", "myPatientProfile", "id")
+
+		query = fmt.Sprintf(`{
+			patientProfile(id: %q) {
+				id
+			}
+		}`, patientProfileID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"patientProfile\":{\"id\":%q}}}", patientProfileID), response.Body.String())
+
+		query = fmt.Sprintf(`{
+			auditLog(filter: { targetId: %q, operation: "patientProfile" }, offset: 0, limit: 10) {
+				totalCount
+				entries {
+					actorId
+					actorRole
+					targetIds
+					outcome
+				}
+			}
+		}`, patientProfileID)
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"auditLog\":{\"totalCount\":1,\"entries\":[{\"actorId\":%q,\"actorRole\":\"PSYCHOLOGIST\",\"targetIds\":[%q],\"outcome\":\"SUCCESS\"}]}}}", storedVariables["psychologist_id"], patientProfileID), response.Body.String())
+
+		// wildcards of LIKE are matched literally
+		query = `{
+			auditLog(filter: { targetId: "%", operation: "patientProfile" }, offset: 0, limit: 10) {
+				totalCount
+			}
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"auditLog\":{\"totalCount\":0}}}", response.Body.String())
+
+		query = `{
+			auditLog(filter: { outcome: FAILURE }, offset: 1, limit: 2) {
+				totalCount
+				entries {
+					outcome
+				}
+			}
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		totalCount := fastjson.GetInt(response.Body.Bytes(), "data", "auditLog", "totalCount")
+		assert.Less(t, 3, totalCount)
+		assert.Equal(t, 2, len(fastjson.MustParse(response.Body.String()).GetArray("data", "auditLog", "entries")))
+		assert.NotContains(t, response.Body.String(), "SUCCESS")
+
+		query = `{
+			auditLog(offset: 0, limit: 1000) {
+				totalCount
+			}
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"limit is out of range\",\"path\":[\"auditLog\"]}],\"data\":null}", response.Body.String())
+
+	})
+
+	t.Run("should impersonate patients and psychologists only if coordinator", func(t *testing.T) {
+
+		query := fmt.Sprintf(`mutation {
+			impersonateUser(id: %q, reason: "Bug report about the profile page") {
+				token
+			}
+		}`, storedVariables["patient_id"])
+
+		response := gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"impersonateUser\"]}],\"data\":null}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			impersonateUser(id: %q, reason: " ") {
+				token
+			}
+		}`, storedVariables["patient_id"])
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"reason is required\",\"path\":[\"impersonateUser\"]}],\"data\":null}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			impersonateUser(id: %q, reason: "Bug report about the profile page") {
+				token
+			}
+		}`, storedVariables["coordinator_id"])
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"users cannot impersonate themselves\",\"path\":[\"impersonateUser\"]}],\"data\":null}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			impersonateUser(id: %q, reason: "Bug report about the profile page") {
+				token
+			}
+		}`, storedVariables["patient_id"])
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		impersonationToken := fastjson.GetString(response.Body.Bytes(), "data", "impersonateUser", "token")
+		assert.NotEqual(t, "", impersonationToken)
+
+		query = `{
+			myUser {
+				email
+			}
+		}`
+
+		response = gql(router, query, impersonationToken)
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"patrick.mahomes@psi.com.br\"}}}", response.Body.String())
+
+		query = `mutation {
+			changeMyPassword(input: { currentPassword: "Abc123!@#", newPassword: "Abc123!@#" })
+		}`
+
+		response = gql(router, query, impersonationToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden while impersonating\",\"path\":[\"changeMyPassword\"]}],\"data\":{\"changeMyPassword\":null}}", response.Body.String())
+
+		query = `mutation {
+			revokeSession(id: "unknown-session")
+		}`
+
+		response = gql(router, query, impersonationToken)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"resource not found\",\"path\":[\"revokeSession\"]}],\"data\":{\"revokeSession\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`{
+			auditLog(filter: { impersonatorId: %q }, offset: 0, limit: 10) {
+				totalCount
+				entries {
+					actorId
+					operation
+					outcome
+					error
+				}
+			}
+		}`, storedVariables["coordinator_id"])
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"auditLog\":{\"totalCount\":3,\"entries\":[{\"actorId\":%q,\"operation\":\"revokeSession\",\"outcome\":\"FAILURE\",\"error\":\"resource not found\"},{\"actorId\":%q,\"operation\":\"changeMyPassword\",\"outcome\":\"FAILURE\",\"error\":\"forbidden while impersonating\"},{\"actorId\":%q,\"operation\":\"myUser\",\"outcome\":\"SUCCESS\",\"error\":\"\"}]}}}", storedVariables["patient_id"], storedVariables["patient_id"], storedVariables["patient_id"]), response.Body.String())
+
+		query = fmt.Sprintf(`{
+			auditLog(filter: { actorId: %q, operation: "impersonateUser", outcome: SUCCESS }, offset: 0, limit: 10) {
+				totalCount
+				entries {
+					targetIds
+					arguments
+				}
+			}
+		}`, storedVariables["coordinator_id"])
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"auditLog\":{\"totalCount\":1,\"entries\":[{\"targetIds\":[%q],\"arguments\":\"{\\\"id\\\":\\\"%s\\\",\\\"reason\\\":\\\"Bug report about the profile page\\\"}\"}]}}}", storedVariables["patient_id"], storedVariables["patient_id"]), response.Body.String())
+
+	})
+
+	t.Run("should inform which rules of the password policy were not followed", func(t *testing.T) {
+
+		passwords := map[string]string{
+			"Ab1!":             "[\"MIN_LENGTH\"]",
+			"abc123!@#":        "[\"UPPERCASE\"]",
+			"ABC123!@#":        "[\"LOWERCASE\"]",
+			"Abcdef!@#":        "[\"DIGIT\"]",
+			"Abcdef123":        "[\"SYMBOL\"]",
+			"Abc1111!@#":       "[\"MAX_REPEATED_CHARACTERS\"]",
+			"Joe.Namath123!":   "[\"EMAIL\"]",
+			"pASSWORD123!":     "[\"BLOCKLIST\"]",
+			"qwerty123!":       "[\"UPPERCASE\",\"BLOCKLIST\"]",
+			"joe.namath":       "[\"UPPERCASE\",\"DIGIT\",\"EMAIL\"]",
+			"Namath&Jets1969!": "",
+		}
+
+		for password, failedRules := range passwords {
+
+			query := fmt.Sprintf(`mutation {
+				createUserWithPassword(input: {
+					email: "joe.namath@psi.com.br",
+					password: %q,
+					role: "PATIENT"
+				})
+			}`, password)
+
+			response := gql(router, query, storedVariables["coordinator_token"])
+
+			if failedRules == "" {
+				assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+				continue
+			}
+
+			assert.Equal(t, fmt.Sprintf("{\"errors\":[{\"message\":\"weak password\",\"path\":[\"createUserWithPassword\"],\"extensions\":{\"failedRules\":%s}}],\"data\":{\"createUserWithPassword\":null}}", failedRules), response.Body.String(), password)
+
+		}
+
+	})
+
+	t.Run("should invite psychologists from a file only if coordinator", func(t *testing.T) {
+
+		query := `mutation ($file: Upload!) {
+			invitePsychologistsFromFile(file: $file) {
+				line
+				email
+				status
+				message
+			}
+		}`
+
+		file := []byte("email,name,crp\n" +
+			"bart.starr@psi.com.br,Bart Starr,06/123456\n" +
+			"not-an-email,Someone,06/654321\n" +
+			"tom.brady@psi.com.br,Tom Brady,06/111111\n" +
+			"terry.bradshaw@psi.com.br\n" +
+			"bart.starr@psi.com.br,Bart Starr,06/123456\n")
+
+		response := gqlWithFile(router, query, storedVariables["patient_token"], "file", "cohort.csv", file)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"invitePsychologistsFromFile\"]}],\"data\":null}", response.Body.String())
+
+		response = gqlWithFile(router, query, storedVariables["coordinator_token"], "file", "cohort.csv", []byte("email,name\n\"unterminated,quote\n"))
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid csv file\",\"path\":[\"invitePsychologistsFromFile\"]}],\"data\":null}", response.Body.String())
+
+		response = gqlWithFile(router, query, storedVariables["coordinator_token"], "file", "cohort.csv", file)
+
+		assert.Equal(t, "{\"data\":{\"invitePsychologistsFromFile\":[{\"line\":2,\"email\":\"bart.starr@psi.com.br\",\"status\":\"CREATED\",\"message\":\"\"},{\"line\":3,\"email\":\"not-an-email\",\"status\":\"INVALID\",\"message\":\"invalid email\"},{\"line\":4,\"email\":\"tom.brady@psi.com.br\",\"status\":\"DUPLICATE\",\"message\":\"user with same email already exists\"},{\"line\":5,\"email\":\"terry.bradshaw@psi.com.br\",\"status\":\"CREATED\",\"message\":\"\"},{\"line\":6,\"email\":\"bart.starr@psi.com.br\",\"status\":\"DUPLICATE\",\"message\":\"email appears more than once in the file\"}]}}", response.Body.String())
+
+		query = `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		invitations := 0
+		var mailBody string
+
+		for _, mail := range *mailbox {
+			if mail["subject"] != "Bem-vindo ao PSI" || !strings.Contains(mail["body"].(string), "como psicólogo") {
+				continue
+			}
+			if reflect.DeepEqual(mail["to"], []string{"terry.bradshaw@psi.com.br"}) {
+				invitations++
+			}
+			if reflect.DeepEqual(mail["to"], []string{"bart.starr@psi.com.br"}) {
+				invitations++
+				mailBody = mail["body"].(string)
+			}
+		}
+
+		assert.Equal(t, 2, invitations)
+
+		regex := regexp.MustCompile("token=(?P<token>[^\"]+)")
+		match := regex.FindStringSubmatch(mailBody)
+
+		query = fmt.Sprintf(`mutation {
+			resetPassword(input: { token: %q, password: "Packers&1961" })
+		}`, match[1])
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"data\":{\"resetPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { email: "bart.starr@psi.com.br", password: "Packers&1961" }) {
+				token
+			}
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		query = `{
+			myPsychologistProfile {
+				fullName
+				crp
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"myPsychologistProfile\":{\"fullName\":\"Bart Starr\",\"crp\":\"06/123456\"}}}", response.Body.String())
+
+	})
+
+	t.Run("should list, resend and revoke pending invitations only if coordinator", func(t *testing.T) {
+
+		pendingInvitationsQuery := `{
+			pendingInvitations {
+				id
+				email
+				role
+				status
+				invitedBy
+			}
+		}`
+
+		response := gql(router, pendingInvitationsQuery, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"pendingInvitations\"]}],\"data\":null}", response.Body.String())
+
+		findInvitation := func(email string) (string, string) {
+			response := gql(router, pendingInvitationsQuery, storedVariables["coordinator_token"])
+			for _, invitation := range fastjson.MustParse(response.Body.String()).GetArray("data", "pendingInvitations") {
+				if string(invitation.GetStringBytes("email")) == email {
+					assert.Equal(t, "PSYCHOLOGIST", string(invitation.GetStringBytes("role")))
+					assert.Equal(t, storedVariables["coordinator_id"], string(invitation.GetStringBytes("invitedBy")))
+					return string(invitation.GetStringBytes("id")), string(invitation.GetStringBytes("status"))
+				}
+			}
+			return "", ""
+		}
+
+		terryInvitationID, status := findInvitation("terry.bradshaw@psi.com.br")
+		assert.NotEqual(t, "", terryInvitationID)
+		assert.Equal(t, "PENDING", status)
+
+		_, status = findInvitation("bart.starr@psi.com.br")
+		assert.Equal(t, "", status)
+
+		res.OrmUtil.Db().Model(&users_models.Invitation{}).Where("id = ?", terryInvitationID).Update("expires_at", time.Now().Add(-time.Hour))
+
+		_, status = findInvitation("terry.bradshaw@psi.com.br")
+		assert.Equal(t, "EXPIRED", status)
+
+		bartInvitation := users_models.Invitation{}
+		res.OrmUtil.Db().Where("email = ?", "bart.starr@psi.com.br").Limit(1).Find(&bartInvitation)
+		assert.Equal(t, users_models.InvitationAccepted, bartInvitation.Status)
+
+		query := fmt.Sprintf(`mutation {
+			resendInvitation(id: %q)
+		}`, bartInvitation.ID)
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invitation is not pending\",\"path\":[\"resendInvitation\"]}],\"data\":{\"resendInvitation\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			resendInvitation(id: %q)
+		}`, terryInvitationID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"resendInvitation\"]}],\"data\":{\"resendInvitation\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"resendInvitation\":null}}", response.Body.String())
+
+		_, status = findInvitation("terry.bradshaw@psi.com.br")
+		assert.Equal(t, "PENDING", status)
+
+		query = `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		terryTokens := []string{}
+		regex := regexp.MustCompile("token=(?P<token>[^\"]+)")
+
+		for _, mail := range *mailbox {
+			if reflect.DeepEqual(mail["to"], []string{"terry.bradshaw@psi.com.br"}) && mail["subject"] == "Bem-vindo ao PSI" {
+				terryTokens = append(terryTokens, regex.FindStringSubmatch(mail["body"].(string))[1])
+			}
+		}
+
+		assert.Equal(t, 2, len(terryTokens))
+
+		query = fmt.Sprintf(`mutation {
+			resetPassword(input: { token: %q, password: "Steelers&1974" })
+		}`, terryTokens[0])
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid token\",\"path\":[\"resetPassword\"]}],\"data\":{\"resetPassword\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			resetPassword(input: { token: %q, password: "Steelers&1974" })
+		}`, terryTokens[1])
+
+		response = gql(router, query, "")
+
+		assert.Equal(t, "{\"data\":{\"resetPassword\":null}}", response.Body.String())
+
+		_, status = findInvitation("terry.bradshaw@psi.com.br")
+		assert.Equal(t, "", status)
+
+		query = `mutation {
+			createPsychologistUser(input: { email: "jim.kelly@psi.com.br" })
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPsychologistUser\":null}}", response.Body.String())
+
+		jimInvitationID, status := findInvitation("jim.kelly@psi.com.br")
+		assert.Equal(t, "PENDING", status)
+
+		query = fmt.Sprintf(`mutation {
+			revokeInvitation(id: %q)
+		}`, jimInvitationID)
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"revokeInvitation\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invitation is not pending\",\"path\":[\"revokeInvitation\"]}],\"data\":{\"revokeInvitation\":null}}", response.Body.String())
+
+		_, status = findInvitation("jim.kelly@psi.com.br")
+		assert.Equal(t, "", status)
+
+		jimInvitation := users_models.Invitation{}
+		res.OrmUtil.Db().Where("id = ?", jimInvitationID).Limit(1).Find(&jimInvitation)
+		assert.Equal(t, users_models.InvitationRevoked, jimInvitation.Status)
+
+		query = `mutation {
+			createPsychologistUser(input: { email: "jim.kelly@psi.com.br" })
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPsychologistUser\":null}}", response.Body.String())
+
+		newJimInvitationID, status := findInvitation("jim.kelly@psi.com.br")
+		assert.Equal(t, "PENDING", status)
+		assert.NotEqual(t, jimInvitationID, newJimInvitationID)
+
+	})
+
+	t.Run("should rehash legacy bcrypt passwords on login", func(t *testing.T) {
+
+		legacyHash, hashErr := hash.BcryptHashUtil{Cost: 4, LoggingUtil: loggingUtil}.Hash("Xyz*()890")
+		assert.Nil(t, hashErr)
+
+		res.OrmUtil.Db().Model(&users_models.User{}).Where("email = ?", "jobrunner@psi.com.br").Update("password", legacyHash)
+
+		query := `{
+			authenticateUser(input: { 
+				email: "jobrunner@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token 
+			} 
+		}`
+
+		response := gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+
+		jobrunner := users_models.User{}
+		res.OrmUtil.Db().Where("email = ?", "jobrunner@psi.com.br").Limit(1).Find(&jobrunner)
+		assert.True(t, strings.HasPrefix(jobrunner.Password, "$argon2id$v=19$m=1024,t=1,p=1$"))
+		assert.False(t, res.HashUtil.NeedsRehash(jobrunner.Password))
+
+		response = gql(router, query, "")
+
+		token = fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+
+	})
+
+	t.Run("should list users page by page only if allowed", func(t *testing.T) {
+
+		usersQuery := func(arguments string) string {
+			return fmt.Sprintf(`{
+				users(%s) {
+					totalCount
+					users {
+						id
+						email
+						role
+						active
+					}
+					endCursor
+					hasNextPage
+				}
+			}`, arguments)
+		}
+
+		response := gql(router, usersQuery("first: 10"), storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"users\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, usersQuery("first: 10"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"users\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, usersQuery("first: 101"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"first is out of range\",\"path\":[\"users\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, usersQuery("first: 10, after: \"abc\""), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid cursor\",\"path\":[\"users\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, usersQuery("search: \"PSI.COM.BR\", sort: EMAIL_ASC, first: 5"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, 12, fastjson.GetInt(response.Body.Bytes(), "data", "users", "totalCount"))
+		assert.True(t, fastjson.GetBool(response.Body.Bytes(), "data", "users", "hasNextPage"))
+		assert.Equal(t, "aaron.rodgers@psi.com.br", fastjson.GetString(response.Body.Bytes(), "data", "users", "users", "0", "email"))
+
+		emailAscCursor := fastjson.GetString(response.Body.Bytes(), "data", "users", "endCursor")
+
+		response = gql(router, usersQuery(fmt.Sprintf("search: \"psi.com.br\", sort: EMAIL_DESC, first: 5, after: %q", emailAscCursor)), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid cursor\",\"path\":[\"users\"]}],\"data\":null}", response.Body.String())
+
+		emails := []string{}
+		after := ""
+
+		for {
+			arguments := "search: \"psi.com.br\", sort: EMAIL_ASC, first: 5"
+			if after != "" {
+				arguments += fmt.Sprintf(", after: %q", after)
+			}
+
+			response = gql(router, usersQuery(arguments), storedVariables["coordinator_token"])
+
+			for _, user := range fastjson.MustParse(response.Body.String()).GetArray("data", "users", "users") {
+				emails = append(emails, string(user.GetStringBytes("email")))
+			}
+
+			if !fastjson.GetBool(response.Body.Bytes(), "data", "users", "hasNextPage") {
+				break
+			}
+
+			after = fastjson.GetString(response.Body.Bytes(), "data", "users", "endCursor")
+		}
+
+		assert.Equal(t, 12, len(emails))
+		assert.True(t, sort.StringsAreSorted(emails))
+		assert.NotContains(t, emails, "joe.montana@psi.com")
+
+		response = gql(router, usersQuery("filter: { role: \"PSYCHOLOGIST\" }, sort: EMAIL_DESC, first: 10"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, 4, fastjson.GetInt(response.Body.Bytes(), "data", "users", "totalCount"))
+		assert.Equal(t, "tom.brady@psi.com.br", fastjson.GetString(response.Body.Bytes(), "data", "users", "users", "0", "email"))
+		assert.False(t, fastjson.GetBool(response.Body.Bytes(), "data", "users", "hasNextPage"))
+
+		response = gql(router, usersQuery("first: 100"), storedVariables["coordinator_token"])
+
+		totalCount := fastjson.GetInt(response.Body.Bytes(), "data", "users", "totalCount")
+
+		response = gql(router, usersQuery("filter: { hasProfile: true }, first: 100"), storedVariables["coordinator_token"])
+
+		withProfileCount := fastjson.GetInt(response.Body.Bytes(), "data", "users", "totalCount")
+
+		response = gql(router, usersQuery("filter: { hasProfile: false }, first: 100"), storedVariables["coordinator_token"])
+
+		withoutProfileCount := fastjson.GetInt(response.Body.Bytes(), "data", "users", "totalCount")
+
+		assert.NotEqual(t, 0, withProfileCount)
+		assert.NotEqual(t, 0, withoutProfileCount)
+		assert.Equal(t, totalCount, withProfileCount+withoutProfileCount)
+		assert.Contains(t, response.Body.String(), "\"email\":\"jobrunner@psi.com.br\"")
+
+		response = gql(router, usersQuery("filter: { hasActiveTreatment: true }, first: 10"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"users\":{\"totalCount\":0,\"users\":[],\"endCursor\":null,\"hasNextPage\":false}}}", response.Body.String())
+
+		treatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("patient_id <> ''").Limit(1).Find(&treatment)
+		treatmentStatus := treatment.Status
+		res.OrmUtil.Db().Model(&treatment).Update("status", treatments_models.Active)
+
+		patient := profiles_models.Patient{}
+		res.OrmUtil.Db().Where("id = ?", treatment.PatientID).Limit(1).Find(&patient)
+		psychologist := profiles_models.Psychologist{}
+		res.OrmUtil.Db().Where("id = ?", treatment.PsychologistID).Limit(1).Find(&psychologist)
+
+		response = gql(router, usersQuery("filter: { hasActiveTreatment: true }, first: 10"), storedVariables["coordinator_token"])
+
+		assert.Equal(t, 2, fastjson.GetInt(response.Body.Bytes(), "data", "users", "totalCount"))
+		assert.ElementsMatch(t, []string{patient.UserID, psychologist.UserID}, []string{
+			fastjson.GetString(response.Body.Bytes(), "data", "users", "users", "0", "id"),
+			fastjson.GetString(response.Body.Bytes(), "data", "users", "users", "1", "id"),
+		})
+
+		res.OrmUtil.Db().Model(&treatment).Update("status", treatmentStatus)
+
+	})
+
+	t.Run("should log in with the identity provider", func(t *testing.T) {
+
+		binding := "3f9c1a7e5b2d4c6e8a0b1d3f5e7c9a2b"
+
+		authorizationQuery := fmt.Sprintf(`{
+			oidcAuthorizationUrl(binding: %q)
+		}`, binding)
+
+		oidcQuery := func(state string, code string, binding string) string {
+			return fmt.Sprintf(`{
+				authenticateWithOidc(input: { state: %q, code: %q, binding: %q }) {
+					token
+					refreshToken
+				}
+			}`, state, code, binding)
+		}
+
+		myUserQuery := `{
+			myUser {
+				email
+				role
+			}
+		}`
+
+		response := gql(router, authorizationQuery, "")
+
+		authorizationURL := fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl")
+		assert.True(t, strings.HasPrefix(authorizationURL, oidcProvider.server.URL+"/authorize?"))
+		assert.Contains(t, authorizationURL, "code_challenge_method=S256")
+		assert.Contains(t, authorizationURL, "client_id=psi")
+
+		state, code := oidcProvider.login(authorizationURL, "patient2@psi.com.br", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "token")
+		assert.NotEqual(t, "", token)
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "refreshToken"))
+
+		response = gql(router, myUserQuery, token)
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"patient2@psi.com.br\",\"role\":\"PATIENT\"}}}", response.Body.String())
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid state\",\"path\":[\"authenticateWithOidc\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, authorizationQuery, "")
+
+		state, _ = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "patient2@psi.com.br", true)
+
+		response = gql(router, oidcQuery(state, "forged-code", binding), "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid authorization code\",\"path\":[\"authenticateWithOidc\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "patient3@psi.com.br", false)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"email is not verified\",\"path\":[\"authenticateWithOidc\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "dan.marino@university.edu", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		token = fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "token")
+		assert.NotEqual(t, "", token)
+
+		response = gql(router, myUserQuery, token)
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"dan.marino@university.edu\",\"role\":\"PATIENT\"}}}", response.Body.String())
+
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "dan.marino@university.edu", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		assert.NotEqual(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "token"))
+
+		count := int64(0)
+		res.OrmUtil.Db().Model(&users_models.User{}).Where("email = ?", "dan.marino@university.edu").Count(&count)
+		assert.Equal(t, int64(1), count)
+
+		patientUser := users_models.User{}
+		res.OrmUtil.Db().Where("email = ?", "patient2@psi.com.br").Limit(1).Find(&patientUser)
+
+		link := users_models.OidcIdentity{}
+		res.OrmUtil.Db().Where("issuer = ? AND subject = ?", oidcProvider.server.URL, "subject-patient2@psi.com.br").Limit(1).Find(&link)
+		assert.NotEqual(t, "", link.UserID)
+		assert.Equal(t, patientUser.ID, link.UserID)
+
+		// the account stays linked to the same user even if its email changes in the provider
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.loginAs(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "subject-patient2@psi.com.br", "patient2@university.edu", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		response = gql(router, myUserQuery, fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "token"))
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"patient2@psi.com.br\",\"role\":\"PATIENT\"}}}", response.Body.String())
+
+		// another account of the provider that got the same email cannot take over the user
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.loginAs(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "another-subject", "patient2@psi.com.br", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"user is linked to another identity\",\"path\":[\"authenticateWithOidc\"]}],\"data\":null}", response.Body.String())
+
+		// invited users accept their invitation by logging in through the provider
+		query := `mutation {
+			createPsychologistUser(input: { email: "john.elway@psi.com.br" })
+		}`
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPsychologistUser\":null}}", response.Body.String())
+
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "john.elway@psi.com.br", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		response = gql(router, myUserQuery, fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "token"))
+
+		assert.Equal(t, "{\"data\":{\"myUser\":{\"email\":\"john.elway@psi.com.br\",\"role\":\"PSYCHOLOGIST\"}}}", response.Body.String())
+
+		invitation := users_models.Invitation{}
+		res.OrmUtil.Db().Where("email = ?", "john.elway@psi.com.br").Limit(1).Find(&invitation)
+		assert.Equal(t, users_models.InvitationAccepted, invitation.Status)
+
+		// the binding must be long enough not to be guessed
+		response = gql(router, `{
+			oidcAuthorizationUrl(binding: "short")
+		}`, "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"binding must have at least 32 characters\",\"path\":[\"oidcAuthorizationUrl\"]}],\"data\":null}", response.Body.String())
+
+		// a state started by someone else cannot be used without the binding kept by whoever started it
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "dan.marino@university.edu", true)
+
+		response = gql(router, oidcQuery(state, code, "8d2e4f6a0c1b3e5d7f9a2c4e6b8d0f1a"), "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid state\",\"path\":[\"authenticateWithOidc\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid state\",\"path\":[\"authenticateWithOidc\"]}],\"data\":null}", response.Body.String())
+
+		// users locked after too many failed attempts cannot log in through the provider either
+		danUser := users_models.User{}
+		res.OrmUtil.Db().Where("email = ?", "dan.marino@university.edu").Limit(1).Find(&danUser)
+
+		lock := cooldowns_models.Cooldown{
+			ID:           "oidc-lock",
+			ProfileID:    danUser.ID,
+			ProfileType:  cooldowns_models.User,
+			CooldownType: cooldowns_models.LoginLocked,
+			ValidUntil:   time.Now().Add(time.Duration(1800) * time.Second),
+		}
+		res.OrmUtil.Db().Create(&lock)
+
+		response = gql(router, authorizationQuery, "")
+
+		state, code = oidcProvider.login(fastjson.GetString(response.Body.Bytes(), "data", "oidcAuthorizationUrl"), "dan.marino@university.edu", true)
+
+		response = gql(router, oidcQuery(state, code, binding), "")
+
+		assert.Equal(t, "", fastjson.GetString(response.Body.Bytes(), "data", "authenticateWithOidc", "token"))
+		assert.True(t, strings.HasPrefix(fastjson.GetString(response.Body.Bytes(), "errors", "0", "message"), "authentication is blocked for this user until "))
+
+		res.OrmUtil.Db().Delete(&lock)
+
+	})
+
+	t.Run("should only match patients with psychologists whose crp was verified", func(t *testing.T) {
+
+		query := `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Thomas Edward Patrick Brady, Jr."
+				likeName: "Tom Brady",
+				birthDate: "1977-08-03T00:00:00Z",
+				city: "Tampa - FL",
+				bio: "Hey there, my name is Tom",
+				crp: "06/654321",
+				whatsapp: "(11) 2345-6789",
+				instagram: "@tombrady"
+			})
+		}`
+
+		response := gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		query = `{
+			myPatientProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		patientID := fastjson.GetString(response.Body.Bytes(), "data", "myPatientProfile", "id")
+		assert.NotEqual(t, "", patientID)
+
+		affinitiesQuery := `{
+			myPatientTopAffinities {
+				psychologist {
+					id
+				}
+			}
+		}`
+
+		res.OrmUtil.Db().Where("profile_id = ? AND cooldown_type = ?", patientID, cooldowns_models.TopAffinitiesSet).Delete(&cooldowns_models.Cooldown{})
+
+		response = gql(router, affinitiesQuery, storedVariables["patient_3_token"])
+
+		assert.False(t, fastjson.Exists(response.Body.Bytes(), "errors"))
+		assert.NotContains(t, response.Body.String(), storedVariables["psychologist_1_id"])
+
+		pendingTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ?", storedVariables["psychologist_1_id"], treatments_models.Pending).Limit(1).Find(&pendingTreatment)
+		assert.NotEqual(t, "", pendingTreatment.ID)
+
+		query = fmt.Sprintf(`mutation {
+			assignTreatment(id: %q, priceRangeName: "low")
+		}`, pendingTreatment.ID)
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"psychologist is not verified\",\"path\":[\"assignTreatment\"]}],\"data\":{\"assignTreatment\":null}}", response.Body.String())
+
+		profileQuery := fmt.Sprintf(`{
+			psychologistProfile(id: %q) {
+				id
+			}
+		}`, storedVariables["psychologist_1_id"])
+
+		response = gql(router, profileQuery, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"data\":{\"psychologistProfile\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			approvePsychologistVerification(id: %q, reason: "CRP found in the registry")
+		}`, storedVariables["psychologist_1_id"])
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"approvePsychologistVerification\":null}}", response.Body.String())
+
+		res.OrmUtil.Db().Where("profile_id = ? AND cooldown_type = ?", patientID, cooldowns_models.TopAffinitiesSet).Delete(&cooldowns_models.Cooldown{})
+
+		response = gql(router, affinitiesQuery, storedVariables["patient_3_token"])
+
+		assert.Contains(t, response.Body.String(), storedVariables["psychologist_1_id"])
+
+		response = gql(router, profileQuery, storedVariables["patient_3_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"psychologistProfile\":{\"id\":%q}}}", storedVariables["psychologist_1_id"]), response.Body.String())
+
+	})
+
+	t.Run("should only create treatments inside the availability of the psychologist", func(t *testing.T) {
+
+		setQuery := `mutation {
+			setMyAvailability(input: [%s])
+		}`
+
+		response := gql(router, fmt.Sprintf(setQuery, `{ weekday: MONDAY, startTime: "18:00", endTime: "21:00" }`), storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"setMyAvailability\"]}],\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(setQuery, `{ weekday: MONDAY, startTime: "18:00", endTime: "25:00" }`), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid time\",\"path\":[\"setMyAvailability\"]}],\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(setQuery, `{ weekday: MONDAY, startTime: "6:00", endTime: "09:00" }`), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid time\",\"path\":[\"setMyAvailability\"]}],\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(setQuery, `{ weekday: MONDAY, startTime: "21:00", endTime: "18:00" }`), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"availability windows must end after they start\",\"path\":[\"setMyAvailability\"]}],\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(setQuery, `{ weekday: MONDAY, startTime: "18:00", endTime: "21:00" }, { weekday: MONDAY, startTime: "20:00", endTime: "22:00" }`), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"availability windows cannot overlap\",\"path\":[\"setMyAvailability\"]}],\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(setQuery, `
+			{ weekday: SATURDAY, startTime: "09:00", endTime: "12:00" },
+			{ weekday: THURSDAY, startTime: "00:00", endTime: "02:00" },
+			{ weekday: MONDAY, startTime: "18:00", endTime: "21:00" },
+			{ weekday: WEDNESDAY, startTime: "22:00", endTime: "24:00" }
+		`), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		availabilityQuery := `{
+			myAvailability {
+				weekday
+				startTime
+				endTime
+			}
+		}`
+
+		response = gql(router, availabilityQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myAvailability\":[{\"weekday\":\"MONDAY\",\"startTime\":\"18:00\",\"endTime\":\"21:00\"},{\"weekday\":\"WEDNESDAY\",\"startTime\":\"22:00\",\"endTime\":\"24:00\"},{\"weekday\":\"THURSDAY\",\"startTime\":\"00:00\",\"endTime\":\"02:00\"},{\"weekday\":\"SATURDAY\",\"startTime\":\"09:00\",\"endTime\":\"12:00\"}]}}", response.Body.String())
+
+		createQuery := `mutation {
+			createTreatment(input: {
+				frequency: 1,
+				weekday: %s,
+				startTime: %q,
+				duration: %d,
+				priceRangeName: "low"
+			})
+		}`
+
+		// monday 20:30 until 21:30 ends after the window of monday
+		response = gql(router, fmt.Sprintf(createQuery, "MONDAY", "20:30", 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"treatment is outside the availability of the psychologist\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		// tuesday 18:00 until 19:00 is in a day without availability
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "18:00", 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"treatment is outside the availability of the psychologist\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		// wednesday 23:00 until thursday 01:00 crosses the end of the week using two contiguous windows
+		response = gql(router, fmt.Sprintf(createQuery, "WEDNESDAY", "23:00", 7200), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		// saturday 09:00 until 10:00
+		response = gql(router, fmt.Sprintf(createQuery, "SATURDAY", "09:00", 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		profileQuery := `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response = gql(router, profileQuery, storedVariables["psychologist_token"])
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+		assert.NotEqual(t, "", psychologistID)
+
+		createdTreatments := []*treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND phase IN ?", psychologistID, []int64{205200, 601200}).Order("phase").Find(&createdTreatments)
+		assert.Equal(t, 2, len(createdTreatments))
+
+		updateQuery := `mutation {
+			updateTreatment(id: %q, input: {
+				frequency: 1,
+				weekday: SATURDAY,
+				startTime: %q,
+				duration: 3600
+			})
+		}`
+
+		// saturday 11:30 until 12:30 ends after the window of saturday
+		response = gql(router, fmt.Sprintf(updateQuery, createdTreatments[0].ID, "11:30"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"treatment is outside the availability of the psychologist\",\"path\":[\"updateTreatment\"]}],\"data\":{\"updateTreatment\":null}}", response.Body.String())
+
+		// saturday 11:00 until 12:00
+		response = gql(router, fmt.Sprintf(updateQuery, createdTreatments[0].ID, "11:00"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"updateTreatment\":null}}", response.Body.String())
+
+		deleteQuery := `mutation {
+			deleteTreatment(id: %q, priceRangeName: "low")
+		}`
+
+		for _, treatment := range createdTreatments {
+			response = gql(router, fmt.Sprintf(deleteQuery, treatment.ID), storedVariables["psychologist_token"])
+
+			assert.Equal(t, "{\"data\":{\"deleteTreatment\":null}}", response.Body.String())
+		}
+
+		response = gql(router, fmt.Sprintf(setQuery, ""), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, availabilityQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myAvailability\":[]}}", response.Body.String())
+
+	})
+
+	t.Run("should schedule appointments in the time zone of the psychologist", func(t *testing.T) {
+
+		query := `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Thomas Edward Patrick Brady, Jr."
+				likeName: "Tom Brady",
+				birthDate: "1977-08-03T00:00:00Z",
+				city: "Tampa - FL",
+				bio: "Hey there, my name is Tom",
+				crp: "06/654321",
+				whatsapp: "(11) 2345-6789",
+				instagram: "@tombrady",
+				timeZone: %q
+			})
+		}`
+
+		response := gql(router, fmt.Sprintf(query, "Mars/Olympus_Mons"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid time zone\",\"path\":[\"upsertMyPsychologistProfile\"]}],\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, "America/New_York"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		query = `{
+			myPatientProfile {
+				timeZone
+			}
+		}`
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"data\":{\"myPatientProfile\":{\"timeZone\":\"UTC\"}}}", response.Body.String())
+
+		query = `{
+			myPsychologistProfile {
+				id
+				timeZone
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "America/New_York", fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "timeZone"))
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		pendingTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ?", psychologistID, treatments_models.Pending).Limit(1).Find(&pendingTreatment)
+		assert.NotEqual(t, "", pendingTreatment.ID)
+
+		query = fmt.Sprintf(`mutation {
+			assignTreatment(id: %q, priceRangeName: "medium")
+		}`, pendingTreatment.ID)
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"data\":{\"assignTreatment\":null}}", response.Body.String())
+
+		res.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Delete(&appointments_models.Appointment{})
+
+		query = `mutation {
+			createPendingAppointments
+		}`
+
+		response = gql(router, query, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		query = `{
+			myPsychologistProfile {
+				appointments {
+					start
+					treatment {
+						frequency
+						week
+						weekday
+						startTime
+					}
+				}
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		appointments := fastjson.MustParseBytes(response.Body.Bytes()).GetArray("data", "myPsychologistProfile", "appointments")
+		assert.NotEqual(t, 0, len(appointments))
+
+		location, _ := time.LoadLocation("America/New_York")
+
+		// whatever the offset of New York is in the date of each appointment, it must start at the local time of the treatment
+		for _, appointment := range appointments {
+			appointmentStart, _ := time.Parse(time.RFC3339, string(appointment.GetStringBytes("start")))
+			localStart := appointmentStart.In(location)
+			wallClock := time.Date(localStart.Year(), localStart.Month(), localStart.Day(), localStart.Hour(), localStart.Minute(), 0, 0, time.UTC)
+			weeksSinceEpoch := wallClock.Unix() / int64(res.ScheduleIntervalDuration/time.Second)
+			assert.Equal(t, appointment.GetInt64("treatment", "week"), weeksSinceEpoch%appointment.GetInt64("treatment", "frequency"))
+			assert.Equal(t, strings.ToUpper(localStart.Weekday().String()), string(appointment.GetStringBytes("treatment", "weekday")))
+			assert.Equal(t, localStart.Format("15:04"), string(appointment.GetStringBytes("treatment", "startTime")))
+			assert.NotEqual(t, appointmentStart.UTC().Format("15:04"), string(appointment.GetStringBytes("treatment", "startTime")))
+		}
+
+		query = fmt.Sprintf(`mutation {
+			finalizeTreatment(id: %q)
+		}`, pendingTreatment.ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"finalizeTreatment\":null}}", response.Body.String())
+
+		query = `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Thomas Edward Patrick Brady, Jr."
+				likeName: "Tom Brady",
+				birthDate: "1977-08-03T00:00:00Z",
+				city: "Tampa - FL",
+				bio: "Hey there, my name is Tom",
+				crp: "06/654321",
+				whatsapp: "(11) 2345-6789",
+				instagram: "@tombrady",
+				timeZone: "UTC"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+	})
+
+	t.Run("should find free slots for new treatments of the psychologist", func(t *testing.T) {
+
+		query := `{
+			myFreeSlots(frequency: %d, duration: %d) {
+				week
+				weekday
+				startTime
+			}
+		}`
+
+		response := gql(router, fmt.Sprintf(query, 1, 3600), storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myFreeSlots\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 0, 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"frequency must be at least one\",\"path\":[\"myFreeSlots\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 13, 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"frequency must be at most 12\",\"path\":[\"myFreeSlots\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 1, 0), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"duration must fit in the interval between sessions\",\"path\":[\"myFreeSlots\"]}],\"data\":null}", response.Body.String())
+
+		// with no availability declared, the whole week is considered, except for the three slots of half an hour that would
+		// collide with each of the two pending treatments
+		response = gql(router, fmt.Sprintf(query, 1, 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, 7*48-2*3, len(fastjson.MustParseBytes(response.Body.Bytes()).GetArray("data", "myFreeSlots")))
+
+		setQuery := `mutation {
+			setMyAvailability(input: [
+				{ weekday: SATURDAY, startTime: "15:00", endTime: "17:30" },
+				{ weekday: SATURDAY, startTime: "19:30", endTime: "21:00" }
+			])
+		}`
+
+		response = gql(router, setQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		// the pending treatments on saturdays at 16:00 in the second week and at 20:00 in the first week block weekly sessions
+		response = gql(router, fmt.Sprintf(query, 1, 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myFreeSlots\":[{\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"15:00\"}]}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 2, 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"myFreeSlots\":[{\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"15:00\"},{\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"15:30\"},{\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"16:00\"},{\"week\":0,\"weekday\":\"SATURDAY\",\"startTime\":\"16:30\"},{\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"15:00\"},{\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"19:30\"},{\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"20:00\"}]}}", response.Body.String())
+
+		createQuery := `mutation {
+			createTreatment(input: {
+				frequency: 2,
+				week: 1,
+				weekday: SATURDAY,
+				startTime: "20:00",
+				duration: 3600,
+				priceRangeName: "low"
+			})
+		}`
+
+		response = gql(router, createQuery, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(query, 2, 3600), storedVariables["psychologist_token"])
+
+		assert.NotContains(t, response.Body.String(), "{\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"19:30\"}")
+		assert.NotContains(t, response.Body.String(), "{\"week\":1,\"weekday\":\"SATURDAY\",\"startTime\":\"20:00\"}")
+
+		createdTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("phase = ? AND status = ?", 835200+14400, treatments_models.Pending).Limit(1).Find(&createdTreatment)
+
+		query = fmt.Sprintf(`mutation {
+			deleteTreatment(id: %q, priceRangeName: "low")
+		}`, createdTreatment.ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"deleteTreatment\":null}}", response.Body.String())
+
+		response = gql(router, `mutation { setMyAvailability(input: []) }`, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+	})
+
+	t.Run("should report which session of which treatment collides across the end of the week", func(t *testing.T) {
+
+		query := `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response := gql(router, query, storedVariables["psychologist_token"])
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		createQuery := `mutation {
+			createTreatment(input: {
+				frequency: %d,
+				week: %d,
+				weekday: %s,
+				startTime: %q,
+				duration: %d,
+				priceRangeName: "low"
+			})
+		}`
+
+		// the interrupted treatment on thursdays at 00:00 does not block this one anymore
+		response = gql(router, fmt.Sprintf(createQuery, 1, 0, "WEDNESDAY", "23:30", 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		crossingTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND phase = ?", psychologistID, treatments_models.Pending, 603000).Limit(1).Find(&crossingTreatment)
+		assert.NotEqual(t, "", crossingTreatment.ID)
+
+		collisionMessage := "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[%q],\"extensions\":{\"collidingTreatmentId\":%q,\"occurrence\":%d,\"startTime\":%q,\"week\":%d,\"weekday\":%q}}],\"data\":{%q:null}}"
+
+		// the session of the first week ends at 00:30 of the thursday that starts the second week
+		response = gql(router, fmt.Sprintf(createQuery, 2, 1, "THURSDAY", "00:00", 1800), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf(collisionMessage, "createTreatment", crossingTreatment.ID, 0, "23:30", 0, "WEDNESDAY", "createTreatment"), response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, 1, 0, "THURSDAY", "00:15", 1800), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf(collisionMessage, "createTreatment", crossingTreatment.ID, 0, "23:30", 0, "WEDNESDAY", "createTreatment"), response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, 1, 0, "WEDNESDAY", "23:00", 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf(collisionMessage, "createTreatment", crossingTreatment.ID, 0, "23:30", 0, "WEDNESDAY", "createTreatment"), response.Body.String())
+
+		// ending exactly when the other one starts is not a collision
+		response = gql(router, fmt.Sprintf(createQuery, 2, 1, "WEDNESDAY", "22:30", 3600), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		touchingTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND phase = ?", psychologistID, treatments_models.Pending, 604800+599400).Limit(1).Find(&touchingTreatment)
+		assert.NotEqual(t, "", touchingTreatment.ID)
+
+		updateQuery := `mutation {
+			updateTreatment(id: %q, input: {
+				frequency: 1,
+				weekday: WEDNESDAY,
+				startTime: %q,
+				duration: 3600
+			})
+		}`
+
+		// a treatment does not collide with itself, but moving it earlier hits the session of the other one in the second week
+		response = gql(router, fmt.Sprintf(updateQuery, crossingTreatment.ID, "23:45"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"updateTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(updateQuery, crossingTreatment.ID, "23:00"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf(collisionMessage, "updateTreatment", touchingTreatment.ID, 0, "22:30", 1, "WEDNESDAY", "updateTreatment"), response.Body.String())
+
+		deleteQuery := `mutation {
+			deleteTreatment(id: %q, priceRangeName: "low")
+		}`
+
+		for _, id := range []string{crossingTreatment.ID, touchingTreatment.ID} {
+			response = gql(router, fmt.Sprintf(deleteQuery, id), storedVariables["psychologist_token"])
+
+			assert.Equal(t, "{\"data\":{\"deleteTreatment\":null}}", response.Body.String())
+		}
+
+	})
+	t.Run("should create treatments with recurrence rules", func(t *testing.T) {
+
+		query := `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response := gql(router, query, storedVariables["psychologist_token"])
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		createQuery := `mutation {
+			createTreatment(input: {
+				frequency: 1,
+				weekday: %s,
+				startTime: %q,
+				recurrence: %q,
+				duration: 3600,
+				priceRangeName: %q
+			})
+		}`
+
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:00", "FREQ=DAILY", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"recurrence rule frequency must be WEEKLY or MONTHLY\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:00", "FREQ=WEEKLY;BYSETPOS=1", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"recurrence rule part BYSETPOS is not supported\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:00", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"frequency must match the interval of the recurrence rule\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:00", "FREQ=WEEKLY;BYDAY=MO,TH", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"weekday must be one of the weekdays of the recurrence rule\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:00", "FREQ=MONTHLY;BYDAY=TU", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"monthly recurrence rules need one weekday with its position, such as 2TU\",\"path\":[\"createTreatment\"]}],\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "MONDAY", "10:00", "FREQ=WEEKLY;BYDAY=TH,MO", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:00", "RRULE:FREQ=MONTHLY;BYDAY=2TU;COUNT=3", "medium"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		query = `{
+			myPsychologistProfile {
+				treatments {
+					weekday
+					startTime
+					recurrence
+					status
+				}
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		// treatments created without a rule are described by the equivalent weekly one
+		assert.Contains(t, response.Body.String(), "{\"weekday\":\"SATURDAY\",\"startTime\":\"16:00\",\"recurrence\":\"FREQ=WEEKLY;INTERVAL=2;BYDAY=SA\",\"status\":\"PENDING\"}")
+		assert.Contains(t, response.Body.String(), "{\"weekday\":\"MONDAY\",\"startTime\":\"10:00\",\"recurrence\":\"FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH\",\"status\":\"PENDING\"}")
+		assert.Contains(t, response.Body.String(), "{\"weekday\":\"TUESDAY\",\"startTime\":\"10:00\",\"recurrence\":\"FREQ=MONTHLY;INTERVAL=1;BYDAY=2TU;COUNT=3\",\"status\":\"PENDING\"}")
+
+		weeklyTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND recurrence = ?", psychologistID, treatments_models.Pending, "FREQ=WEEKLY;INTERVAL=1;BYDAY=MO,TH").Limit(1).Find(&weeklyTreatment)
+		assert.NotEqual(t, "", weeklyTreatment.ID)
+
+		monthlyTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND recurrence = ?", psychologistID, treatments_models.Pending, "FREQ=MONTHLY;INTERVAL=1;BYDAY=2TU;COUNT=3").Limit(1).Find(&monthlyTreatment)
+		assert.NotEqual(t, "", monthlyTreatment.ID)
+
+		collisionMessage := "{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"],\"extensions\":{\"collidingTreatmentId\":%q,\"occurrence\":%d,\"startTime\":%q,\"week\":%d,\"weekday\":%q}}],\"data\":{\"createTreatment\":null}}"
+
+		// the session on thursdays comes first in the week, which starts on thursdays
+		response = gql(router, fmt.Sprintf(createQuery, "THURSDAY", "10:30", "FREQ=WEEKLY;BYDAY=TH", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf(collisionMessage, weeklyTreatment.ID, 0, "10:00", 0, "THURSDAY"), response.Body.String())
+
+		response = gql(router, fmt.Sprintf(createQuery, "MONDAY", "09:30", "", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf(collisionMessage, weeklyTreatment.ID, 1, "10:00", 0, "MONDAY"), response.Body.String())
+
+		secondTuesday := func(year int, month time.Month) time.Time {
+			first := time.Date(year, month, 1, 10, 0, 0, 0, time.UTC)
+			return first.AddDate(0, 0, (int(time.Tuesday)-int(first.Weekday())+7)%7+7)
+		}
+
+		now := time.Now().UTC()
+		nextSession := secondTuesday(now.Year(), now.Month())
+		if nextSession.Before(now) {
+			nextSession = secondTuesday(now.Year(), now.Month()+1)
+		}
+
+		// weekly sessions on tuesdays hit the monthly ones, which are reported with their date
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:30", "", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, fmt.Sprintf("{\"errors\":[{\"message\":\"there is another treatment in the same period\",\"path\":[\"createTreatment\"],\"extensions\":{\"collidingTreatmentId\":%q,\"date\":%q,\"occurrence\":0,\"startTime\":\"10:00\",\"week\":0,\"weekday\":\"TUESDAY\"}}],\"data\":{\"createTreatment\":null}}", monthlyTreatment.ID, nextSession.Format("2006-01-02")), response.Body.String())
+
+		// sessions of a rule that has already ended do not collide with anything
+		response = gql(router, fmt.Sprintf(createQuery, "TUESDAY", "10:30", "FREQ=WEEKLY;BYDAY=TU;UNTIL=20200101", "low"), storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		endedTreatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND recurrence = ?", psychologistID, treatments_models.Pending, "FREQ=WEEKLY;INTERVAL=1;BYDAY=TU;UNTIL=20200101").Limit(1).Find(&endedTreatment)
+		assert.NotEqual(t, "", endedTreatment.ID)
+
+		query = `{
+			myFreeSlots(frequency: 1, duration: 3600) {
+				weekday
+				startTime
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.NotContains(t, response.Body.String(), "{\"weekday\":\"MONDAY\",\"startTime\":\"10:00\"}")
+		assert.NotContains(t, response.Body.String(), "{\"weekday\":\"THURSDAY\",\"startTime\":\"10:00\"}")
+		assert.NotContains(t, response.Body.String(), "{\"weekday\":\"TUESDAY\",\"startTime\":\"10:00\"}")
+		assert.Contains(t, response.Body.String(), "{\"weekday\":\"TUESDAY\",\"startTime\":\"11:00\"}")
+
+		query = fmt.Sprintf(`mutation {
+			assignTreatment(id: %q, priceRangeName: "medium")
+		}`, monthlyTreatment.ID)
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"data\":{\"assignTreatment\":null}}", response.Body.String())
+
+		res.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Delete(&appointments_models.Appointment{})
+
+		createPendingQuery := `mutation {
+			createPendingAppointments
+		}`
+
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		appointment := appointments_models.Appointment{}
+		res.OrmUtil.Db().Where("treatment_id = ?", monthlyTreatment.ID).Limit(1).Find(&appointment)
+		assert.Equal(t, nextSession.Unix(), appointment.Start.Unix())
+		assert.Equal(t, nextSession.Add(time.Hour).Unix(), appointment.End.Unix())
+
+		// the three sessions of the rule are counted since the treatment was assigned, so none are left a year later
+		res.OrmUtil.Db().Where("treatment_id = ?", monthlyTreatment.ID).Delete(&appointments_models.Appointment{})
+		res.OrmUtil.Db().Model(&treatments_models.Treatment{}).Where("id = ?", monthlyTreatment.ID).Update("start_date", now.AddDate(-1, 0, 0))
+
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		var count int64
+		res.OrmUtil.Db().Model(&appointments_models.Appointment{}).Where("treatment_id = ?", monthlyTreatment.ID).Count(&count)
+		assert.Equal(t, int64(0), count)
+
+		query = fmt.Sprintf(`mutation {
+			finalizeTreatment(id: %q)
+		}`, monthlyTreatment.ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"finalizeTreatment\":null}}", response.Body.String())
+
+		deleteQuery := `mutation {
+			deleteTreatment(id: %q, priceRangeName: "low")
+		}`
+
+		for _, id := range []string{weeklyTreatment.ID, endedTreatment.ID} {
+			response = gql(router, fmt.Sprintf(deleteQuery, id), storedVariables["psychologist_token"])
+
+			assert.Equal(t, "{\"data\":{\"deleteTreatment\":null}}", response.Body.String())
+		}
+
+	})
+	t.Run("should keep a rolling horizon of appointments without regenerating canceled or edited ones", func(t *testing.T) {
+
+		query := `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response := gql(router, query, storedVariables["psychologist_token"])
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		query = `mutation {
+			createTreatment(input: {
+				frequency: 1,
+				weekday: WEDNESDAY,
+				startTime: "08:00",
+				duration: 3600,
+				priceRangeName: "medium"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		treatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND phase = ?", psychologistID, treatments_models.Pending, 6*86400+8*3600).Limit(1).Find(&treatment)
+		assert.NotEqual(t, "", treatment.ID)
+
+		query = fmt.Sprintf(`mutation {
+			assignTreatment(id: %q, priceRangeName: "medium")
+		}`, treatment.ID)
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"data\":{\"assignTreatment\":null}}", response.Body.String())
+
+		createPendingQuery := `mutation {
+			createPendingAppointments
+		}`
+
+		processPendingMailQuery := `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, processPendingMailQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		previousMails := len(*mailbox)
+
+		getAppointments := func() []*appointments_models.Appointment {
+			appointments := []*appointments_models.Appointment{}
+			res.OrmUtil.Db().Where("treatment_id = ?", treatment.ID).Order("occurrence ASC").Find(&appointments)
+			return appointments
+		}
+
+		// running the job more than once does not create the same sessions again
+		for i := 0; i < 2; i++ {
+			response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+			assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+		}
+
+		appointments := getAppointments()
+		assert.Equal(t, 4, len(appointments))
+		for index, appointment := range appointments {
+			assert.Equal(t, int64(index), *appointment.Occurrence)
+			assert.Equal(t, appointments[0].Start.Add(time.Duration(index)*7*24*time.Hour).Unix(), appointment.Start.Unix())
+		}
+
+		// the same occurrence cannot have two appointments, even if two runs of the job happen at the same time
+		duplicateOccurrence := int64(0)
+		duplicateErr := res.OrmUtil.Db().Create(&appointments_models.Appointment{
+			ID:             "duplicate-occurrence-appointment",
+			TreatmentID:    treatment.ID,
+			Occurrence:     &duplicateOccurrence,
+			PatientID:      treatment.PatientID,
+			PsychologistID: treatment.PsychologistID,
+			Start:          appointments[0].Start,
+			End:            appointments[0].End,
+			Status:         appointments_models.Created,
+		}).Error
+		assert.NotNil(t, duplicateErr)
+		assert.Equal(t, 4, len(getAppointments()))
+
+		response = gql(router, processPendingMailQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		// the patient gets a single mail for all the sessions created for the treatment
+		mailsFound := 0
+		for _, mail := range (*mailbox)[previousMails:] {
+			if reflect.DeepEqual(mail["to"], []string{"patient3@psi.com.br"}) && mail["subject"] == "Consulta criada no PSI" {
+				assert.Contains(t, mail["body"], "gerou 4 novas consultas")
+				mailsFound++
+			}
+		}
+		assert.Equal(t, 1, mailsFound)
+
+		query = fmt.Sprintf(`mutation {
+			cancelAppointmentByPsychologist(id: %q, reason: "I will be at a conference.")
+		}`, appointments[0].ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"cancelAppointmentByPsychologist\":null}}", response.Body.String())
+
+		editedStart := appointments[1].Start.Add(2 * time.Hour)
+
+		query = fmt.Sprintf(`mutation {
+			editAppointmentByPsychologist(id: %q, input: {
+				start: %q
+				end: %q
+				priceRangeName: "medium"
+				reason: "I have a meeting in the morning."
+			})
+		}`, appointments[1].ID, editedStart.Format(time.RFC3339), editedStart.Add(time.Hour).Format(time.RFC3339))
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"editAppointmentByPsychologist\":null}}", response.Body.String())
+
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		updatedAppointments := getAppointments()
+		assert.Equal(t, 4, len(updatedAppointments))
+		assert.Equal(t, appointments_models.CanceledByPsychologist, updatedAppointments[0].Status)
+		assert.Equal(t, appointments_models.EditedByPsychologist, updatedAppointments[1].Status)
+		assert.Equal(t, editedStart.Unix(), updatedAppointments[1].Start.Unix())
+
+		// as if everything had happened a week ago, the first session is in the past and the horizon moves forward by one session
+		res.OrmUtil.Db().Model(&treatments_models.Treatment{}).Where("id = ?", treatment.ID).Update("start_date", time.Now().AddDate(0, 0, -7))
+		for _, appointment := range updatedAppointments {
+			appointment.Start = appointment.Start.AddDate(0, 0, -7)
+			appointment.End = appointment.End.AddDate(0, 0, -7)
+			res.OrmUtil.Db().Save(appointment)
+		}
+
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		updatedAppointments = getAppointments()
+		assert.Equal(t, 5, len(updatedAppointments))
+		assert.Equal(t, int64(4), *updatedAppointments[4].Occurrence)
+		assert.Equal(t, appointments[3].Start.Unix(), updatedAppointments[4].Start.Unix())
+
+		// changing the schedule of the treatment removes the future sessions that nobody acted on, keeping the edited one
+		query = fmt.Sprintf(`mutation {
+			updateTreatment(id: %q, input: {
+				frequency: 1,
+				weekday: WEDNESDAY,
+				startTime: "09:00",
+				duration: 3600,
+				priceRangeName: "medium"
+			})
+		}`, treatment.ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"updateTreatment\":null}}", response.Body.String())
+
+		keptAppointments := []*appointments_models.Appointment{}
+		res.OrmUtil.Db().Where("treatment_id = ?", treatment.ID).Order("start ASC").Find(&keptAppointments)
+		assert.Equal(t, 2, len(keptAppointments))
+		assert.Equal(t, appointments_models.CanceledByPsychologist, keptAppointments[0].Status)
+		assert.Equal(t, appointments_models.EditedByPsychologist, keptAppointments[1].Status)
+		for _, appointment := range keptAppointments {
+			assert.Nil(t, appointment.Occurrence)
+		}
+
+		// the next sessions are created with the new schedule, even the one in the week of the edited appointment
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		rescheduledAppointments := []*appointments_models.Appointment{}
+		res.OrmUtil.Db().Where("treatment_id = ? AND occurrence IS NOT NULL", treatment.ID).Order("occurrence ASC").Find(&rescheduledAppointments)
+		assert.Equal(t, 4, len(rescheduledAppointments))
+		for index, appointment := range rescheduledAppointments {
+			assert.Equal(t, int64(index+1), *appointment.Occurrence)
+			assert.Equal(t, appointments_models.Created, appointment.Status)
+			assert.Equal(t, appointments[index].Start.Add(time.Hour).Unix(), appointment.Start.Unix())
+		}
+
+		assert.Equal(t, 6, len(getAppointments()))
+
+		query = fmt.Sprintf(`mutation {
+			finalizeTreatment(id: %q)
+		}`, treatment.ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"finalizeTreatment\":null}}", response.Body.String())
+
+	})
+	t.Run("should skip sessions on holidays and absences of the psychologist", func(t *testing.T) {
+
+		query := `{
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response := gql(router, query, storedVariables["psychologist_token"])
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		// the psychologist is in UTC, so the next sessions are the next fridays at 16:00 UTC
+		now := time.Now().UTC()
+		firstSession := time.Date(now.Year(), now.Month(), now.Day(), 16, 0, 0, 0, time.UTC)
+		for firstSession.Weekday() != time.Friday || !firstSession.After(now) {
+			firstSession = firstSession.AddDate(0, 0, 1)
+		}
+		sessions := []time.Time{}
+		for i := 0; i < 4; i++ {
+			sessions = append(sessions, firstSession.AddDate(0, 0, 7*i))
+		}
+
+		query = fmt.Sprintf(`mutation {
+			createHoliday(input: {
+				date: %q,
+				name: "Feriado municipal"
+			})
+		}`, sessions[3].Format("2006-01-02"))
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"createHoliday\"]}],\"data\":{\"createHoliday\":null}}", response.Body.String())
+
+		response = gql(router, `mutation {
+			createHoliday(input: {
+				date: "25/12/2026",
+				name: "Natal"
+			})
+		}`, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid date\",\"path\":[\"createHoliday\"]}],\"data\":{\"createHoliday\":null}}", response.Body.String())
+
+		importQuery := `mutation ($file: Upload!) {
+			importHolidays(file: $file) {
+				date
+				name
+			}
+		}`
+
+		response = gqlWithFile(router, importQuery, storedVariables["coordinator_token"], "file", "holidays.ics", []byte("not a calendar\n"))
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"invalid ics file\",\"path\":[\"importHolidays\"]}],\"data\":null}", response.Body.String())
+
+		file := []byte("BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"BEGIN:VEVENT\r\n" +
+			"DTSTART;VALUE=DATE:" + sessions[1].AddDate(0, 0, -1).Format("20060102") + "\r\n" +
+			"DTEND;VALUE=DATE:" + sessions[1].AddDate(0, 0, 1).Format("20060102") + "\r\n" +
+			"SUMMARY:Feriado prolongado\\, com ponte\r\n" +
+			"END:VEVENT\r\n" +
+			"END:VCALENDAR\r\n")
+
+		response = gqlWithFile(router, importQuery, storedVariables["coordinator_token"], "file", "holidays.ics", file)
+
+		assert.Equal(t, fmt.Sprintf("{\"data\":{\"importHolidays\":[{\"date\":%q,\"name\":\"Feriado prolongado, com ponte\"},{\"date\":%q,\"name\":\"Feriado prolongado, com ponte\"}]}}", sessions[1].AddDate(0, 0, -1).Format("2006-01-02"), sessions[1].Format("2006-01-02")), response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			createMyAbsence(input: {
+				start: %q,
+				end: %q,
+				reason: "Férias"
+			})
+		}`, sessions[2].Add(-time.Hour).Format(time.RFC3339), sessions[2].Add(30*time.Minute).Format(time.RFC3339))
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createMyAbsence\":null}}", response.Body.String())
+
+		query = `{
+			myAbsences {
+				id
+				start
+				end
+				reason
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		absenceID := fastjson.GetString(response.Body.Bytes(), "data", "myAbsences", "0", "id")
+		assert.Equal(t, sessions[2].Add(-time.Hour).Format(time.RFC3339), fastjson.GetString(response.Body.Bytes(), "data", "myAbsences", "0", "start"))
+		assert.Equal(t, "Férias", fastjson.GetString(response.Body.Bytes(), "data", "myAbsences", "0", "reason"))
+
+		query = `mutation {
+			createTreatment(input: {
+				frequency: 1,
+				weekday: FRIDAY,
+				startTime: "16:00",
+				duration: 3600,
+				priceRangeName: "medium"
+			})
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		treatment := treatments_models.Treatment{}
+		res.OrmUtil.Db().Where("psychologist_id = ? AND status = ? AND phase = ?", psychologistID, treatments_models.Pending, 86400+16*3600).Limit(1).Find(&treatment)
+		assert.NotEqual(t, "", treatment.ID)
+
+		query = fmt.Sprintf(`mutation {
+			assignTreatment(id: %q, priceRangeName: "medium")
+		}`, treatment.ID)
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"data\":{\"assignTreatment\":null}}", response.Body.String())
+
+		createPendingQuery := `mutation {
+			createPendingAppointments
+		}`
+
+		processPendingMailQuery := `mutation {
+			processPendingMail
+		}`
+
+		response = gql(router, processPendingMailQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		mailbox, mailboxErr := res.MailUtil.GetMockedMessages()
+		assert.Equal(t, mailboxErr, nil)
+
+		previousMails := len(*mailbox)
+
+		getAppointments := func() []*appointments_models.Appointment {
+			appointments := []*appointments_models.Appointment{}
+			res.OrmUtil.Db().Where("treatment_id = ?", treatment.ID).Order("occurrence ASC").Find(&appointments)
+			return appointments
+		}
+
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		appointments := getAppointments()
+		assert.Equal(t, 4, len(appointments))
+		for index, appointment := range appointments {
+			assert.Equal(t, sessions[index].Unix(), appointment.Start.Unix())
+		}
+		assert.Equal(t, appointments_models.Created, appointments[0].Status)
+		assert.Equal(t, appointments_models.Skipped, appointments[1].Status)
+		assert.Equal(t, "Feriado prolongado, com ponte", appointments[1].Reason)
+		assert.Equal(t, appointments_models.Skipped, appointments[2].Status)
+		assert.Equal(t, "Férias", appointments[2].Reason)
+		assert.Equal(t, appointments_models.Created, appointments[3].Status)
+
+		query = fmt.Sprintf(`mutation {
+			confirmAppointmentByPatient(id: %q)
+		}`, appointments[1].ID)
+
+		response = gql(router, query, storedVariables["patient_3_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"appointment status cannot change from SKIPPED to CONFIRMED_BY_PATIENT\",\"path\":[\"confirmAppointmentByPatient\"]}],\"data\":{\"confirmAppointmentByPatient\":null}}", response.Body.String())
+
+		// a holiday declared after the session was created makes it skipped in the next run of the job
+		query = fmt.Sprintf(`mutation {
+			createHoliday(input: {
+				date: %q,
+				name: "Feriado municipal"
+			})
+		}`, sessions[3].Format("2006-01-02"))
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createHoliday\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"there is already a holiday in this date\",\"path\":[\"createHoliday\"]}],\"data\":{\"createHoliday\":null}}", response.Body.String())
+
+		response = gql(router, createPendingQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"createPendingAppointments\":null}}", response.Body.String())
+
+		updatedAppointments := getAppointments()
+		assert.Equal(t, 4, len(updatedAppointments))
+		assert.Equal(t, appointments_models.Created, updatedAppointments[0].Status)
+		assert.Equal(t, appointments_models.Skipped, updatedAppointments[3].Status)
+		assert.Equal(t, "Feriado municipal", updatedAppointments[3].Reason)
+
+		response = gql(router, processPendingMailQuery, storedVariables["jobrunner_token"])
+
+		assert.Equal(t, "{\"data\":{\"processPendingMail\":null}}", response.Body.String())
+
+		// the patient gets a single mail for each run of the job, listing the sessions that will not happen
+		patientMails := map[interface{}]map[string]interface{}{}
+		for _, mail := range (*mailbox)[previousMails:] {
+			if reflect.DeepEqual(mail["to"], []string{"patient3@psi.com.br"}) {
+				patientMails[mail["subject"]] = mail
+			}
+		}
+		assert.Equal(t, 2, len(patientMails))
+		assert.Contains(t, patientMails["Consulta criada no PSI"]["body"], "gerou 2 novas consultas")
+		assert.Contains(t, patientMails["Consulta criada no PSI"]["body"], "não acontecerão")
+		assert.Contains(t, patientMails["Consulta criada no PSI"]["body"], "Feriado prolongado, com ponte")
+		assert.Contains(t, patientMails["Consulta criada no PSI"]["body"], "Férias")
+		assert.NotContains(t, patientMails["Consultas suspensas no PSI"]["body"], "novas consultas")
+		assert.Contains(t, patientMails["Consultas suspensas no PSI"]["body"], "Feriado municipal")
+
+		query = `{
+			holidays {
+				id
+				date
+				name
+			}
+		}`
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "Feriado municipal", fastjson.GetString(response.Body.Bytes(), "data", "holidays", "2", "name"))
+
+		deleteHolidayQuery := `mutation {
+			deleteHoliday(id: %q)
+		}`
+
+		for i := 0; i < 3; i++ {
+			holidayID := fastjson.GetString(response.Body.Bytes(), "data", "holidays", fmt.Sprint(i), "id")
+
+			deleteResponse := gql(router, fmt.Sprintf(deleteHolidayQuery, holidayID), storedVariables["coordinator_token"])
+
+			assert.Equal(t, "{\"data\":{\"deleteHoliday\":null}}", deleteResponse.Body.String())
+		}
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"holidays\":[]}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			deleteMyAbsence(id: %q)
+		}`, absenceID)
+
+		response = gql(router, query, storedVariables["patient_token"])
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"deleteMyAbsence\"]}],\"data\":{\"deleteMyAbsence\":null}}", response.Body.String())
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"deleteMyAbsence\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			finalizeTreatment(id: %q)
+		}`, treatment.ID)
+
+		response = gql(router, query, storedVariables["psychologist_token"])
+
+		assert.Equal(t, "{\"data\":{\"finalizeTreatment\":null}}", response.Body.String())
+
+	})
+	t.Run("should take every permission away from deactivated users", func(t *testing.T) {
+
+		query := `mutation {
+			createUserWithPassword(
+			  input: {
+				email: "warren.moon@psi.com.br"
+				password: "Xyz*()890"
+				role: "PATIENT"
+			  }
+			)
+		}`
+
+		response := gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: { 
+				email: "warren.moon@psi.com.br", 
+				password: "Xyz*()890"
+			}) { 
+				token 
+			} 
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+		assert.NotEqual(t, "", token)
+
+		myUserQuery := `{
+			myUser {
+				id
+				email
+			}
+		}`
+
+		response = gql(router, myUserQuery, token)
+
+		userID := fastjson.GetString(response.Body.Bytes(), "data", "myUser", "id")
+		assert.Equal(t, "warren.moon@psi.com.br", fastjson.GetString(response.Body.Bytes(), "data", "myUser", "email"))
+
+		updateQuery := `mutation {
+			updateUser(id: %q, input: {
+				active: %t,
+				role: "PATIENT"
+			})
+		}`
+
+		response = gql(router, fmt.Sprintf(updateQuery, userID, false), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"updateUser\":null}}", response.Body.String())
+
+		response = gql(router, myUserQuery, token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"forbidden\",\"path\":[\"myUser\"]}],\"data\":null}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(updateQuery, userID, true), storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"updateUser\":null}}", response.Body.String())
+
+		response = gql(router, myUserQuery, token)
+
+		assert.Equal(t, "warren.moon@psi.com.br", fastjson.GetString(response.Body.Bytes(), "data", "myUser", "email"))
+
+	})
+
+	t.Run("should export and erase the absences of psychologists", func(t *testing.T) {
+
+		query := `mutation {
+			createUserWithPassword(input: {
+				email: "ken.stabler@psi.com.br"
+				password: "Xyz*()890"
+				role: "PSYCHOLOGIST"
+			})
+		}`
+
+		response := gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: {
+				email: "ken.stabler@psi.com.br",
+				password: "Xyz*()890"
+			}) {
+				token
+			}
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		query = `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Kenneth Michael Stabler"
+				likeName: "Ken Stabler",
+				birthDate: "1945-12-25T00:00:00Z",
+				city: "Oakland - CA",
+				bio: "Hey there, my name is Ken",
+				crp: "02/654321",
+				whatsapp: "(11) 9876-1234",
+				instagram: "@kenstabler"
+			})
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		query = fmt.Sprintf(`mutation {
+			createMyAbsence(input: {
+				start: %q,
+				end: %q,
+				reason: "Cirurgia no joelho"
+			})
+		}`, time.Now().AddDate(0, 0, 7).Format(time.RFC3339), time.Now().AddDate(0, 0, 14).Format(time.RFC3339))
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"createMyAbsence\":null}}", response.Body.String())
+
+		query = `{
+			myUser {
+				id
+			}
+			myPsychologistProfile {
+				id
+			}
+		}`
+
+		response = gql(router, query, token)
+
+		userID := fastjson.GetString(response.Body.Bytes(), "data", "myUser", "id")
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		archive, archiveErr := res.GetPersonalDataArchiveService().Execute(userID)
+		assert.Equal(t, nil, archiveErr)
+
+		reader, zipErr := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		assert.Equal(t, nil, zipErr)
+
+		absences := ""
+		for _, file := range reader.File {
+			if file.Name == "absences.json" {
+				fileReader, _ := file.Open()
+				data, _ := io.ReadAll(fileReader)
+				absences = string(data)
+			}
+		}
+
+		assert.Contains(t, absences, "Cirurgia no joelho")
+
+		query = fmt.Sprintf(`mutation {
+			eraseUser(id: %q)
+		}`, userID)
+
+		response = gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"eraseUser\":null}}", response.Body.String())
+
+		remainingAbsences := int64(0)
+		res.OrmUtil.Db().Model(&appointments_models.Absence{}).Where("psychologist_id = ?", psychologistID).Count(&remainingAbsences)
+		assert.Equal(t, int64(0), remainingAbsences)
+
+	})
+
+	t.Run("should keep the sessions at the same moment when the psychologist changes time zone", func(t *testing.T) {
+
+		query := `mutation {
+			createUserWithPassword(input: {
+				email: "mike.webster@psi.com.br"
+				password: "Xyz*()890"
+				role: "PSYCHOLOGIST"
+			})
+		}`
+
+		response := gql(router, query, storedVariables["coordinator_token"])
+
+		assert.Equal(t, "{\"data\":{\"createUserWithPassword\":null}}", response.Body.String())
+
+		query = `{
+			authenticateUser(input: {
+				email: "mike.webster@psi.com.br",
+				password: "Xyz*()890"
+			}) {
+				token
+			}
+		}`
+
+		response = gql(router, query, "")
+
+		token := fastjson.GetString(response.Body.Bytes(), "data", "authenticateUser", "token")
+
+		upsertQuery := `mutation {
+			upsertMyPsychologistProfile(input: {
+				fullName: "Michael Lewis Webster"
+				likeName: "Mike Webster",
+				birthDate: "1952-03-18T00:00:00Z",
+				city: "Pittsburgh - PA",
+				bio: "Hey there, my name is Mike",
+				crp: "03/654321",
+				whatsapp: "(11) 9876-4321",
+				instagram: "@mikewebster",
+				timeZone: %q
+			})
+		}`
+
+		response = gql(router, fmt.Sprintf(upsertQuery, "America/Sao_Paulo"), token)
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+
+		query = `mutation {
+			setMyAvailability(input: [
+				{ weekday: MONDAY, startTime: "21:00", endTime: "22:00" },
+				{ weekday: WEDNESDAY, startTime: "09:00", endTime: "12:00" },
+				{ weekday: WEDNESDAY, startTime: "21:00", endTime: "22:00" }
+			])
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		query = `mutation {
+			createTreatment(input: {
+				frequency: 1,
+				weekday: WEDNESDAY,
+				startTime: "10:00",
+				duration: 3600,
+				priceRangeName: "medium"
+			})
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		query = `mutation {
+			createTreatment(input: {
+				frequency: 1,
+				weekday: WEDNESDAY,
+				startTime: "21:00",
+				recurrence: "FREQ=WEEKLY;BYDAY=MO,WE",
+				duration: 3600,
+				priceRangeName: "medium"
+			})
+		}`
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"createTreatment\":null}}", response.Body.String())
+
+		response = gql(router, `{
+			myPatientProfile {
+				id
+			}
+		}`, storedVariables["patient_3_token"])
+
+		patientID := fastjson.GetString(response.Body.Bytes(), "data", "myPatientProfile", "id")
+
+		treatmentsQuery := `{
+			myPsychologistProfile {
+				id
+				treatments {
+					id
+					weekday
+					startTime
+					recurrence
+				}
+			}
+		}`
+
+		response = gql(router, treatmentsQuery, token)
+
+		psychologistID := fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "id")
+
+		weeklyTreatmentID := ""
+		recurrentTreatmentID := ""
+		for _, treatment := range fastjson.MustParseBytes(response.Body.Bytes()).GetArray("data", "myPsychologistProfile", "treatments") {
+			if string(treatment.GetStringBytes("startTime")) == "10:00" {
+				weeklyTreatmentID = string(treatment.GetStringBytes("id"))
+			} else {
+				recurrentTreatmentID = string(treatment.GetStringBytes("id"))
+			}
+		}
+
+		res.OrmUtil.Db().Model(&treatments_models.Treatment{}).Where("id = ?", weeklyTreatmentID).Updates(map[string]interface{}{
+			"status":           treatments_models.Active,
+			"patient_id":       patientID,
+			"price_range_name": "medium",
+			"start_date":       time.Now(),
+		})
+
+		getTimeZone := func() string {
+			psychologist := profiles_models.Psychologist{}
+			res.OrmUtil.Db().Where("id = ?", psychologistID).Limit(1).Find(&psychologist)
+			return psychologist.TimeZone
+		}
+
+		// the sessions of treatments with a recurrence rule would not happen on the weekdays of the rule anymore
+		response = gql(router, fmt.Sprintf(upsertQuery, "Europe/Lisbon"), token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"sessions of treatments with a recurrence rule cannot move to another day in the new time zone\",\"path\":[\"upsertMyPsychologistProfile\"]}],\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+		assert.Equal(t, "America/Sao_Paulo", getTimeZone())
+
+		query = fmt.Sprintf(`mutation {
+			deleteTreatment(id: %q, priceRangeName: "medium")
+		}`, recurrentTreatmentID)
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"deleteTreatment\":null}}", response.Body.String())
+
+		// the availability is kept in the wall clock, so the moved sessions do not fit in it anymore
+		response = gql(router, fmt.Sprintf(upsertQuery, "Europe/Lisbon"), token)
+
+		assert.Equal(t, "{\"errors\":[{\"message\":\"treatment is outside the availability of the psychologist\",\"path\":[\"upsertMyPsychologistProfile\"]}],\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+		assert.Equal(t, "America/Sao_Paulo", getTimeZone())
+
+		response = gql(router, `mutation { setMyAvailability(input: []) }`, token)
+
+		assert.Equal(t, "{\"data\":{\"setMyAvailability\":null}}", response.Body.String())
+
+		response = gql(router, fmt.Sprintf(upsertQuery, "Europe/Lisbon"), token)
+
+		assert.Equal(t, "{\"data\":{\"upsertMyPsychologistProfile\":null}}", response.Body.String())
+		assert.Equal(t, "Europe/Lisbon", getTimeZone())
+
+		oldLocation, _ := time.LoadLocation("America/Sao_Paulo")
+		newLocation, _ := time.LoadLocation("Europe/Lisbon")
+		_, oldOffset := time.Now().In(oldLocation).Zone()
+		_, newOffset := time.Now().In(newLocation).Zone()
+		expectedStart := time.Date(2000, 1, 1, 10, 0, 0, 0, time.UTC).Add(time.Duration(newOffset-oldOffset) * time.Second)
+
+		response = gql(router, treatmentsQuery, token)
+
+		assert.Equal(t, expectedStart.Format("15:04"), fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "treatments", "0", "startTime"))
+		assert.Equal(t, "WEDNESDAY", fastjson.GetString(response.Body.Bytes(), "data", "myPsychologistProfile", "treatments", "0", "weekday"))
+
+		// the patient is told about the change
+		mail := mails_models.TransientMailMessage{}
+		res.OrmUtil.Db().Where("\"to\" = ? AND subject = ?", "patient3@psi.com.br", "Fuso horário do tratamento modificado no PSI").Limit(1).Find(&mail)
+		assert.NotEqual(t, "", mail.ID)
+		assert.Contains(t, mail.Html, "Michael Lewis Webster")
+
+		query = fmt.Sprintf(`mutation {
+			finalizeTreatment(id: %q)
+		}`, weeklyTreatmentID)
+
+		response = gql(router, query, token)
+
+		assert.Equal(t, "{\"data\":{\"finalizeTreatment\":null}}", response.Body.String())
+
+	})
+
 }
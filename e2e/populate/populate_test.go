@@ -12,10 +12,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/guicostaarantes/psi-server/utils/logging"
+	"github.com/guicostaarantes/psi-server/utils/otp"
 	"github.com/stretchr/testify/assert"
 	"github.com/valyala/fastjson"
 )
 
+var otpUtil = otp.TotpOtpUtil{
+	Issuer:      "PSI",
+	LoggingUtil: logging.PrintLoggingUtil{},
+}
+
 // WARNING: Running this test will make changes to your database state
 // Proceed with caution and avoid running this in production environments
 
@@ -24,16 +31,20 @@ import (
 var API_URL = "http://localhost:7070/gql"
 var TIMEOUT_SECONDS = 20
 var COORDINATOR_USERNAME = "coordinator@psi.com.br"
-var COORDINATOR_PASSWORD = "Abc123!@#"
+var COORDINATOR_PASSWORD = "Psi#Admin2024!"
+
+// Two factor authentication is mandatory for coordinators and psychologists. Leave COORDINATOR_OTP_SECRET empty in the
+// first run and the bootstrap coordinator will set it up, writing the secret to the report. Fill it in for the next runs
+var COORDINATOR_OTP_SECRET = ""
 var NEW_JOBRUNNER_USERNAME = "jobrunner@psi.com.br"
-var NEW_JOBRUNNER_PASSWORD = "Abc123!@#"
+var NEW_JOBRUNNER_PASSWORD = "Psi#Robot2024!"
 var BATCH_SIZE = 10
 var PSYCOLOGIST_START = 1
 var PSYCHOLOGIST_BATCHES = 5
 var PATIENT_START = 1
 var PATIENT_BATCHES = 5
-var NEW_PSYCHOLOGISTS_PASSWORD = "Abc123!@#"
-var NEW_PATIENTS_PASSWORD = "Abc123!@#"
+var NEW_PSYCHOLOGISTS_PASSWORD = "Psi#Psychologist2024"
+var NEW_PATIENTS_PASSWORD = "Psi#Patient2024"
 var TREATMENTS_PER_PSYCHOLOGIST = 2
 
 func gql(client *http.Client, query string, token string, report *os.File) (*http.Response, error) {
@@ -66,6 +77,62 @@ func gql(client *http.Client, query string, token string, report *os.File) (*htt
 
 }
 
+// setUpTwoFactor enables two factor authentication for a user whose session is waiting for it, returning the secret
+// and a new access token that is not restricted anymore
+func setUpTwoFactor(client *http.Client, token string, refreshToken string, report *os.File) (string, string, error) {
+
+	query := `mutation {
+		setupTwoFactor {
+			secret
+		}
+	}`
+
+	response, err := gql(client, query, token, report)
+	if err != nil {
+		return "", "", err
+	}
+	body, _ := ioutil.ReadAll(response.Body)
+
+	secret := fastjson.GetString(body, "data", "setupTwoFactor", "secret")
+	if secret == "" {
+		return "", "", fmt.Errorf("could not set up two factor authentication: %s", body)
+	}
+
+	code, err := otpUtil.GenerateCode(secret, time.Now())
+	if err != nil {
+		return "", "", err
+	}
+
+	query = fmt.Sprintf(`mutation {
+		confirmTwoFactor(code: %q)
+	}`, code)
+
+	response, err = gql(client, query, token, report)
+	if err != nil {
+		return "", "", err
+	}
+	body, _ = ioutil.ReadAll(response.Body)
+
+	if string(body) != "{\"data\":{\"confirmTwoFactor\":null}}" {
+		return "", "", fmt.Errorf("could not confirm two factor authentication: %s", body)
+	}
+
+	query = fmt.Sprintf(`{
+		refreshAuthentication(refreshToken: %q) {
+			token
+		}
+	}`, refreshToken)
+
+	response, err = gql(client, query, "", report)
+	if err != nil {
+		return "", "", err
+	}
+	body, _ = ioutil.ReadAll(response.Body)
+
+	return secret, fastjson.GetString(body, "data", "refreshAuthentication", "token"), nil
+
+}
+
 func TestPopulateScript(t *testing.T) {
 
 	os.Setenv("TZ", "UTC")
@@ -89,16 +156,24 @@ func TestPopulateScript(t *testing.T) {
 
 	t.Run("should log in as bootstrap coordinator", func(t *testing.T) {
 
+		otpCode := ""
+		if COORDINATOR_OTP_SECRET != "" {
+			otpCode, _ = otpUtil.GenerateCode(COORDINATOR_OTP_SECRET, time.Now())
+		}
+
 		query := fmt.Sprintf(`{
 			authenticateUser(
 				input: {
 					email: %q,
 					password: %q,
+					otpCode: %q
 				}
 			) {
 				token
+				refreshToken
+				twoFactorPending
 			}
-		}`, COORDINATOR_USERNAME, COORDINATOR_PASSWORD)
+		}`, COORDINATOR_USERNAME, COORDINATOR_PASSWORD, otpCode)
 
 		response, err := gql(client, query, "", report)
 		if err != nil {
@@ -109,6 +184,18 @@ func TestPopulateScript(t *testing.T) {
 		token := fastjson.GetString(body, "data", "authenticateUser", "token")
 		assert.NotEqual(t, "", token)
 
+		if fastjson.GetBool(body, "data", "authenticateUser", "twoFactorPending") {
+			secret, newToken, err := setUpTwoFactor(client, token, fastjson.GetString(body, "data", "authenticateUser", "refreshToken"), report)
+			if err != nil {
+				panic(err)
+			}
+
+			report.WriteString(fmt.Sprintf("Set COORDINATOR_OTP_SECRET to %s for the next runs\n", secret))
+
+			token = newToken
+			assert.NotEqual(t, "", token)
+		}
+
 		storedVariables["coordinator_token"] = token
 
 	})
@@ -120,7 +207,7 @@ func TestPopulateScript(t *testing.T) {
 			  input: {
 					email: %q,
 					password: %q,
-					role: JOBRUNNER
+					role: "JOBRUNNER"
 			  }
 			)
 		}`, NEW_JOBRUNNER_USERNAME, NEW_JOBRUNNER_PASSWORD)
@@ -752,7 +839,7 @@ func TestPopulateScript(t *testing.T) {
 							input: {
 								email: %q,
 								password: %q,
-								role: PSYCHOLOGIST
+								role: "PSYCHOLOGIST"
 							}
 						)
 					}`, email, NEW_PSYCHOLOGISTS_PASSWORD)
@@ -775,6 +862,7 @@ func TestPopulateScript(t *testing.T) {
 							}
 						) {
 							token
+							refreshToken
 						}
 					}`, email, NEW_PSYCHOLOGISTS_PASSWORD)
 
@@ -786,7 +874,12 @@ func TestPopulateScript(t *testing.T) {
 					}
 					body, _ = ioutil.ReadAll(response.Body)
 
-					token := fastjson.GetString(body, "data", "authenticateUser", "token")
+					_, token, err := setUpTwoFactor(client, fastjson.GetString(body, "data", "authenticateUser", "token"), fastjson.GetString(body, "data", "authenticateUser", "refreshToken"), report)
+					if err != nil {
+						fmt.Println(err)
+						wg.Done()
+						return
+					}
 					assert.NotEqual(t, "", token)
 
 					query = fmt.Sprintf(`mutation {
@@ -1034,12 +1127,13 @@ func TestPopulateScript(t *testing.T) {
 							createTreatment(
 								input: {
 									frequency: 1,
-									phase: %d,
+									weekday: %s,
+									startTime: "%02d:00",
 									duration: 3000,
 									priceRangeName: %q
 								}
 							)
-						}`, 3600*rand.Intn(168), []string{"free", "low", "medium", "high"}[rand.Intn(4)])
+						}`, []string{"SUNDAY", "MONDAY", "TUESDAY", "WEDNESDAY", "THURSDAY", "FRIDAY", "SATURDAY"}[rand.Intn(7)], rand.Intn(24), []string{"free", "low", "medium", "high"}[rand.Intn(4)])
 
 						response, err = gql(client, query, token, report)
 						if err != nil {
@@ -1089,7 +1183,7 @@ func TestPopulateScript(t *testing.T) {
 							input: {
 								email: %q,
 								password: %q,
-								role: PATIENT
+								role: "PATIENT"
 							}
 						)
 					}`, email, NEW_PATIENTS_PASSWORD)
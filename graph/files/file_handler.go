@@ -14,14 +14,22 @@ type FileHandler struct {
 func (f FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	userID := ctx.Value("userID").(string)
+	twoFactorPending := ctx.Value("twoFactorPending").(bool)
 
-	if userID == "" {
+	if userID == "" || twoFactorPending {
 		w.WriteHeader(403)
 		w.Header().Set("Content-Type", "application/json")
 		w.Write([]byte("403 forbidden"))
 		return
 	}
 
+	exportToken := chi.URLParam(r, "token")
+
+	if exportToken != "" {
+		f.serveDataExport(w, r, exportToken, userID)
+		return
+	}
+
 	if r.Method == "GET" {
 		fileName := chi.URLParam(r, "name")
 
@@ -45,3 +53,35 @@ func (f FileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(404)
 	w.Write([]byte("404 page not found"))
 }
+
+// serveDataExport serves a copy of personal data to the user that requested it. Besides being logged in, the user needs
+// the token sent to their email, so that a leaked session is not enough to download the copy
+func (f FileHandler) serveDataExport(w http.ResponseWriter, r *http.Request, token string, userID string) {
+	if r.Method != "GET" {
+		w.WriteHeader(404)
+		w.Write([]byte("404 page not found"))
+		return
+	}
+
+	data, readErr := f.Resolvers.GetDataExportFileService().Execute(token, userID)
+	if readErr != nil {
+		if readErr.Error() == "invalid token" {
+			w.WriteHeader(404)
+			w.Write([]byte("404 page not found"))
+			return
+		}
+		w.WriteHeader(500)
+		w.Write([]byte("500 internal server error"))
+		return
+	}
+
+	if len(data) == 0 {
+		w.WriteHeader(404)
+		w.Write([]byte("404 page not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="psi-meus-dados.zip"`)
+	w.Write(data)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/guicostaarantes/psi-server/graph/generated"
 	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
 	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
@@ -64,12 +65,50 @@ func (r *mutationResolver) ConfirmAppointmentByPsychologist(ctx context.Context,
 	return nil, serviceErr
 }
 
+func (r *mutationResolver) CreateHoliday(ctx context.Context, input appointments_models.CreateHolidayInput) (*bool, error) {
+	serviceErr := r.CreateHolidayService().Execute(input)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) CreateMyAbsence(ctx context.Context, input appointments_models.CreateAbsenceInput) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	servicePsy, servicePsyErr := r.GetPsychologistByUserIDService().Execute(userID)
+	if servicePsyErr != nil {
+		return nil, servicePsyErr
+	}
+
+	serviceErr := r.CreateAbsenceService().Execute(servicePsy.ID, input)
+
+	return nil, serviceErr
+}
+
 func (r *mutationResolver) CreatePendingAppointments(ctx context.Context) (*bool, error) {
 	serviceErr := r.CreatePendingAppointmentsService().Execute()
 
 	return nil, serviceErr
 }
 
+func (r *mutationResolver) DeleteHoliday(ctx context.Context, id string) (*bool, error) {
+	serviceErr := r.DeleteHolidayService().Execute(id)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) DeleteMyAbsence(ctx context.Context, id string) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	servicePsy, servicePsyErr := r.GetPsychologistByUserIDService().Execute(userID)
+	if servicePsyErr != nil {
+		return nil, servicePsyErr
+	}
+
+	serviceErr := r.DeleteAbsenceService().Execute(id, servicePsy.ID)
+
+	return nil, serviceErr
+}
+
 func (r *mutationResolver) EditAppointmentByPatient(ctx context.Context, id string, input appointments_models.EditAppointmentByPatientInput) (*bool, error) {
 	userID := ctx.Value("userID").(string)
 
@@ -96,6 +135,10 @@ func (r *mutationResolver) EditAppointmentByPsychologist(ctx context.Context, id
 	return nil, serviceErr
 }
 
+func (r *mutationResolver) ImportHolidays(ctx context.Context, file graphql.Upload) ([]*appointments_models.Holiday, error) {
+	return r.ImportHolidaysService().Execute(file)
+}
+
 func (r *patientAppointmentResolver) PriceRange(ctx context.Context, obj *appointments_models.Appointment) (*treatments_models.TreatmentPriceRange, error) {
 	return r.GetTreatmentPriceRangeByNameService().Execute(obj.PriceRangeName)
 }
@@ -112,6 +155,21 @@ func (r *psychologistAppointmentResolver) Treatment(ctx context.Context, obj *ap
 	return r.GetTreatmentForPsychologistService().Execute(obj.TreatmentID)
 }
 
+func (r *queryResolver) Holidays(ctx context.Context) ([]*appointments_models.Holiday, error) {
+	return r.GetHolidaysService().Execute()
+}
+
+func (r *queryResolver) MyAbsences(ctx context.Context) ([]*appointments_models.Absence, error) {
+	userID := ctx.Value("userID").(string)
+
+	servicePsy, servicePsyErr := r.GetPsychologistByUserIDService().Execute(userID)
+	if servicePsyErr != nil {
+		return nil, servicePsyErr
+	}
+
+	return r.GetAbsencesService().Execute(servicePsy.ID)
+}
+
 // PatientAppointment returns generated.PatientAppointmentResolver implementation.
 func (r *Resolver) PatientAppointment() generated.PatientAppointmentResolver {
 	return &patientAppointmentResolver{r}
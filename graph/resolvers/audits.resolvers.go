@@ -0,0 +1,29 @@
+package resolvers
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"strings"
+
+	"github.com/guicostaarantes/psi-server/graph/generated"
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
+)
+
+func (r *auditEntryResolver) TargetIds(ctx context.Context, obj *audits_models.AuditEntry) ([]string, error) {
+	if obj.TargetIDs == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(obj.TargetIDs, ","), nil
+}
+
+func (r *queryResolver) AuditLog(ctx context.Context, filter *audits_models.AuditLogFilterInput, offset int64, limit int64) (*audits_models.AuditLogPage, error) {
+	return r.GetAuditLogService().Execute(filter, offset, limit)
+}
+
+// AuditEntry returns generated.AuditEntryResolver implementation.
+func (r *Resolver) AuditEntry() generated.AuditEntryResolver { return &auditEntryResolver{r} }
+
+type auditEntryResolver struct{ *Resolver }
@@ -14,6 +14,34 @@ import (
 	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
 )
 
+func (r *mutationResolver) ApprovePsychologistVerification(ctx context.Context, id string, reason string) (*bool, error) {
+	serviceErr := r.ReviewPsychologistVerificationService().Execute(&profiles_models.ReviewPsychologistVerificationInput{
+		ID:         id,
+		ReviewerID: ctx.Value("userID").(string),
+		Status:     profiles_models.Verified,
+		Reason:     reason,
+	})
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	return nil, nil
+}
+
+func (r *mutationResolver) RejectPsychologistVerification(ctx context.Context, id string, reason string) (*bool, error) {
+	serviceErr := r.ReviewPsychologistVerificationService().Execute(&profiles_models.ReviewPsychologistVerificationInput{
+		ID:         id,
+		ReviewerID: ctx.Value("userID").(string),
+		Status:     profiles_models.Rejected,
+		Reason:     reason,
+	})
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	return nil, nil
+}
+
 func (r *mutationResolver) SetMyPatientCharacteristicChoices(ctx context.Context, input []*characteristics_models.SetCharacteristicChoiceInput) (*bool, error) {
 	userID := ctx.Value("userID").(string)
 
@@ -170,8 +198,12 @@ func (r *queryResolver) PatientProfile(ctx context.Context, id string) (*profile
 	return r.GetPatientService().Execute(id)
 }
 
+func (r *queryResolver) PsychologistsPendingVerification(ctx context.Context) ([]*profiles_models.Psychologist, error) {
+	return r.GetPendingVerificationsService().Execute()
+}
+
 func (r *queryResolver) PsychologistProfile(ctx context.Context, id string) (*profiles_models.Psychologist, error) {
-	return r.GetPsychologistService().Execute(id)
+	return r.GetVerifiedPsychologistService().Execute(id)
 }
 
 // PatientProfile returns generated.PatientProfileResolver implementation.
@@ -1,10 +1,12 @@
 package resolvers
 
 import (
+	"net"
 	"time"
 
 	agreements_services "github.com/guicostaarantes/psi-server/modules/agreements/services"
 	appointments_services "github.com/guicostaarantes/psi-server/modules/appointments/services"
+	audits_services "github.com/guicostaarantes/psi-server/modules/audits/services"
 	characteristics_services "github.com/guicostaarantes/psi-server/modules/characteristics/services"
 	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
 	files_services "github.com/guicostaarantes/psi-server/modules/files/services"
@@ -12,14 +14,21 @@ import (
 	profiles_services "github.com/guicostaarantes/psi-server/modules/profiles/services"
 	translations_services "github.com/guicostaarantes/psi-server/modules/translations/services"
 	treatments_services "github.com/guicostaarantes/psi-server/modules/treatments/services"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 	users_services "github.com/guicostaarantes/psi-server/modules/users/services"
+	"github.com/guicostaarantes/psi-server/utils/archive"
+	"github.com/guicostaarantes/psi-server/utils/calendar"
+	"github.com/guicostaarantes/psi-server/utils/digest"
 	"github.com/guicostaarantes/psi-server/utils/file_storage"
 	"github.com/guicostaarantes/psi-server/utils/hash"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/mail"
 	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/guicostaarantes/psi-server/utils/oidc"
 	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/otp"
 	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/table"
 	"github.com/guicostaarantes/psi-server/utils/token"
 )
 
@@ -30,71 +39,158 @@ import (
 // Resolver receives all utils and registers all services within the application
 type Resolver struct {
 	OrmUtil                                   orm.IOrmUtil
+	ArchiveUtil                               archive.IArchiveUtil
+	CalendarUtil                              calendar.ICalendarUtil
+	DigestUtil                                digest.IDigestUtil
+	ExportStorageUtil                         file_storage.IFileStorageUtil
 	FileStorageUtil                           file_storage.IFileStorageUtil
 	HashUtil                                  hash.IHashUtil
 	IdentifierUtil                            identifier.IIdentifierUtil
 	MailUtil                                  mail.IMailUtil
 	MatchUtil                                 match.IMatchUtil
+	OidcUtil                                  oidc.IOidcUtil
+	OtpUtil                                   otp.IOtpUtil
 	SerializingUtil                           serializing.ISerializingUtil
+	TableUtil                                 table.ITableUtil
 	TokenUtil                                 token.ITokenUtil
 	MaxAffinityNumber                         int64
+	MaxAuditLogPageSize                       int64
+	MaxUsersPageSize                          int64
+	AppointmentHorizonOccurrences             int64
 	ScheduleIntervalDuration                  time.Duration
+	FreeSlotStepDuration                      time.Duration
 	ExpireAuthTokenDuration                   time.Duration
+	ExpireRefreshTokenDuration                time.Duration
 	ExpireResetTokenDuration                  time.Duration
+	ExpireEmailChangeTokenDuration            time.Duration
+	ExpireDataExportDuration                  time.Duration
+	ExpireImpersonationTokenDuration          time.Duration
+	ExpireInvitationDuration                  time.Duration
+	ExpireOidcAuthorizationDuration           time.Duration
 	InterruptTreatmentCooldownDuration        time.Duration
 	TopAffinitiesCooldownDuration             time.Duration
+	LoginFailedCooldownDuration               time.Duration
+	LoginFailedDelayDuration                  time.Duration
+	LoginLockedCooldownDuration               time.Duration
+	MaxLoginFailuresPerUser                   int64
+	MaxLoginFailuresPerIPAddress              int64
+	RecoveryCodesQuantity                     int64
+	TwoFactorRequiredRoles                    []users_models.Role
+	ImpersonationAllowedMutations             []string
+	TrustedProxies                            []*net.IPNet
+	OidcDefaultRole                           users_models.Role
 	askResetPasswordService                   *users_services.AskResetPasswordService
 	assignTreatmentService                    *treatments_services.AssignTreatmentService
 	authenticateUserService                   *users_services.AuthenticateUserService
+	authenticateWithOidcService               *users_services.AuthenticateWithOidcService
 	cancelAppointmentByPatientService         *appointments_services.CancelAppointmentByPatientService
 	cancelAppointmentByPsychologistService    *appointments_services.CancelAppointmentByPsychologistService
+	changeMyEmailService                      *users_services.ChangeMyEmailService
+	changeMyPasswordService                   *users_services.ChangeMyPasswordService
+	changeTreatmentsTimeZoneService           *treatments_services.ChangeTreatmentsTimeZoneService
+	checkTreatmentAvailabilityService         *treatments_services.CheckTreatmentAvailabilityService
 	checkTreatmentCollisionService            *treatments_services.CheckTreatmentCollisionService
 	confirmAppointmentByPatientService        *appointments_services.ConfirmAppointmentByPatientService
 	confirmAppointmentByPsychologistService   *appointments_services.ConfirmAppointmentByPsychologistService
+	confirmEmailChangeService                 *users_services.ConfirmEmailChangeService
+	confirmTwoFactorService                   *users_services.ConfirmTwoFactorService
+	createAbsenceService                      *appointments_services.CreateAbsenceService
+	createHolidayService                      *appointments_services.CreateHolidayService
 	createPendingAppointmentsService          *appointments_services.CreatePendingAppointmentsService
+	createRoleService                         *users_services.CreateRoleService
+	createSessionService                      *users_services.CreateSessionService
 	createTreatmentService                    *treatments_services.CreateTreatmentService
 	createUserService                         *users_services.CreateUserService
 	createUserWithPasswordService             *users_services.CreateUserWithPasswordService
+	deleteAbsenceService                      *appointments_services.DeleteAbsenceService
+	deleteAvatarFileService                   *files_services.DeleteAvatarFileService
+	deleteCooldownsService                    *cooldowns_services.DeleteCooldownsService
+	deleteDataExportsService                  *users_services.DeleteDataExportsService
+	deleteHolidayService                      *appointments_services.DeleteHolidayService
+	deleteMyAccountService                    *users_services.DeleteMyAccountService
 	deleteTreatmentService                    *treatments_services.DeleteTreatmentService
+	disableTwoFactorService                   *users_services.DisableTwoFactorService
 	editAppointmentByPatientService           *appointments_services.EditAppointmentByPatientService
 	editAppointmentByPsychologistService      *appointments_services.EditAppointmentByPsychologistService
+	erasePatientService                       *profiles_services.ErasePatientService
+	erasePsychologistService                  *profiles_services.ErasePsychologistService
+	eraseUserService                          *users_services.EraseUserService
 	finalizeTreatmentService                  *treatments_services.FinalizeTreatmentService
+	getAbsencesService                        *appointments_services.GetAbsencesService
 	getAgreementsByProfileIdService           *agreements_services.GetAgreementsByProfileIdService
 	getAppointmentsOfPatientService           *appointments_services.GetAppointmentsOfPatientService
 	getAppointmentsOfPsychologistService      *appointments_services.GetAppointmentsOfPsychologistService
+	getAuditLogService                        *audits_services.GetAuditLogService
+	getAvailabilityWindowsService             *treatments_services.GetAvailabilityWindowsService
 	getCharacteristicsByIDService             *characteristics_services.GetCharacteristicsByIDService
 	getCharacteristicsService                 *characteristics_services.GetCharacteristicsService
 	getCooldownService                        *cooldowns_services.GetCooldownService
+	getCooldownsService                       *cooldowns_services.GetCooldownsService
+	getDataExportFileService                  *users_services.GetDataExportFileService
+	getFreeSlotsService                       *treatments_services.GetFreeSlotsService
+	getHolidaysService                        *appointments_services.GetHolidaysService
+	getLockedUsersService                     *users_services.GetLockedUsersService
 	getPatientByUserIDService                 *profiles_services.GetPatientByUserIDService
 	getPatientService                         *profiles_services.GetPatientService
 	getPatientTreatmentsService               *treatments_services.GetPatientTreatmentsService
+	getPendingInvitationsService              *users_services.GetPendingInvitationsService
+	getPermissionsByRoleService               *users_services.GetPermissionsByRoleService
+	getPermissionsService                     *users_services.GetPermissionsService
+	getPersonalDataArchiveService             *users_services.GetPersonalDataArchiveService
 	getPreferencesByIDService                 *characteristics_services.GetPreferencesByIDService
 	getPsychologistByUserIDService            *profiles_services.GetPsychologistByUserIDService
 	getPsychologistService                    *profiles_services.GetPsychologistService
 	getPsychologistPendingTreatmentsService   *treatments_services.GetPsychologistPendingTreatmentsService
 	getPsychologistPriceRangeOfferingsService *treatments_services.GetPsychologistPriceRangeOfferingsService
+	getPendingVerificationsService            *profiles_services.GetPendingVerificationsService
 	getPsychologistTreatmentsService          *treatments_services.GetPsychologistTreatmentsService
+	getRolesService                           *users_services.GetRolesService
+	getSessionsByUserIDService                *users_services.GetSessionsByUserIDService
 	getTermsByProfileTypeService              *agreements_services.GetTermsByProfileTypeService
 	getTopAffinitiesForPatientService         *characteristics_services.GetTopAffinitiesForPatientService
 	getTranslationsService                    *translations_services.GetTranslationsService
 	getTreatmentForPatientService             *treatments_services.GetTreatmentForPatientService
 	getTreatmentForPsychologistService        *treatments_services.GetTreatmentForPsychologistService
+	getTreatmentOccurrencesService            *treatments_services.GetTreatmentOccurrencesService
 	getTreatmentPriceRangeByNameService       *treatments_services.GetTreatmentPriceRangeByNameService
 	getTreatmentPriceRangesService            *treatments_services.GetTreatmentPriceRangesService
 	getUserByIDService                        *users_services.GetUserByIDService
 	getUsersByRoleService                     *users_services.GetUsersByRoleService
+	getUsersService                           *users_services.GetUsersService
+	getVerifiedPsychologistService            *profiles_services.GetVerifiedPsychologistService
+	hashStoredTokensService                   *users_services.HashStoredTokensService
+	impersonateUserService                    *users_services.ImpersonateUserService
+	importHolidaysService                     *appointments_services.ImportHolidaysService
 	interruptTreatmentByPatientService        *treatments_services.InterruptTreatmentByPatientService
 	interruptTreatmentByPsychologistService   *treatments_services.InterruptTreatmentByPsychologistService
+	invitePsychologistsFromFileService        *users_services.InvitePsychologistsFromFileService
+	processPendingDataExportsService          *users_services.ProcessPendingDataExportsService
 	processPendingMailsService                *mails_services.ProcessPendingMailsService
+	recordAuditEntryService                   *audits_services.RecordAuditEntryService
+	refreshAuthenticationService              *users_services.RefreshAuthenticationService
+	registerLoginFailureService               *users_services.RegisterLoginFailureService
+	requestDataExportService                  *users_services.RequestDataExportService
+	resendInvitationService                   *users_services.ResendInvitationService
 	resetPasswordService                      *users_services.ResetPasswordService
 	readFileService                           *files_services.ReadFileService
+	reviewPsychologistVerificationService     *profiles_services.ReviewPsychologistVerificationService
+	revokeAllOtherSessionsService             *users_services.RevokeAllOtherSessionsService
+	revokeInvitationService                   *users_services.RevokeInvitationService
+	revokeSessionService                      *users_services.RevokeSessionService
 	saveCooldownService                       *cooldowns_services.SaveCooldownService
+	seedPermissionsService                    *users_services.SeedPermissionsService
+	sendInvitationService                     *users_services.SendInvitationService
+	setAvailabilityWindowsService             *treatments_services.SetAvailabilityWindowsService
 	setCharacteristicChoicesService           *characteristics_services.SetCharacteristicChoicesService
 	setCharacteristicsService                 *characteristics_services.SetCharacteristicsService
 	setPreferencesService                     *characteristics_services.SetPreferencesService
+	setRolePermissionsService                 *users_services.SetRolePermissionsService
 	setTopAffinitiesForPatientService         *characteristics_services.SetTopAffinitiesForPatientService
 	setTranslationsService                    *translations_services.SetTranslationsService
 	setTreatmentPriceRangesService            *treatments_services.SetTreatmentPriceRangesService
+	setupTwoFactorService                     *users_services.SetupTwoFactorService
+	startOidcAuthenticationService            *users_services.StartOidcAuthenticationService
+	unlockUserService                         *users_services.UnlockUserService
 	updateTreatmentService                    *treatments_services.UpdateTreatmentService
 	updateUserService                         *users_services.UpdateUserService
 	uploadAvatarFileService                   *files_services.UploadAvatarFileService
@@ -102,6 +198,7 @@ type Resolver struct {
 	upsertPatientService                      *profiles_services.UpsertPatientService
 	upsertPsychologistService                 *profiles_services.UpsertPsychologistService
 	upsertTermService                         *agreements_services.UpsertTermService
+	validateTwoFactorCodeService              *users_services.ValidateTwoFactorCodeService
 	validateUserTokenService                  *users_services.ValidateUserTokenService
 }
 
@@ -109,6 +206,7 @@ type Resolver struct {
 func (r *Resolver) AskResetPasswordService() *users_services.AskResetPasswordService {
 	if r.askResetPasswordService == nil {
 		r.askResetPasswordService = &users_services.AskResetPasswordService{
+			DigestUtil:               r.DigestUtil,
 			IdentifierUtil:           r.IdentifierUtil,
 			OrmUtil:                  r.OrmUtil,
 			TokenUtil:                r.TokenUtil,
@@ -133,16 +231,35 @@ func (r *Resolver) AssignTreatmentService() *treatments_services.AssignTreatment
 func (r *Resolver) AuthenticateUserService() *users_services.AuthenticateUserService {
 	if r.authenticateUserService == nil {
 		r.authenticateUserService = &users_services.AuthenticateUserService{
-			HashUtil:                r.HashUtil,
-			OrmUtil:                 r.OrmUtil,
-			SerializingUtil:         r.SerializingUtil,
-			TokenUtil:               r.TokenUtil,
-			ExpireAuthTokenDuration: r.ExpireAuthTokenDuration,
+			HashUtil:                    r.HashUtil,
+			OrmUtil:                     r.OrmUtil,
+			DeleteCooldownsService:      r.DeleteCooldownsService(),
+			GetCooldownService:          r.GetCooldownService(),
+			GetCooldownsService:         r.GetCooldownsService(),
+			CreateSessionService:        r.CreateSessionService(),
+			RegisterLoginFailureService: r.RegisterLoginFailureService(),
+			LoginFailedDelayDuration:    r.LoginFailedDelayDuration,
 		}
 	}
 	return r.authenticateUserService
 }
 
+// AuthenticateWithOidcService gets or sets the service with same name
+func (r *Resolver) AuthenticateWithOidcService() *users_services.AuthenticateWithOidcService {
+	if r.authenticateWithOidcService == nil {
+		r.authenticateWithOidcService = &users_services.AuthenticateWithOidcService{
+			DigestUtil:           r.DigestUtil,
+			IdentifierUtil:       r.IdentifierUtil,
+			OidcUtil:             r.OidcUtil,
+			OrmUtil:              r.OrmUtil,
+			GetCooldownService:   r.GetCooldownService(),
+			CreateSessionService: r.CreateSessionService(),
+			OidcDefaultRole:      r.OidcDefaultRole,
+		}
+	}
+	return r.authenticateWithOidcService
+}
+
 // CancelAppointmentByPatientService gets or sets the service with same name
 func (r *Resolver) CancelAppointmentByPatientService() *appointments_services.CancelAppointmentByPatientService {
 	if r.cancelAppointmentByPatientService == nil {
@@ -165,6 +282,58 @@ func (r *Resolver) CancelAppointmentByPsychologistService() *appointments_servic
 	return r.cancelAppointmentByPsychologistService
 }
 
+// ChangeMyEmailService gets or sets the service with same name
+func (r *Resolver) ChangeMyEmailService() *users_services.ChangeMyEmailService {
+	if r.changeMyEmailService == nil {
+		r.changeMyEmailService = &users_services.ChangeMyEmailService{
+			DigestUtil:                     r.DigestUtil,
+			HashUtil:                       r.HashUtil,
+			IdentifierUtil:                 r.IdentifierUtil,
+			MatchUtil:                      r.MatchUtil,
+			OrmUtil:                        r.OrmUtil,
+			TokenUtil:                      r.TokenUtil,
+			ExpireEmailChangeTokenDuration: r.ExpireEmailChangeTokenDuration,
+		}
+	}
+	return r.changeMyEmailService
+}
+
+// ChangeMyPasswordService gets or sets the service with same name
+func (r *Resolver) ChangeMyPasswordService() *users_services.ChangeMyPasswordService {
+	if r.changeMyPasswordService == nil {
+		r.changeMyPasswordService = &users_services.ChangeMyPasswordService{
+			HashUtil:                      r.HashUtil,
+			MatchUtil:                     r.MatchUtil,
+			OrmUtil:                       r.OrmUtil,
+			RevokeAllOtherSessionsService: r.RevokeAllOtherSessionsService(),
+		}
+	}
+	return r.changeMyPasswordService
+}
+
+// ChangeTreatmentsTimeZoneService gets or sets the service with same name
+func (r *Resolver) ChangeTreatmentsTimeZoneService() *treatments_services.ChangeTreatmentsTimeZoneService {
+	if r.changeTreatmentsTimeZoneService == nil {
+		r.changeTreatmentsTimeZoneService = &treatments_services.ChangeTreatmentsTimeZoneService{
+			IdentifierUtil:                    r.IdentifierUtil,
+			OrmUtil:                           r.OrmUtil,
+			CheckTreatmentAvailabilityService: r.CheckTreatmentAvailabilityService(),
+			ScheduleIntervalDuration:          r.ScheduleIntervalDuration,
+		}
+	}
+	return r.changeTreatmentsTimeZoneService
+}
+
+// CheckTreatmentAvailabilityService gets or sets the service with same name
+func (r *Resolver) CheckTreatmentAvailabilityService() *treatments_services.CheckTreatmentAvailabilityService {
+	if r.checkTreatmentAvailabilityService == nil {
+		r.checkTreatmentAvailabilityService = &treatments_services.CheckTreatmentAvailabilityService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.checkTreatmentAvailabilityService
+}
+
 // CheckTreatmentCollisionService gets or sets the service with same name
 func (r *Resolver) CheckTreatmentCollisionService() *treatments_services.CheckTreatmentCollisionService {
 	if r.checkTreatmentCollisionService == nil {
@@ -196,25 +365,100 @@ func (r *Resolver) ConfirmAppointmentByPsychologistService() *appointments_servi
 	return r.confirmAppointmentByPsychologistService
 }
 
+// ConfirmEmailChangeService gets or sets the service with same name
+func (r *Resolver) ConfirmEmailChangeService() *users_services.ConfirmEmailChangeService {
+	if r.confirmEmailChangeService == nil {
+		r.confirmEmailChangeService = &users_services.ConfirmEmailChangeService{
+			DigestUtil: r.DigestUtil,
+			OrmUtil:    r.OrmUtil,
+		}
+	}
+	return r.confirmEmailChangeService
+}
+
+// ConfirmTwoFactorService gets or sets the service with same name
+func (r *Resolver) ConfirmTwoFactorService() *users_services.ConfirmTwoFactorService {
+	if r.confirmTwoFactorService == nil {
+		r.confirmTwoFactorService = &users_services.ConfirmTwoFactorService{
+			OrmUtil: r.OrmUtil,
+			OtpUtil: r.OtpUtil,
+		}
+	}
+	return r.confirmTwoFactorService
+}
+
+// CreateAbsenceService gets or sets the service with same name
+func (r *Resolver) CreateAbsenceService() *appointments_services.CreateAbsenceService {
+	if r.createAbsenceService == nil {
+		r.createAbsenceService = &appointments_services.CreateAbsenceService{
+			IdentifierUtil: r.IdentifierUtil,
+			OrmUtil:        r.OrmUtil,
+		}
+	}
+	return r.createAbsenceService
+}
+
+// CreateHolidayService gets or sets the service with same name
+func (r *Resolver) CreateHolidayService() *appointments_services.CreateHolidayService {
+	if r.createHolidayService == nil {
+		r.createHolidayService = &appointments_services.CreateHolidayService{
+			IdentifierUtil: r.IdentifierUtil,
+			OrmUtil:        r.OrmUtil,
+		}
+	}
+	return r.createHolidayService
+}
+
 // CreatePendingAppointmentsService gets or sets the service with same name
 func (r *Resolver) CreatePendingAppointmentsService() *appointments_services.CreatePendingAppointmentsService {
 	if r.createPendingAppointmentsService == nil {
 		r.createPendingAppointmentsService = &appointments_services.CreatePendingAppointmentsService{
-			IdentifierUtil:           r.IdentifierUtil,
-			OrmUtil:                  r.OrmUtil,
-			ScheduleIntervalDuration: r.ScheduleIntervalDuration,
+			IdentifierUtil:                 r.IdentifierUtil,
+			OrmUtil:                        r.OrmUtil,
+			GetTreatmentOccurrencesService: r.GetTreatmentOccurrencesService(),
+			AppointmentHorizonOccurrences:  r.AppointmentHorizonOccurrences,
 		}
 	}
 	return r.createPendingAppointmentsService
 }
 
+// CreateRoleService gets or sets the service with same name
+func (r *Resolver) CreateRoleService() *users_services.CreateRoleService {
+	if r.createRoleService == nil {
+		r.createRoleService = &users_services.CreateRoleService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.createRoleService
+}
+
+// CreateSessionService gets or sets the service with same name
+func (r *Resolver) CreateSessionService() *users_services.CreateSessionService {
+	if r.createSessionService == nil {
+		r.createSessionService = &users_services.CreateSessionService{
+			DigestUtil:                   r.DigestUtil,
+			IdentifierUtil:               r.IdentifierUtil,
+			OrmUtil:                      r.OrmUtil,
+			SerializingUtil:              r.SerializingUtil,
+			TokenUtil:                    r.TokenUtil,
+			RegisterLoginFailureService:  r.RegisterLoginFailureService(),
+			ValidateTwoFactorCodeService: r.ValidateTwoFactorCodeService(),
+			ExpireAuthTokenDuration:      r.ExpireAuthTokenDuration,
+			ExpireRefreshTokenDuration:   r.ExpireRefreshTokenDuration,
+			TwoFactorRequiredRoles:       r.TwoFactorRequiredRoles,
+		}
+	}
+	return r.createSessionService
+}
+
 // CreateTreatmentService gets or sets the service with same name
 func (r *Resolver) CreateTreatmentService() *treatments_services.CreateTreatmentService {
 	if r.createTreatmentService == nil {
 		r.createTreatmentService = &treatments_services.CreateTreatmentService{
-			IdentifierUtil:                 r.IdentifierUtil,
-			OrmUtil:                        r.OrmUtil,
-			CheckTreatmentCollisionService: r.CheckTreatmentCollisionService(),
+			IdentifierUtil:                    r.IdentifierUtil,
+			OrmUtil:                           r.OrmUtil,
+			CheckTreatmentAvailabilityService: r.CheckTreatmentAvailabilityService(),
+			CheckTreatmentCollisionService:    r.CheckTreatmentCollisionService(),
 		}
 	}
 	return r.createTreatmentService
@@ -224,12 +468,10 @@ func (r *Resolver) CreateTreatmentService() *treatments_services.CreateTreatment
 func (r *Resolver) CreateUserService() *users_services.CreateUserService {
 	if r.createUserService == nil {
 		r.createUserService = &users_services.CreateUserService{
-			IdentifierUtil:          r.IdentifierUtil,
-			MatchUtil:               r.MatchUtil,
-			OrmUtil:                 r.OrmUtil,
-			SerializingUtil:         r.SerializingUtil,
-			TokenUtil:               r.TokenUtil,
-			ExpireAuthTokenDuration: r.ExpireResetTokenDuration,
+			IdentifierUtil:        r.IdentifierUtil,
+			MatchUtil:             r.MatchUtil,
+			OrmUtil:               r.OrmUtil,
+			SendInvitationService: r.SendInvitationService(),
 		}
 	}
 	return r.createUserService
@@ -249,6 +491,70 @@ func (r *Resolver) CreateUserWithPasswordService() *users_services.CreateUserWit
 	return r.createUserWithPasswordService
 }
 
+// DeleteAbsenceService gets or sets the service with same name
+func (r *Resolver) DeleteAbsenceService() *appointments_services.DeleteAbsenceService {
+	if r.deleteAbsenceService == nil {
+		r.deleteAbsenceService = &appointments_services.DeleteAbsenceService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.deleteAbsenceService
+}
+
+// DeleteAvatarFileService gets or sets the service with same name
+func (r *Resolver) DeleteAvatarFileService() *files_services.DeleteAvatarFileService {
+	if r.deleteAvatarFileService == nil {
+		r.deleteAvatarFileService = &files_services.DeleteAvatarFileService{
+			FileStorageUtil: r.FileStorageUtil,
+			OrmUtil:         r.OrmUtil,
+		}
+	}
+	return r.deleteAvatarFileService
+}
+
+// DeleteCooldownsService gets or sets the service with same name
+func (r *Resolver) DeleteCooldownsService() *cooldowns_services.DeleteCooldownsService {
+	if r.deleteCooldownsService == nil {
+		r.deleteCooldownsService = &cooldowns_services.DeleteCooldownsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.deleteCooldownsService
+}
+
+// DeleteDataExportsService gets or sets the service with same name
+func (r *Resolver) DeleteDataExportsService() *users_services.DeleteDataExportsService {
+	if r.deleteDataExportsService == nil {
+		r.deleteDataExportsService = &users_services.DeleteDataExportsService{
+			ExportStorageUtil: r.ExportStorageUtil,
+			OrmUtil:           r.OrmUtil,
+		}
+	}
+	return r.deleteDataExportsService
+}
+
+// DeleteHolidayService gets or sets the service with same name
+func (r *Resolver) DeleteHolidayService() *appointments_services.DeleteHolidayService {
+	if r.deleteHolidayService == nil {
+		r.deleteHolidayService = &appointments_services.DeleteHolidayService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.deleteHolidayService
+}
+
+// DeleteMyAccountService gets or sets the service with same name
+func (r *Resolver) DeleteMyAccountService() *users_services.DeleteMyAccountService {
+	if r.deleteMyAccountService == nil {
+		r.deleteMyAccountService = &users_services.DeleteMyAccountService{
+			HashUtil:         r.HashUtil,
+			OrmUtil:          r.OrmUtil,
+			EraseUserService: r.EraseUserService(),
+		}
+	}
+	return r.deleteMyAccountService
+}
+
 // DeleteTreatmentService gets or sets the service with same name
 func (r *Resolver) DeleteTreatmentService() *treatments_services.DeleteTreatmentService {
 	if r.deleteTreatmentService == nil {
@@ -259,6 +565,18 @@ func (r *Resolver) DeleteTreatmentService() *treatments_services.DeleteTreatment
 	return r.deleteTreatmentService
 }
 
+// DisableTwoFactorService gets or sets the service with same name
+func (r *Resolver) DisableTwoFactorService() *users_services.DisableTwoFactorService {
+	if r.disableTwoFactorService == nil {
+		r.disableTwoFactorService = &users_services.DisableTwoFactorService{
+			OrmUtil:                      r.OrmUtil,
+			ValidateTwoFactorCodeService: r.ValidateTwoFactorCodeService(),
+			TwoFactorRequiredRoles:       r.TwoFactorRequiredRoles,
+		}
+	}
+	return r.disableTwoFactorService
+}
+
 // EditAppointmentByPatientService gets or sets the service with same name
 func (r *Resolver) EditAppointmentByPatientService() *appointments_services.EditAppointmentByPatientService {
 	if r.editAppointmentByPatientService == nil {
@@ -281,6 +599,43 @@ func (r *Resolver) EditAppointmentByPsychologistService() *appointments_services
 	return r.editAppointmentByPsychologistService
 }
 
+// ErasePatientService gets or sets the service with same name
+func (r *Resolver) ErasePatientService() *profiles_services.ErasePatientService {
+	if r.erasePatientService == nil {
+		r.erasePatientService = &profiles_services.ErasePatientService{
+			OrmUtil:                            r.OrmUtil,
+			DeleteAvatarFileService:            r.DeleteAvatarFileService(),
+			InterruptTreatmentByPatientService: r.InterruptTreatmentByPatientService(),
+		}
+	}
+	return r.erasePatientService
+}
+
+// ErasePsychologistService gets or sets the service with same name
+func (r *Resolver) ErasePsychologistService() *profiles_services.ErasePsychologistService {
+	if r.erasePsychologistService == nil {
+		r.erasePsychologistService = &profiles_services.ErasePsychologistService{
+			OrmUtil:                                 r.OrmUtil,
+			DeleteAvatarFileService:                 r.DeleteAvatarFileService(),
+			InterruptTreatmentByPsychologistService: r.InterruptTreatmentByPsychologistService(),
+		}
+	}
+	return r.erasePsychologistService
+}
+
+// EraseUserService gets or sets the service with same name
+func (r *Resolver) EraseUserService() *users_services.EraseUserService {
+	if r.eraseUserService == nil {
+		r.eraseUserService = &users_services.EraseUserService{
+			OrmUtil:                  r.OrmUtil,
+			DeleteDataExportsService: r.DeleteDataExportsService(),
+			ErasePatientService:      r.ErasePatientService(),
+			ErasePsychologistService: r.ErasePsychologistService(),
+		}
+	}
+	return r.eraseUserService
+}
+
 // FinalizeTreatmentService gets or sets the service with same name
 func (r *Resolver) FinalizeTreatmentService() *treatments_services.FinalizeTreatmentService {
 	if r.finalizeTreatmentService == nil {
@@ -292,6 +647,16 @@ func (r *Resolver) FinalizeTreatmentService() *treatments_services.FinalizeTreat
 	return r.finalizeTreatmentService
 }
 
+// GetAbsencesService gets or sets the service with same name
+func (r *Resolver) GetAbsencesService() *appointments_services.GetAbsencesService {
+	if r.getAbsencesService == nil {
+		r.getAbsencesService = &appointments_services.GetAbsencesService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getAbsencesService
+}
+
 // GetAgreementsByProfileIdService gets or sets the service with same name
 func (r *Resolver) GetAgreementsByProfileIdService() *agreements_services.GetAgreementsByProfileIdService {
 	if r.getAgreementsByProfileIdService == nil {
@@ -322,6 +687,27 @@ func (r *Resolver) GetAppointmentsOfPsychologistService() *appointments_services
 	return r.getAppointmentsOfPsychologistService
 }
 
+// GetAuditLogService gets or sets the service with same name
+func (r *Resolver) GetAuditLogService() *audits_services.GetAuditLogService {
+	if r.getAuditLogService == nil {
+		r.getAuditLogService = &audits_services.GetAuditLogService{
+			OrmUtil:             r.OrmUtil,
+			MaxAuditLogPageSize: r.MaxAuditLogPageSize,
+		}
+	}
+	return r.getAuditLogService
+}
+
+// GetAvailabilityWindowsService gets or sets the service with same name
+func (r *Resolver) GetAvailabilityWindowsService() *treatments_services.GetAvailabilityWindowsService {
+	if r.getAvailabilityWindowsService == nil {
+		r.getAvailabilityWindowsService = &treatments_services.GetAvailabilityWindowsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getAvailabilityWindowsService
+}
+
 // GetCharacteristicsByIDService gets or sets the service with same name
 func (r *Resolver) GetCharacteristicsByIDService() *characteristics_services.GetCharacteristicsByIDService {
 	if r.getCharacteristicsByIDService == nil {
@@ -352,6 +738,133 @@ func (r *Resolver) GetCooldownService() *cooldowns_services.GetCooldownService {
 	return r.getCooldownService
 }
 
+// GetCooldownsService gets or sets the service with same name
+func (r *Resolver) GetCooldownsService() *cooldowns_services.GetCooldownsService {
+	if r.getCooldownsService == nil {
+		r.getCooldownsService = &cooldowns_services.GetCooldownsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getCooldownsService
+}
+
+// GetDataExportFileService gets or sets the service with same name
+func (r *Resolver) GetDataExportFileService() *users_services.GetDataExportFileService {
+	if r.getDataExportFileService == nil {
+		r.getDataExportFileService = &users_services.GetDataExportFileService{
+			DigestUtil:        r.DigestUtil,
+			ExportStorageUtil: r.ExportStorageUtil,
+			OrmUtil:           r.OrmUtil,
+		}
+	}
+	return r.getDataExportFileService
+}
+
+// GetFreeSlotsService gets or sets the service with same name
+func (r *Resolver) GetFreeSlotsService() *treatments_services.GetFreeSlotsService {
+	if r.getFreeSlotsService == nil {
+		r.getFreeSlotsService = &treatments_services.GetFreeSlotsService{
+			OrmUtil:                  r.OrmUtil,
+			ScheduleIntervalDuration: r.ScheduleIntervalDuration,
+			SlotStepDuration:         r.FreeSlotStepDuration,
+		}
+	}
+	return r.getFreeSlotsService
+}
+
+// GetHolidaysService gets or sets the service with same name
+func (r *Resolver) GetHolidaysService() *appointments_services.GetHolidaysService {
+	if r.getHolidaysService == nil {
+		r.getHolidaysService = &appointments_services.GetHolidaysService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getHolidaysService
+}
+
+// GetLockedUsersService gets or sets the service with same name
+func (r *Resolver) GetLockedUsersService() *users_services.GetLockedUsersService {
+	if r.getLockedUsersService == nil {
+		r.getLockedUsersService = &users_services.GetLockedUsersService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getLockedUsersService
+}
+
+// GetPendingInvitationsService gets or sets the service with same name
+func (r *Resolver) GetPendingInvitationsService() *users_services.GetPendingInvitationsService {
+	if r.getPendingInvitationsService == nil {
+		r.getPendingInvitationsService = &users_services.GetPendingInvitationsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getPendingInvitationsService
+}
+
+// GetPermissionsByRoleService gets or sets the service with same name
+func (r *Resolver) GetPermissionsByRoleService() *users_services.GetPermissionsByRoleService {
+	if r.getPermissionsByRoleService == nil {
+		r.getPermissionsByRoleService = &users_services.GetPermissionsByRoleService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getPermissionsByRoleService
+}
+
+// GetPermissionsService gets or sets the service with same name
+func (r *Resolver) GetPermissionsService() *users_services.GetPermissionsService {
+	if r.getPermissionsService == nil {
+		r.getPermissionsService = &users_services.GetPermissionsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getPermissionsService
+}
+
+// GetPersonalDataArchiveService gets or sets the service with same name
+func (r *Resolver) GetPersonalDataArchiveService() *users_services.GetPersonalDataArchiveService {
+	if r.getPersonalDataArchiveService == nil {
+		r.getPersonalDataArchiveService = &users_services.GetPersonalDataArchiveService{
+			ArchiveUtil:     r.ArchiveUtil,
+			FileStorageUtil: r.FileStorageUtil,
+			OrmUtil:         r.OrmUtil,
+			SerializingUtil: r.SerializingUtil,
+		}
+	}
+	return r.getPersonalDataArchiveService
+}
+
+// GetPendingVerificationsService gets or sets the service with same name
+func (r *Resolver) GetPendingVerificationsService() *profiles_services.GetPendingVerificationsService {
+	if r.getPendingVerificationsService == nil {
+		r.getPendingVerificationsService = &profiles_services.GetPendingVerificationsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getPendingVerificationsService
+}
+
+// GetRolesService gets or sets the service with same name
+func (r *Resolver) GetRolesService() *users_services.GetRolesService {
+	if r.getRolesService == nil {
+		r.getRolesService = &users_services.GetRolesService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getRolesService
+}
+
+// GetSessionsByUserIDService gets or sets the service with same name
+func (r *Resolver) GetSessionsByUserIDService() *users_services.GetSessionsByUserIDService {
+	if r.getSessionsByUserIDService == nil {
+		r.getSessionsByUserIDService = &users_services.GetSessionsByUserIDService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getSessionsByUserIDService
+}
+
 // GetTranslationsService gets or sets the service with same name
 func (r *Resolver) GetTranslationsService() *translations_services.GetTranslationsService {
 	if r.getTranslationsService == nil {
@@ -494,6 +1007,16 @@ func (r *Resolver) GetTopAffinitiesForPatientService() *characteristics_services
 	return r.getTopAffinitiesForPatientService
 }
 
+// GetTreatmentOccurrencesService gets or sets the service with same name
+func (r *Resolver) GetTreatmentOccurrencesService() *treatments_services.GetTreatmentOccurrencesService {
+	if r.getTreatmentOccurrencesService == nil {
+		r.getTreatmentOccurrencesService = &treatments_services.GetTreatmentOccurrencesService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getTreatmentOccurrencesService
+}
+
 // GetTreatmentPriceRangeByNameService gets or sets the service with same name
 func (r *Resolver) GetTreatmentPriceRangeByNameService() *treatments_services.GetTreatmentPriceRangeByNameService {
 	if r.getTreatmentPriceRangeByNameService == nil {
@@ -534,6 +1057,65 @@ func (r *Resolver) GetUserByIDService() *users_services.GetUserByIDService {
 	return r.getUserByIDService
 }
 
+// GetUsersService gets or sets the service with same name
+func (r *Resolver) GetUsersService() *users_services.GetUsersService {
+	if r.getUsersService == nil {
+		r.getUsersService = &users_services.GetUsersService{
+			OrmUtil:          r.OrmUtil,
+			SerializingUtil:  r.SerializingUtil,
+			MaxUsersPageSize: r.MaxUsersPageSize,
+		}
+	}
+	return r.getUsersService
+}
+
+// GetVerifiedPsychologistService gets or sets the service with same name
+func (r *Resolver) GetVerifiedPsychologistService() *profiles_services.GetVerifiedPsychologistService {
+	if r.getVerifiedPsychologistService == nil {
+		r.getVerifiedPsychologistService = &profiles_services.GetVerifiedPsychologistService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.getVerifiedPsychologistService
+}
+
+// HashStoredTokensService gets or sets the service with same name
+func (r *Resolver) HashStoredTokensService() *users_services.HashStoredTokensService {
+	if r.hashStoredTokensService == nil {
+		r.hashStoredTokensService = &users_services.HashStoredTokensService{
+			DigestUtil: r.DigestUtil,
+			OrmUtil:    r.OrmUtil,
+		}
+	}
+	return r.hashStoredTokensService
+}
+
+// ImpersonateUserService gets or sets the service with same name
+func (r *Resolver) ImpersonateUserService() *users_services.ImpersonateUserService {
+	if r.impersonateUserService == nil {
+		r.impersonateUserService = &users_services.ImpersonateUserService{
+			IdentifierUtil:                   r.IdentifierUtil,
+			OrmUtil:                          r.OrmUtil,
+			SerializingUtil:                  r.SerializingUtil,
+			TokenUtil:                        r.TokenUtil,
+			ExpireImpersonationTokenDuration: r.ExpireImpersonationTokenDuration,
+		}
+	}
+	return r.impersonateUserService
+}
+
+// ImportHolidaysService gets or sets the service with same name
+func (r *Resolver) ImportHolidaysService() *appointments_services.ImportHolidaysService {
+	if r.importHolidaysService == nil {
+		r.importHolidaysService = &appointments_services.ImportHolidaysService{
+			CalendarUtil:   r.CalendarUtil,
+			IdentifierUtil: r.IdentifierUtil,
+			OrmUtil:        r.OrmUtil,
+		}
+	}
+	return r.importHolidaysService
+}
+
 // InterruptTreatmentByPatientService gets or sets the service with same name
 func (r *Resolver) InterruptTreatmentByPatientService() *treatments_services.InterruptTreatmentByPatientService {
 	if r.interruptTreatmentByPatientService == nil {
@@ -557,6 +1139,37 @@ func (r *Resolver) InterruptTreatmentByPsychologistService() *treatments_service
 	return r.interruptTreatmentByPsychologistService
 }
 
+// InvitePsychologistsFromFileService gets or sets the service with same name
+func (r *Resolver) InvitePsychologistsFromFileService() *users_services.InvitePsychologistsFromFileService {
+	if r.invitePsychologistsFromFileService == nil {
+		r.invitePsychologistsFromFileService = &users_services.InvitePsychologistsFromFileService{
+			MatchUtil:                 r.MatchUtil,
+			OrmUtil:                   r.OrmUtil,
+			TableUtil:                 r.TableUtil,
+			CreateUserService:         r.CreateUserService(),
+			UpsertPsychologistService: r.UpsertPsychologistService(),
+		}
+	}
+	return r.invitePsychologistsFromFileService
+}
+
+// ProcessPendingDataExportsService gets or sets the service with same name
+func (r *Resolver) ProcessPendingDataExportsService() *users_services.ProcessPendingDataExportsService {
+	if r.processPendingDataExportsService == nil {
+		r.processPendingDataExportsService = &users_services.ProcessPendingDataExportsService{
+			DigestUtil:                    r.DigestUtil,
+			ExportStorageUtil:             r.ExportStorageUtil,
+			IdentifierUtil:                r.IdentifierUtil,
+			OrmUtil:                       r.OrmUtil,
+			TokenUtil:                     r.TokenUtil,
+			ExpireDataExportDuration:      r.ExpireDataExportDuration,
+			DeleteDataExportsService:      r.DeleteDataExportsService(),
+			GetPersonalDataArchiveService: r.GetPersonalDataArchiveService(),
+		}
+	}
+	return r.processPendingDataExportsService
+}
+
 // ProcessPendingMailsService gets or sets the service with same name
 func (r *Resolver) ProcessPendingMailsService() *mails_services.ProcessPendingMailsService {
 	if r.processPendingMailsService == nil {
@@ -578,18 +1191,127 @@ func (r *Resolver) ReadFileService() *files_services.ReadFileService {
 	return r.readFileService
 }
 
+// RecordAuditEntryService gets or sets the service with same name
+func (r *Resolver) RecordAuditEntryService() *audits_services.RecordAuditEntryService {
+	if r.recordAuditEntryService == nil {
+		r.recordAuditEntryService = &audits_services.RecordAuditEntryService{
+			IdentifierUtil:  r.IdentifierUtil,
+			OrmUtil:         r.OrmUtil,
+			SerializingUtil: r.SerializingUtil,
+		}
+	}
+	return r.recordAuditEntryService
+}
+
+// RefreshAuthenticationService gets or sets the service with same name
+func (r *Resolver) RefreshAuthenticationService() *users_services.RefreshAuthenticationService {
+	if r.refreshAuthenticationService == nil {
+		r.refreshAuthenticationService = &users_services.RefreshAuthenticationService{
+			DigestUtil:                 r.DigestUtil,
+			OrmUtil:                    r.OrmUtil,
+			SerializingUtil:            r.SerializingUtil,
+			TokenUtil:                  r.TokenUtil,
+			ExpireAuthTokenDuration:    r.ExpireAuthTokenDuration,
+			ExpireRefreshTokenDuration: r.ExpireRefreshTokenDuration,
+			TwoFactorRequiredRoles:     r.TwoFactorRequiredRoles,
+		}
+	}
+	return r.refreshAuthenticationService
+}
+
+// RegisterLoginFailureService gets or sets the service with same name
+func (r *Resolver) RegisterLoginFailureService() *users_services.RegisterLoginFailureService {
+	if r.registerLoginFailureService == nil {
+		r.registerLoginFailureService = &users_services.RegisterLoginFailureService{
+			IdentifierUtil:               r.IdentifierUtil,
+			OrmUtil:                      r.OrmUtil,
+			DeleteCooldownsService:       r.DeleteCooldownsService(),
+			GetCooldownService:           r.GetCooldownService(),
+			GetCooldownsService:          r.GetCooldownsService(),
+			SaveCooldownService:          r.SaveCooldownService(),
+			MaxLoginFailuresPerUser:      r.MaxLoginFailuresPerUser,
+			MaxLoginFailuresPerIPAddress: r.MaxLoginFailuresPerIPAddress,
+		}
+	}
+	return r.registerLoginFailureService
+}
+
+// RequestDataExportService gets or sets the service with same name
+func (r *Resolver) RequestDataExportService() *users_services.RequestDataExportService {
+	if r.requestDataExportService == nil {
+		r.requestDataExportService = &users_services.RequestDataExportService{
+			IdentifierUtil: r.IdentifierUtil,
+			OrmUtil:        r.OrmUtil,
+		}
+	}
+	return r.requestDataExportService
+}
+
+// ResendInvitationService gets or sets the service with same name
+func (r *Resolver) ResendInvitationService() *users_services.ResendInvitationService {
+	if r.resendInvitationService == nil {
+		r.resendInvitationService = &users_services.ResendInvitationService{
+			OrmUtil:               r.OrmUtil,
+			SendInvitationService: r.SendInvitationService(),
+		}
+	}
+	return r.resendInvitationService
+}
+
 // ResetPasswordService gets or sets the service with same name
 func (r *Resolver) ResetPasswordService() *users_services.ResetPasswordService {
 	if r.resetPasswordService == nil {
 		r.resetPasswordService = &users_services.ResetPasswordService{
-			HashUtil:  r.HashUtil,
-			MatchUtil: r.MatchUtil,
-			OrmUtil:   r.OrmUtil,
+			DigestUtil: r.DigestUtil,
+			HashUtil:   r.HashUtil,
+			MatchUtil:  r.MatchUtil,
+			OrmUtil:    r.OrmUtil,
 		}
 	}
 	return r.resetPasswordService
 }
 
+// ReviewPsychologistVerificationService gets or sets the service with same name
+func (r *Resolver) ReviewPsychologistVerificationService() *profiles_services.ReviewPsychologistVerificationService {
+	if r.reviewPsychologistVerificationService == nil {
+		r.reviewPsychologistVerificationService = &profiles_services.ReviewPsychologistVerificationService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.reviewPsychologistVerificationService
+}
+
+// RevokeAllOtherSessionsService gets or sets the service with same name
+func (r *Resolver) RevokeAllOtherSessionsService() *users_services.RevokeAllOtherSessionsService {
+	if r.revokeAllOtherSessionsService == nil {
+		r.revokeAllOtherSessionsService = &users_services.RevokeAllOtherSessionsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.revokeAllOtherSessionsService
+}
+
+// RevokeInvitationService gets or sets the service with same name
+func (r *Resolver) RevokeInvitationService() *users_services.RevokeInvitationService {
+	if r.revokeInvitationService == nil {
+		r.revokeInvitationService = &users_services.RevokeInvitationService{
+			OrmUtil:          r.OrmUtil,
+			EraseUserService: r.EraseUserService(),
+		}
+	}
+	return r.revokeInvitationService
+}
+
+// RevokeSessionService gets or sets the service with same name
+func (r *Resolver) RevokeSessionService() *users_services.RevokeSessionService {
+	if r.revokeSessionService == nil {
+		r.revokeSessionService = &users_services.RevokeSessionService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.revokeSessionService
+}
+
 // SaveCooldownService gets or sets the service with same name
 func (r *Resolver) SaveCooldownService() *cooldowns_services.SaveCooldownService {
 	if r.saveCooldownService == nil {
@@ -598,11 +1320,48 @@ func (r *Resolver) SaveCooldownService() *cooldowns_services.SaveCooldownService
 			OrmUtil:                            r.OrmUtil,
 			InterruptTreatmentCooldownDuration: r.InterruptTreatmentCooldownDuration,
 			TopAffinitiesCooldownDuration:      r.TopAffinitiesCooldownDuration,
+			LoginFailedCooldownDuration:        r.LoginFailedCooldownDuration,
+			LoginLockedCooldownDuration:        r.LoginLockedCooldownDuration,
 		}
 	}
 	return r.saveCooldownService
 }
 
+// SeedPermissionsService gets or sets the service with same name
+func (r *Resolver) SeedPermissionsService() *users_services.SeedPermissionsService {
+	if r.seedPermissionsService == nil {
+		r.seedPermissionsService = &users_services.SeedPermissionsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.seedPermissionsService
+}
+
+// SendInvitationService gets or sets the service with same name
+func (r *Resolver) SendInvitationService() *users_services.SendInvitationService {
+	if r.sendInvitationService == nil {
+		r.sendInvitationService = &users_services.SendInvitationService{
+			DigestUtil:               r.DigestUtil,
+			IdentifierUtil:           r.IdentifierUtil,
+			OrmUtil:                  r.OrmUtil,
+			TokenUtil:                r.TokenUtil,
+			ExpireInvitationDuration: r.ExpireInvitationDuration,
+		}
+	}
+	return r.sendInvitationService
+}
+
+// SetAvailabilityWindowsService gets or sets the service with same name
+func (r *Resolver) SetAvailabilityWindowsService() *treatments_services.SetAvailabilityWindowsService {
+	if r.setAvailabilityWindowsService == nil {
+		r.setAvailabilityWindowsService = &treatments_services.SetAvailabilityWindowsService{
+			IdentifierUtil: r.IdentifierUtil,
+			OrmUtil:        r.OrmUtil,
+		}
+	}
+	return r.setAvailabilityWindowsService
+}
+
 // SetCharacteristicChoicesService gets or sets the service with same name
 func (r *Resolver) SetCharacteristicChoicesService() *characteristics_services.SetCharacteristicChoicesService {
 	if r.setCharacteristicChoicesService == nil {
@@ -625,6 +1384,16 @@ func (r *Resolver) SetCharacteristicsService() *characteristics_services.SetChar
 	return r.setCharacteristicsService
 }
 
+// SetRolePermissionsService gets or sets the service with same name
+func (r *Resolver) SetRolePermissionsService() *users_services.SetRolePermissionsService {
+	if r.setRolePermissionsService == nil {
+		r.setRolePermissionsService = &users_services.SetRolePermissionsService{
+			OrmUtil: r.OrmUtil,
+		}
+	}
+	return r.setRolePermissionsService
+}
+
 // SetTranslationsService gets or sets the service with same name
 func (r *Resolver) SetTranslationsService() *translations_services.SetTranslationsService {
 	if r.setTranslationsService == nil {
@@ -669,13 +1438,52 @@ func (r *Resolver) SetTreatmentPriceRangesService() *treatments_services.SetTrea
 	return r.setTreatmentPriceRangesService
 }
 
+// SetupTwoFactorService gets or sets the service with same name
+func (r *Resolver) SetupTwoFactorService() *users_services.SetupTwoFactorService {
+	if r.setupTwoFactorService == nil {
+		r.setupTwoFactorService = &users_services.SetupTwoFactorService{
+			DigestUtil:            r.DigestUtil,
+			IdentifierUtil:        r.IdentifierUtil,
+			OrmUtil:               r.OrmUtil,
+			OtpUtil:               r.OtpUtil,
+			RecoveryCodesQuantity: r.RecoveryCodesQuantity,
+		}
+	}
+	return r.setupTwoFactorService
+}
+
+// StartOidcAuthenticationService gets or sets the service with same name
+func (r *Resolver) StartOidcAuthenticationService() *users_services.StartOidcAuthenticationService {
+	if r.startOidcAuthenticationService == nil {
+		r.startOidcAuthenticationService = &users_services.StartOidcAuthenticationService{
+			DigestUtil:                      r.DigestUtil,
+			OidcUtil:                        r.OidcUtil,
+			OrmUtil:                         r.OrmUtil,
+			ExpireOidcAuthorizationDuration: r.ExpireOidcAuthorizationDuration,
+		}
+	}
+	return r.startOidcAuthenticationService
+}
+
+// UnlockUserService gets or sets the service with same name
+func (r *Resolver) UnlockUserService() *users_services.UnlockUserService {
+	if r.unlockUserService == nil {
+		r.unlockUserService = &users_services.UnlockUserService{
+			OrmUtil:                r.OrmUtil,
+			DeleteCooldownsService: r.DeleteCooldownsService(),
+		}
+	}
+	return r.unlockUserService
+}
+
 // UpdateTreatmentService gets or sets the service with same name
 func (r *Resolver) UpdateTreatmentService() *treatments_services.UpdateTreatmentService {
 	if r.updateTreatmentService == nil {
 		r.updateTreatmentService = &treatments_services.UpdateTreatmentService{
-			IdentifierUtil:                 r.IdentifierUtil,
-			OrmUtil:                        r.OrmUtil,
-			CheckTreatmentCollisionService: r.CheckTreatmentCollisionService(),
+			IdentifierUtil:                    r.IdentifierUtil,
+			OrmUtil:                           r.OrmUtil,
+			CheckTreatmentAvailabilityService: r.CheckTreatmentAvailabilityService(),
+			CheckTreatmentCollisionService:    r.CheckTreatmentCollisionService(),
 		}
 	}
 	return r.updateTreatmentService
@@ -728,9 +1536,10 @@ func (r *Resolver) UpsertPatientService() *profiles_services.UpsertPatientServic
 func (r *Resolver) UpsertPsychologistService() *profiles_services.UpsertPsychologistService {
 	if r.upsertPsychologistService == nil {
 		r.upsertPsychologistService = &profiles_services.UpsertPsychologistService{
-			IdentifierUtil:          r.IdentifierUtil,
-			OrmUtil:                 r.OrmUtil,
-			UploadAvatarFileService: r.UploadAvatarFileService(),
+			IdentifierUtil:                  r.IdentifierUtil,
+			OrmUtil:                         r.OrmUtil,
+			ChangeTreatmentsTimeZoneService: r.ChangeTreatmentsTimeZoneService(),
+			UploadAvatarFileService:         r.UploadAvatarFileService(),
 		}
 	}
 	return r.upsertPsychologistService
@@ -747,13 +1556,25 @@ func (r *Resolver) UpsertTermService() *agreements_services.UpsertTermService {
 	return r.upsertTermService
 }
 
+// ValidateTwoFactorCodeService gets or sets the service with same name
+func (r *Resolver) ValidateTwoFactorCodeService() *users_services.ValidateTwoFactorCodeService {
+	if r.validateTwoFactorCodeService == nil {
+		r.validateTwoFactorCodeService = &users_services.ValidateTwoFactorCodeService{
+			DigestUtil: r.DigestUtil,
+			OrmUtil:    r.OrmUtil,
+			OtpUtil:    r.OtpUtil,
+		}
+	}
+	return r.validateTwoFactorCodeService
+}
+
 // ValidateUserTokenService gets or sets the service with same name
 func (r *Resolver) ValidateUserTokenService() *users_services.ValidateUserTokenService {
 	if r.validateUserTokenService == nil {
 		r.validateUserTokenService = &users_services.ValidateUserTokenService{
-			OrmUtil:                 r.OrmUtil,
-			SerializingUtil:         r.SerializingUtil,
-			ExpireAuthTokenDuration: r.ExpireAuthTokenDuration,
+			OrmUtil:         r.OrmUtil,
+			SerializingUtil: r.SerializingUtil,
+			TokenUtil:       r.TokenUtil,
 		}
 	}
 	return r.validateUserTokenService
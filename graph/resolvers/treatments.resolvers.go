@@ -89,6 +89,19 @@ func (r *mutationResolver) FinalizeTreatment(ctx context.Context, id string) (*b
 	return nil, serviceErr
 }
 
+func (r *mutationResolver) SetMyAvailability(ctx context.Context, input []*treatments_models.SetAvailabilityWindowInput) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	servicePsy, servicePsyErr := r.GetPsychologistByUserIDService().Execute(userID)
+	if servicePsyErr != nil {
+		return nil, servicePsyErr
+	}
+
+	serviceErr := r.SetAvailabilityWindowsService().Execute(servicePsy.ID, input)
+
+	return nil, serviceErr
+}
+
 func (r *mutationResolver) SetTreatmentPriceRanges(ctx context.Context, input []*treatments_models.TreatmentPriceRange) (*bool, error) {
 	serviceErr := r.SetTreatmentPriceRangesService().Execute(input)
 
@@ -124,6 +137,28 @@ func (r *psychologistTreatmentResolver) Patient(ctx context.Context, obj *treatm
 	return r.GetPatientService().Execute(obj.PatientID)
 }
 
+func (r *queryResolver) MyAvailability(ctx context.Context) ([]*treatments_models.AvailabilityWindow, error) {
+	userID := ctx.Value("userID").(string)
+
+	servicePsy, servicePsyErr := r.GetPsychologistByUserIDService().Execute(userID)
+	if servicePsyErr != nil {
+		return nil, servicePsyErr
+	}
+
+	return r.GetAvailabilityWindowsService().Execute(servicePsy.ID)
+}
+
+func (r *queryResolver) MyFreeSlots(ctx context.Context, frequency int64, duration int64) ([]*treatments_models.FreeSlot, error) {
+	userID := ctx.Value("userID").(string)
+
+	servicePsy, servicePsyErr := r.GetPsychologistByUserIDService().Execute(userID)
+	if servicePsyErr != nil {
+		return nil, servicePsyErr
+	}
+
+	return r.GetFreeSlotsService().Execute(servicePsy.ID, frequency, duration)
+}
+
 func (r *queryResolver) TreatmentPriceRanges(ctx context.Context) ([]*treatments_models.TreatmentPriceRange, error) {
 	return r.GetTreatmentPriceRangesService().Execute()
 }
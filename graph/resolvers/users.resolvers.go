@@ -6,6 +6,7 @@ package resolvers
 import (
 	"context"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/guicostaarantes/psi-server/graph/generated"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 )
@@ -20,6 +21,37 @@ func (r *mutationResolver) AskResetPassword(ctx context.Context, email string) (
 	return nil, nil
 }
 
+func (r *mutationResolver) ChangeMyEmail(ctx context.Context, input users_models.ChangeMyEmailInput) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	serviceErr := r.ChangeMyEmailService().Execute(userID, &input)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ChangeMyPassword(ctx context.Context, input users_models.ChangeMyPasswordInput) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+	sessionID := ctx.Value("sessionID").(string)
+
+	serviceErr := r.ChangeMyPasswordService().Execute(userID, sessionID, &input)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ConfirmEmailChange(ctx context.Context, token string) (*bool, error) {
+	serviceErr := r.ConfirmEmailChangeService().Execute(token)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ConfirmTwoFactor(ctx context.Context, code string) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	serviceErr := r.ConfirmTwoFactorService().Execute(userID, code)
+
+	return nil, serviceErr
+}
+
 func (r *mutationResolver) CreatePatientUser(ctx context.Context, input users_models.CreateUserInput) (*bool, error) {
 	serviceErr := r.CreateUserService().Execute(&users_models.CreateUserInput{
 		Email: input.Email,
@@ -34,9 +66,12 @@ func (r *mutationResolver) CreatePatientUser(ctx context.Context, input users_mo
 }
 
 func (r *mutationResolver) CreatePsychologistUser(ctx context.Context, input users_models.CreateUserInput) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
 	serviceErr := r.CreateUserService().Execute(&users_models.CreateUserInput{
-		Email: input.Email,
-		Role:  "PSYCHOLOGIST",
+		Email:     input.Email,
+		Role:      "PSYCHOLOGIST",
+		InvitedBy: userID,
 	}, "INVITE_PSYCHOLOGIST")
 
 	if serviceErr != nil {
@@ -46,6 +81,12 @@ func (r *mutationResolver) CreatePsychologistUser(ctx context.Context, input use
 	return nil, nil
 }
 
+func (r *mutationResolver) CreateRole(ctx context.Context, name users_models.Role) (*bool, error) {
+	serviceErr := r.CreateRoleService().Execute(name)
+
+	return nil, serviceErr
+}
+
 func (r *mutationResolver) CreateUserWithPassword(ctx context.Context, input users_models.CreateUserWithPasswordInput) (*bool, error) {
 	serviceErr := r.CreateUserWithPasswordService().Execute(&users_models.CreateUserWithPasswordInput{
 		Email:    input.Email,
@@ -60,6 +101,71 @@ func (r *mutationResolver) CreateUserWithPassword(ctx context.Context, input use
 	return nil, nil
 }
 
+func (r *mutationResolver) DeleteMyAccount(ctx context.Context, currentPassword string) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	serviceErr := r.DeleteMyAccountService().Execute(userID, currentPassword)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) DisableTwoFactor(ctx context.Context, code string) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	serviceErr := r.DisableTwoFactorService().Execute(userID, code)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) EraseUser(ctx context.Context, id string) (*bool, error) {
+	serviceErr := r.EraseUserService().Execute(id)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ExportMyData(ctx context.Context) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	serviceErr := r.RequestDataExportService().Execute(userID)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ImpersonateUser(ctx context.Context, id string, reason string) (*users_models.Authentication, error) {
+	impersonatorID := ctx.Value("userID").(string)
+	impersonatorSessionID := ctx.Value("sessionID").(string)
+	return r.ImpersonateUserService().Execute(impersonatorID, impersonatorSessionID, id, reason)
+}
+
+func (r *mutationResolver) InvitePsychologistsFromFile(ctx context.Context, file graphql.Upload) ([]*users_models.InvitationRowResult, error) {
+	userID := ctx.Value("userID").(string)
+	return r.InvitePsychologistsFromFileService().Execute(userID, file)
+}
+
+func (r *mutationResolver) Logout(ctx context.Context) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+	sessionID := ctx.Value("sessionID").(string)
+
+	serviceErr := r.RevokeSessionService().Execute(sessionID, userID)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ProcessPendingDataExports(ctx context.Context) (*bool, error) {
+	serviceErr := r.ProcessPendingDataExportsService().Execute()
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) ResendInvitation(ctx context.Context, id string) (*bool, error) {
+	serviceErr := r.ResendInvitationService().Execute(id)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	return nil, nil
+}
+
 func (r *mutationResolver) ResetPassword(ctx context.Context, input users_models.ResetPasswordInput) (*bool, error) {
 	serviceErr := r.ResetPasswordService().Execute(&users_models.ResetPasswordInput{
 		Token:    input.Token,
@@ -73,6 +179,50 @@ func (r *mutationResolver) ResetPassword(ctx context.Context, input users_models
 	return nil, nil
 }
 
+func (r *mutationResolver) RevokeAllOtherSessions(ctx context.Context) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+	sessionID := ctx.Value("sessionID").(string)
+
+	serviceErr := r.RevokeAllOtherSessionsService().Execute(userID, sessionID)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) RevokeSession(ctx context.Context, id string) (*bool, error) {
+	userID := ctx.Value("userID").(string)
+
+	serviceErr := r.RevokeSessionService().Execute(id, userID)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) RevokeInvitation(ctx context.Context, id string) (*bool, error) {
+	serviceErr := r.RevokeInvitationService().Execute(id)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	return nil, nil
+}
+
+func (r *mutationResolver) SetRolePermissions(ctx context.Context, role users_models.Role, permissions []string) (*bool, error) {
+	serviceErr := r.SetRolePermissionsService().Execute(role, permissions)
+
+	return nil, serviceErr
+}
+
+func (r *mutationResolver) SetupTwoFactor(ctx context.Context) (*users_models.TwoFactorSetup, error) {
+	userID := ctx.Value("userID").(string)
+
+	return r.SetupTwoFactorService().Execute(userID)
+}
+
+func (r *mutationResolver) UnlockUser(ctx context.Context, id string) (*bool, error) {
+	serviceErr := r.UnlockUserService().Execute(id)
+
+	return nil, serviceErr
+}
+
 func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input users_models.UpdateUserInput) (*bool, error) {
 	serviceErr := r.UpdateUserService().Execute(id, &input)
 
@@ -85,29 +235,91 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input user
 
 func (r *queryResolver) AuthenticateUser(ctx context.Context, input users_models.AuthenticateUserInput) (*users_models.Authentication, error) {
 	return r.AuthenticateUserService().Execute(&users_models.AuthenticateUserInput{
-		Email:    input.Email,
-		Password: input.Password,
+		Email:     input.Email,
+		Password:  input.Password,
+		OtpCode:   input.OtpCode,
+		Device:    input.Device,
+		IPAddress: ctx.Value("ipAddress").(string),
+		UserAgent: ctx.Value("userAgent").(string),
 	})
 }
 
+func (r *queryResolver) AuthenticateWithOidc(ctx context.Context, input users_models.AuthenticateWithOidcInput) (*users_models.Authentication, error) {
+	return r.AuthenticateWithOidcService().Execute(&users_models.AuthenticateWithOidcInput{
+		State:     input.State,
+		Code:      input.Code,
+		Binding:   input.Binding,
+		OtpCode:   input.OtpCode,
+		Device:    input.Device,
+		IPAddress: ctx.Value("ipAddress").(string),
+		UserAgent: ctx.Value("userAgent").(string),
+	})
+}
+
+func (r *queryResolver) LockedUsers(ctx context.Context) ([]*users_models.LockedUser, error) {
+	return r.GetLockedUsersService().Execute()
+}
+
+func (r *queryResolver) MySessions(ctx context.Context) ([]*users_models.Authentication, error) {
+	userID := ctx.Value("userID").(string)
+	return r.GetSessionsByUserIDService().Execute(userID)
+}
+
 func (r *queryResolver) MyUser(ctx context.Context) (*users_models.User, error) {
 	userID := ctx.Value("userID").(string)
 	return r.GetUserByIDService().Execute(userID)
 }
 
+func (r *queryResolver) OidcAuthorizationURL(ctx context.Context, binding string) (string, error) {
+	return r.StartOidcAuthenticationService().Execute(binding)
+}
+
+func (r *queryResolver) PendingInvitations(ctx context.Context) ([]*users_models.Invitation, error) {
+	return r.GetPendingInvitationsService().Execute()
+}
+
+func (r *queryResolver) Permissions(ctx context.Context) ([]string, error) {
+	return r.GetPermissionsService().Execute()
+}
+
+func (r *queryResolver) RefreshAuthentication(ctx context.Context, refreshToken string) (*users_models.Authentication, error) {
+	return r.RefreshAuthenticationService().Execute(refreshToken)
+}
+
+func (r *queryResolver) Roles(ctx context.Context) ([]users_models.Role, error) {
+	return r.GetRolesService().Execute()
+}
+
+func (r *queryResolver) RolePermissions(ctx context.Context, role users_models.Role) ([]string, error) {
+	return r.GetPermissionsByRoleService().Execute(role)
+}
+
 func (r *queryResolver) User(ctx context.Context, id string) (*users_models.User, error) {
 	return r.GetUserByIDService().Execute(id)
 }
 
+func (r *queryResolver) Users(ctx context.Context, filter *users_models.UsersFilterInput, search *string, sort *users_models.UserSort, first int64, after *string) (*users_models.UserPage, error) {
+	return r.GetUsersService().Execute(filter, search, sort, first, after)
+}
+
 func (r *queryResolver) UsersByRole(ctx context.Context, role users_models.Role) ([]*users_models.User, error) {
 	return r.GetUsersByRoleService().Execute(string(role))
 }
 
+func (r *sessionResolver) Current(ctx context.Context, obj *users_models.Authentication) (bool, error) {
+	sessionID := ctx.Value("sessionID").(string)
+	return obj.ID == sessionID, nil
+}
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// Session returns generated.SessionResolver implementation.
+func (r *Resolver) Session() generated.SessionResolver { return &sessionResolver{r} }
+
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type sessionResolver struct{ *Resolver }
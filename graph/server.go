@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
@@ -17,12 +20,27 @@ import (
 	"github.com/guicostaarantes/psi-server/graph/files"
 	"github.com/guicostaarantes/psi-server/graph/generated"
 	"github.com/guicostaarantes/psi-server/graph/resolvers"
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 // CreateServer will take the resolver object with dependencies and return a Mux router for the GraphQL application
 func CreateServer(res *resolvers.Resolver) *chi.Mux {
 
+	hashErr := res.HashStoredTokensService().Execute()
+	if hashErr != nil {
+		panic(hashErr)
+	}
+
+	seedErr := res.SeedPermissionsService().Execute()
+	if seedErr != nil {
+		panic(seedErr)
+	}
+
 	bootstrapUser := os.Getenv("PSI_BOOTSTRAP_USER")
 	bootstrap := strings.Split(bootstrapUser, "|")
 
@@ -39,25 +57,21 @@ func CreateServer(res *resolvers.Resolver) *chi.Mux {
 
 	fileHandler := files.FileHandler{Resolvers: res}
 
-	c.Directives.HasRole = func(ctx context.Context, obj interface{}, next graphql.Resolver, role []users_models.Role) (interface{}, error) {
+	c.Directives.HasPermission = func(ctx context.Context, obj interface{}, next graphql.Resolver, permission string) (interface{}, error) {
 		userID := ctx.Value("userID").(string)
+		twoFactorPending := ctx.Value("twoFactorPending").(bool)
 
-		if userID == "" {
+		if userID == "" || (twoFactorPending && permission != users_models.SetupTwoFactorPermission) {
 			return nil, errors.New("forbidden")
 		}
 
-		user, userErr := res.GetUserByIDService().Execute(userID)
-		if userErr != nil {
-			return nil, errors.New("forbidden")
-		}
+		permissions := ctx.Value("permissions").(*requestPermissions).get(res, userID)
 
-		for _, v := range role {
-			if v == user.Role {
-				return next(ctx)
-			}
+		if !permissions[permission] {
+			return nil, errors.New("forbidden")
 		}
 
-		return nil, errors.New("forbidden")
+		return next(ctx)
 	}
 
 	router := chi.NewRouter()
@@ -73,30 +87,140 @@ func CreateServer(res *resolvers.Resolver) *chi.Mux {
 
 	router.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ipAddress := clientIPAddress(r, res.TrustedProxies)
+
+			ctx := context.WithValue(r.Context(), "ipAddress", ipAddress)
+			ctx = context.WithValue(ctx, "userAgent", r.UserAgent())
+
 			token := r.Header.Get("Authorization")
 
 			if token == "" {
-				ctx := context.WithValue(r.Context(), "userID", "")
+				ctx = context.WithValue(ctx, "userID", "")
+				ctx = context.WithValue(ctx, "sessionID", "")
+				ctx = context.WithValue(ctx, "twoFactorPending", false)
+				ctx = context.WithValue(ctx, "impersonatorID", "")
 				r = r.WithContext(ctx)
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			userID, tokenErr := res.ValidateUserTokenService().Execute(token)
+			claims, tokenErr := res.ValidateUserTokenService().Execute(token)
 			if tokenErr != nil {
-				ctx := context.WithValue(r.Context(), "userID", "")
+				ctx = context.WithValue(ctx, "userID", "")
+				ctx = context.WithValue(ctx, "sessionID", "")
+				ctx = context.WithValue(ctx, "twoFactorPending", false)
+				ctx = context.WithValue(ctx, "impersonatorID", "")
 				r = r.WithContext(ctx)
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), "userID", userID)
+			ctx = context.WithValue(ctx, "userID", claims.UserID)
+			ctx = context.WithValue(ctx, "sessionID", claims.SessionID)
+			ctx = context.WithValue(ctx, "twoFactorPending", claims.TwoFactorPending)
+			ctx = context.WithValue(ctx, "impersonatorID", claims.ImpersonatorID)
+			ctx = context.WithValue(ctx, "permissions", &requestPermissions{})
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
 		})
 	})
 
 	srv := handler.NewDefaultServer(generated.NewExecutableSchema(c))
+	srv.SetErrorPresenter(func(ctx context.Context, err error) *gqlerror.Error {
+		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+		// users get the rules of the password policy that their password does not follow
+		var weakPasswordErr *match.WeakPasswordError
+		if errors.As(err, &weakPasswordErr) {
+			gqlErr.Extensions = map[string]interface{}{"failedRules": weakPasswordErr.FailedRules}
+		}
+
+		// psychologists get which session of which treatment is in the way of the one being created or updated
+		var collisionErr *treatments_models.TreatmentCollisionError
+		if errors.As(err, &collisionErr) {
+			gqlErr.Extensions = map[string]interface{}{
+				"collidingTreatmentId": collisionErr.TreatmentID,
+				"occurrence":           collisionErr.Occurrence,
+				"week":                 collisionErr.Week,
+				"weekday":              collisionErr.Weekday,
+				"startTime":            collisionErr.StartTime,
+			}
+			if collisionErr.Date != "" {
+				gqlErr.Extensions["date"] = collisionErr.Date
+			}
+		}
+
+		return gqlErr
+	})
+	srv.AroundFields(func(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+		fc := graphql.GetFieldContext(ctx)
+		if fc == nil || fc.Field.Definition == nil {
+			return next(ctx)
+		}
+
+		impersonatorID := ctx.Value("impersonatorID").(string)
+
+		// every mutation, every resolution of a patient profile by another user and
+		// every operation performed while impersonating another user is recorded
+		if fc.Object != "Mutation" && fc.Field.Definition.Type.Name() != "PublicPatientProfile" &&
+			(impersonatorID == "" || fc.Object != "Query") {
+			return next(ctx)
+		}
+
+		var result interface{}
+		var err error
+
+		if impersonatorID != "" && fc.Object == "Mutation" && !allowedWhileImpersonating(res, fc.Field.Name) {
+			err = errors.New("forbidden while impersonating")
+		} else {
+			result, err = next(ctx)
+		}
+
+		operation := fc.Field.Name
+		if fc.Object != "Mutation" && fc.Object != "Query" {
+			operation = fc.Object + "." + fc.Field.Name
+		}
+
+		arguments := fc.Field.ArgumentMap(graphql.GetOperationContext(ctx).Variables)
+
+		targetIDs := collectTargetIDs(arguments, []string{})
+		if patient, ok := result.(*profiles_models.Patient); ok && patient != nil {
+			targetIDs = appendUnique(targetIDs, patient.ID)
+		}
+
+		userID := ctx.Value("userID").(string)
+
+		actorRole := ""
+		if userID != "" {
+			actorRole = string(ctx.Value("permissions").(*requestPermissions).getRole(res, userID))
+		}
+
+		outcome := audits_models.Success
+		errorMessage := ""
+		if err != nil {
+			outcome = audits_models.Failure
+			errorMessage = err.Error()
+			var gqlErr *gqlerror.Error
+			if errors.As(err, &gqlErr) {
+				errorMessage = gqlErr.Message
+			}
+		}
+
+		// a failure to record the entry must not change the response of the operation
+		res.RecordAuditEntryService().Execute(&audits_models.RecordAuditEntryInput{
+			ActorID:        userID,
+			ActorRole:      actorRole,
+			ImpersonatorID: impersonatorID,
+			Operation:      operation,
+			TargetIDs:      targetIDs,
+			Arguments:      arguments,
+			IPAddress:      ctx.Value("ipAddress").(string),
+			Outcome:        outcome,
+			Error:          errorMessage,
+		})
+
+		return result, err
+	})
 	srv.Use(&extension.ComplexityLimit{
 		Func: func(ctx context.Context, rc *graphql.OperationContext) int {
 			if rc != nil && rc.Operation.Name == "IntrospectionQuery" {
@@ -109,7 +233,150 @@ func CreateServer(res *resolvers.Resolver) *chi.Mux {
 	router.Handle("/", playground.Handler("GraphQL playground", "/gql"))
 	router.Handle("/gql", srv)
 	router.Handle("/static/{name}", fileHandler)
+	router.Handle("/exports/{token}", fileHandler)
 
 	return router
 
 }
+
+// requestPermissions loads the user of a request and the permissions granted to their role only once,
+// no matter how many fields of the request are guarded by the hasPermission directive or audited
+type requestPermissions struct {
+	once        sync.Once
+	role        users_models.Role
+	permissions map[string]bool
+}
+
+func (p *requestPermissions) get(res *resolvers.Resolver, userID string) map[string]bool {
+	p.load(res, userID)
+	return p.permissions
+}
+
+func (p *requestPermissions) getRole(res *resolvers.Resolver, userID string) users_models.Role {
+	p.load(res, userID)
+	return p.role
+}
+
+func (p *requestPermissions) load(res *resolvers.Resolver, userID string) {
+	p.once.Do(func() {
+		p.permissions = map[string]bool{}
+
+		user, userErr := res.GetUserByIDService().Execute(userID)
+		if userErr != nil || user == nil {
+			return
+		}
+
+		p.role = user.Role
+
+		// deactivated users keep their tokens until they expire, but lose every permission immediately
+		if !user.Active {
+			return
+		}
+
+		permissions, permissionsErr := res.GetPermissionsByRoleService().Execute(user.Role)
+		if permissionsErr != nil {
+			return
+		}
+
+		for _, permission := range permissions {
+			p.permissions[permission] = true
+		}
+	})
+}
+
+// clientIPAddress returns the address of the client that made a request. Forwarded headers can be forged by anyone, so
+// they are only read when the request comes from a trusted proxy, and the X-Forwarded-For chain is followed from the
+// right until the first address that is not a trusted proxy
+func clientIPAddress(r *http.Request, trustedProxies []*net.IPNet) string {
+	ipAddress, _, splitErr := net.SplitHostPort(r.RemoteAddr)
+	if splitErr != nil {
+		ipAddress = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(ipAddress, trustedProxies) {
+		return ipAddress
+	}
+
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			ipAddress = hop
+			if !isTrustedProxy(hop, trustedProxies) {
+				break
+			}
+		}
+		return ipAddress
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-Ip")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return ipAddress
+}
+
+func isTrustedProxy(ipAddress string, trustedProxies []*net.IPNet) bool {
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectTargetIDs finds the identifiers informed in the arguments of an operation, at any depth
+func collectTargetIDs(value interface{}, targetIDs []string) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := []string{}
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			item := v[key]
+			if id, ok := item.(string); ok && (key == "id" || strings.HasSuffix(key, "Id")) {
+				targetIDs = appendUnique(targetIDs, id)
+				continue
+			}
+			targetIDs = collectTargetIDs(item, targetIDs)
+		}
+	case []interface{}:
+		for _, item := range v {
+			targetIDs = collectTargetIDs(item, targetIDs)
+		}
+	}
+
+	return targetIDs
+}
+
+func allowedWhileImpersonating(res *resolvers.Resolver, mutation string) bool {
+	for _, allowed := range res.ImpersonationAllowedMutations {
+		if allowed == mutation {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
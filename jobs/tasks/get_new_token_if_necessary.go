@@ -11,14 +11,43 @@ import (
 type authenticateUserResponseBody struct {
 	Data struct {
 		AuthenticateUser struct {
-			Token string `json:"token"`
+			Token        string `json:"token"`
+			RefreshToken string `json:"refreshToken"`
 		} `json:"authenticateUser"`
 	} `json:"data"`
 }
 
-func GetNewTokenIfNecessary(token *string, url string, user string, pass string) {
+type refreshAuthenticationResponseBody struct {
+	Data struct {
+		RefreshAuthentication struct {
+			Token        string `json:"token"`
+			RefreshToken string `json:"refreshToken"`
+		} `json:"refreshAuthentication"`
+	} `json:"data"`
+}
+
+func GetNewTokenIfNecessary(token *string, refreshToken *string, url string, user string, pass string) {
+	if *token == "" && *refreshToken != "" {
+		bodyTpl := `{"query":"{ refreshAuthentication( refreshToken: \"%s\" ) { token refreshToken } }"}`
+		req, _ := http.NewRequest("POST", url, bytes.NewBuffer([]byte(fmt.Sprintf(bodyTpl, *refreshToken))))
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		jsonBody, _ := ioutil.ReadAll(resp.Body)
+		body := refreshAuthenticationResponseBody{}
+		json.Unmarshal(jsonBody, &body)
+		*token = body.Data.RefreshAuthentication.Token
+		*refreshToken = body.Data.RefreshAuthentication.RefreshToken
+	}
+
 	if *token == "" {
-		bodyTpl := `{"query":"{ authenticateUser( input: { email: \"%s\", password: \"%s\" } ) { token } }"}`
+		bodyTpl := `{"query":"{ authenticateUser( input: { email: \"%s\", password: \"%s\" } ) { token refreshToken } }"}`
 		req, _ := http.NewRequest("POST", url, bytes.NewBuffer([]byte(fmt.Sprintf(bodyTpl, user, pass))))
 		req.Header.Set("Content-Type", "application/json")
 
@@ -33,5 +62,6 @@ func GetNewTokenIfNecessary(token *string, url string, user string, pass string)
 		body := authenticateUserResponseBody{}
 		json.Unmarshal(jsonBody, &body)
 		*token = body.Data.AuthenticateUser.Token
+		*refreshToken = body.Data.AuthenticateUser.RefreshToken
 	}
 }
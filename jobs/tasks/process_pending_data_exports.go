@@ -0,0 +1,43 @@
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+)
+
+type processPendingDataExportsResponseBody struct {
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func ProcessPendingDataExports(token *string, url string) {
+	if *token != "" {
+		bodyTpl := `{"query":"mutation { processPendingDataExports }"}`
+		req, _ := http.NewRequest("POST", url, bytes.NewBuffer([]byte(bodyTpl)))
+		req.Header.Set("Authorization", *token)
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+
+		jsonBody, _ := ioutil.ReadAll(resp.Body)
+		body := processPendingDataExportsResponseBody{}
+		json.Unmarshal(jsonBody, &body)
+		if len(body.Errors) > 0 {
+			if body.Errors[0].Message == "forbidden" {
+				*token = ""
+			} else {
+				log.Fatalf(`ProcessPendingDataExports returned error %s`, body.Errors[0].Message)
+			}
+		}
+	}
+}
@@ -2,21 +2,32 @@ package main
 
 import (
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
+	// The time zone database is embedded since the production image does not ship one, and profiles rely on it
+	_ "time/tzdata"
 
 	"github.com/guicostaarantes/psi-server/graph"
 	"github.com/guicostaarantes/psi-server/graph/resolvers"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/archive"
+	"github.com/guicostaarantes/psi-server/utils/calendar"
+	"github.com/guicostaarantes/psi-server/utils/digest"
 	"github.com/guicostaarantes/psi-server/utils/file_storage"
 	"github.com/guicostaarantes/psi-server/utils/hash"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/logging"
 	"github.com/guicostaarantes/psi-server/utils/mail"
 	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/guicostaarantes/psi-server/utils/oidc"
 	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/otp"
 	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/table"
 	"github.com/guicostaarantes/psi-server/utils/token"
 )
 
@@ -27,17 +38,50 @@ func main() {
 	smtpUser := os.Getenv("PSI_SMTP_USERNAME")
 	smtpPass := os.Getenv("PSI_SMTP_PASSWORD")
 	filesBaseFolder := os.Getenv("PSI_FILES_BASE_FOLDER")
+	exportsBaseFolder := os.Getenv("PSI_EXPORTS_BASE_FOLDER")
 	postgresDsn := os.Getenv("PSI_POSTGRES_DSN")
+	tokenSigningKeys := os.Getenv("PSI_TOKEN_SIGNING_KEYS")
+	tokenDigestKey := os.Getenv("PSI_TOKEN_DIGEST_KEY")
+	passwordBlocklistFile := os.Getenv("PSI_PASSWORD_BLOCKLIST_FILE")
+	oidcIssuerURL := os.Getenv("PSI_OIDC_ISSUER_URL")
+	oidcClientID := os.Getenv("PSI_OIDC_CLIENT_ID")
+	oidcClientSecret := os.Getenv("PSI_OIDC_CLIENT_SECRET")
+	oidcRedirectURL := os.Getenv("PSI_OIDC_REDIRECT_URL")
+	oidcDefaultRole := users_models.Role(os.Getenv("PSI_OIDC_DEFAULT_ROLE"))
+	trustedProxies := os.Getenv("PSI_TRUSTED_PROXIES")
 
 	loggingUtil := logging.PrintLoggingUtil{}
 
+	archiveUtil := archive.ZipArchiveUtil{
+		LoggingUtil: loggingUtil,
+	}
+
+	calendarUtil := calendar.IcsCalendarUtil{
+		LoggingUtil: loggingUtil,
+	}
+
+	digestUtil := digest.HmacDigestUtil{
+		Key:         tokenDigestKey,
+		LoggingUtil: loggingUtil,
+	}
+
 	fileStorageUtil := file_storage.DiskFileStorageUtil{
 		BaseFolder:  filesBaseFolder,
 		LoggingUtil: loggingUtil,
 	}
 
-	hashUtil := hash.BcryptHashUtil{
-		Cost:        8,
+	// copies of personal data are kept apart from the files served at /static
+	exportStorageUtil := file_storage.DiskFileStorageUtil{
+		BaseFolder:  exportsBaseFolder,
+		LoggingUtil: loggingUtil,
+	}
+
+	hashUtil := hash.Argon2idHashUtil{
+		Memory:      64 * 1024,
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
 		LoggingUtil: loggingUtil,
 	}
 
@@ -53,10 +97,44 @@ func main() {
 		LoggingUtil: loggingUtil,
 	}
 
+	// The blocklist file holds common and breached passwords, one per line
+	passwordBlocklist := map[string]bool{}
+	if passwordBlocklistFile != "" {
+		content, readErr := os.ReadFile(passwordBlocklistFile)
+		if readErr != nil {
+			log.Fatalln(readErr)
+		}
+		passwordBlocklist = match.ParseBlocklist(string(content))
+	}
+
 	matchUtil := match.RegexpMatchUtil{
+		PasswordPolicy: match.PasswordPolicy{
+			MinLength:             10,
+			RequireUppercase:      true,
+			RequireLowercase:      true,
+			RequireDigit:          true,
+			RequireSymbol:         true,
+			MaxRepeatedCharacters: 3,
+			ForbidEmail:           true,
+			Blocklist:             passwordBlocklist,
+		},
 		LoggingUtil: loggingUtil,
 	}
 
+	// Logging in with an identity provider is disabled when PSI_OIDC_ISSUER_URL is empty
+	oidcUtil := oidc.HttpOidcUtil{
+		IssuerURL:    oidcIssuerURL,
+		ClientID:     oidcClientID,
+		ClientSecret: oidcClientSecret,
+		RedirectURL:  oidcRedirectURL,
+		HttpClient:   &http.Client{Timeout: time.Duration(10) * time.Second},
+		LoggingUtil:  loggingUtil,
+	}
+
+	if oidcDefaultRole != "" && oidcDefaultRole != users_models.Patient && oidcDefaultRole != users_models.Psychologist {
+		log.Fatalln("PSI_OIDC_DEFAULT_ROLE must be empty, PATIENT or PSYCHOLOGIST")
+	}
+
 	ormUtil := orm.PostgresOrmUtil{}
 
 	err := ormUtil.Connect(postgresDsn)
@@ -64,30 +142,99 @@ func main() {
 		log.Fatalln(err)
 	}
 
+	otpUtil := otp.TotpOtpUtil{
+		Issuer:      "PSI",
+		LoggingUtil: loggingUtil,
+	}
+
 	serializingUtil := serializing.JsonSerializingUtil{
 		LoggingUtil: loggingUtil,
 	}
 
-	tokenUtil := token.RngTokenUtil{
-		Runes: "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
-		Size:  64,
+	tableUtil := table.CsvTableUtil{
+		LoggingUtil: loggingUtil,
+	}
+
+	// Signing keys are informed as id=secret pairs separated by |, the first one being used to sign new tokens
+	tokenUtil := token.HmacTokenUtil{
+		Keys:        map[string]string{},
+		LoggingUtil: loggingUtil,
+	}
+
+	for _, pair := range strings.Split(tokenSigningKeys, "|") {
+		key := strings.SplitN(pair, "=", 2)
+		if len(key) != 2 || key[0] == "" || key[1] == "" {
+			log.Fatalln("PSI_TOKEN_SIGNING_KEYS must be a list of id=secret pairs separated by |")
+		}
+		if tokenUtil.CurrentKeyID == "" {
+			tokenUtil.CurrentKeyID = key[0]
+		}
+		tokenUtil.Keys[key[0]] = key[1]
+	}
+
+	// Forwarded headers are only read from proxies informed as IP addresses or CIDR ranges separated by |
+	trustedProxyNetworks := []*net.IPNet{}
+
+	for _, proxy := range strings.Split(trustedProxies, "|") {
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, network, parseErr := net.ParseCIDR(proxy)
+		if parseErr != nil {
+			log.Fatalln("PSI_TRUSTED_PROXIES must be a list of IP addresses or CIDR ranges separated by |")
+		}
+		trustedProxyNetworks = append(trustedProxyNetworks, network)
 	}
 
 	res := &resolvers.Resolver{
+		ArchiveUtil:                        archiveUtil,
+		CalendarUtil:                       calendarUtil,
+		DigestUtil:                         digestUtil,
+		ExportStorageUtil:                  exportStorageUtil,
 		FileStorageUtil:                    fileStorageUtil,
 		HashUtil:                           hashUtil,
 		IdentifierUtil:                     identifierUtil,
 		MailUtil:                           mailUtil,
 		MatchUtil:                          matchUtil,
+		OidcUtil:                           oidcUtil,
 		OrmUtil:                            &ormUtil,
+		OtpUtil:                            otpUtil,
 		SerializingUtil:                    serializingUtil,
+		TableUtil:                          tableUtil,
 		TokenUtil:                          tokenUtil,
 		MaxAffinityNumber:                  int64(5),
+		MaxAuditLogPageSize:                int64(100),
+		MaxUsersPageSize:                   int64(100),
+		AppointmentHorizonOccurrences:      int64(4),
 		ScheduleIntervalDuration:           time.Duration(604800) * time.Second,
-		ExpireAuthTokenDuration:            time.Duration(28800) * time.Second,
+		FreeSlotStepDuration:               time.Duration(1800) * time.Second,
+		ExpireAuthTokenDuration:            time.Duration(900) * time.Second,
+		ExpireRefreshTokenDuration:         time.Duration(2592000) * time.Second,
 		ExpireResetTokenDuration:           time.Duration(86400) * time.Second,
+		ExpireEmailChangeTokenDuration:     time.Duration(86400) * time.Second,
+		ExpireDataExportDuration:           time.Duration(172800) * time.Second,
+		ExpireImpersonationTokenDuration:   time.Duration(900) * time.Second,
+		ExpireInvitationDuration:           time.Duration(604800) * time.Second,
+		ExpireOidcAuthorizationDuration:    time.Duration(600) * time.Second,
 		InterruptTreatmentCooldownDuration: time.Duration(259200) * time.Second,
 		TopAffinitiesCooldownDuration:      time.Duration(86400) * time.Second,
+		LoginFailedCooldownDuration:        time.Duration(900) * time.Second,
+		LoginFailedDelayDuration:           time.Duration(1) * time.Second,
+		LoginLockedCooldownDuration:        time.Duration(1800) * time.Second,
+		MaxLoginFailuresPerUser:            int64(5),
+		MaxLoginFailuresPerIPAddress:       int64(50),
+		RecoveryCodesQuantity:              int64(10),
+		TwoFactorRequiredRoles:             []users_models.Role{users_models.Coordinator, users_models.Psychologist},
+		ImpersonationAllowedMutations:      []string{},
+		OidcDefaultRole:                    oidcDefaultRole,
+		TrustedProxies:                     trustedProxyNetworks,
 	}
 
 	router := graph.CreateServer(res)
@@ -0,0 +1,19 @@
+package appointments_models
+
+import "time"
+
+// Absence is the schema for a period in which a psychologist will not attend sessions, such as vacations
+type Absence struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	PsychologistID string    `json:"psychologistId" gorm:"index"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Reason         string    `json:"reason"`
+}
+
+// CreateAbsenceInput is the schema for information needed to create an absence of a psychologist
+type CreateAbsenceInput struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason"`
+}
@@ -32,15 +32,20 @@ const (
 	TreatmentInterruptedByPsychologist AppointmentStatus = "TREATMENT_INTERRUPTED_BY_PSYCHOLOGIST"
 	// TreatmentFinalized means that the whole treatment was finalized
 	TreatmentFinalized AppointmentStatus = "TREATMENT_FINALIZED"
+	// Skipped means that the session falls on a holiday or in an absence of the psychologist, which is informed in the reason
+	Skipped AppointmentStatus = "SKIPPED"
 )
 
-// Appointment represents the mutual promise of psychologist and patient to meet at a specific time
+// Appointment represents the mutual promise of psychologist and patient to meet at a specific time.
+// Occurrence is the index of the session of the treatment that originated the appointment, counted since the treatment was assigned.
+// It is nil for appointments created before occurrences were counted, which are left out of the unique index
 type Appointment struct {
 	ID             string            `json:"id" gorm:"primaryKey"`
 	CreatedAt      time.Time         `json:"createdAt`
 	UpdatedAt      time.Time         `json:"updatedAt`
 	DeletedAt      gorm.DeletedAt    `gorm:"index"`
-	TreatmentID    string            `json:"treatmentId"`
+	TreatmentID    string            `json:"treatmentId" gorm:"uniqueIndex:idx_appointment_treatment_occurrence,where:occurrence IS NOT NULL"`
+	Occurrence     *int64            `json:"occurrence" gorm:"uniqueIndex:idx_appointment_treatment_occurrence"`
 	PatientID      string            `json:"patientId"`
 	PsychologistID string            `json:"psychologistId"`
 	Start          time.Time         `json:"start"`
@@ -0,0 +1,15 @@
+package appointments_models
+
+// Holiday is the schema for a day in which no sessions are scheduled. Date is in the YYYY-MM-DD format and is compared to
+// the date of each session in the time zone of its psychologist
+type Holiday struct {
+	ID   string `json:"id" gorm:"primaryKey"`
+	Date string `json:"date" gorm:"uniqueIndex"`
+	Name string `json:"name"`
+}
+
+// CreateHolidayInput is the schema for information needed to create a holiday
+type CreateHolidayInput struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
@@ -54,7 +54,7 @@ func (s CancelAppointmentByPsychologistService) Execute(id string, psychologistI
 		return result.Error
 	}
 
-	if appointment.Status == appointments_models.CanceledByPatient || appointment.Status == appointments_models.CanceledByPsychologist {
+	if appointment.Status == appointments_models.CanceledByPatient || appointment.Status == appointments_models.CanceledByPsychologist || appointment.Status == appointments_models.Skipped {
 		return fmt.Errorf("appointment status cannot change from %s to CANCELED_BY_PSYCHOLOGIST", string(appointment.Status))
 	}
 
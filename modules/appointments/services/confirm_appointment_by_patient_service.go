@@ -27,7 +27,7 @@ func (s ConfirmAppointmentByPatientService) Execute(id string, patientID string)
 		return errors.New("resource not found")
 	}
 
-	if appointment.Status == appointments_models.EditedByPatient || appointment.Status == appointments_models.ConfirmedByPatient || appointment.Status == appointments_models.CanceledByPsychologist {
+	if appointment.Status == appointments_models.EditedByPatient || appointment.Status == appointments_models.ConfirmedByPatient || appointment.Status == appointments_models.CanceledByPsychologist || appointment.Status == appointments_models.Skipped {
 		return fmt.Errorf("appointment status cannot change from %s to CONFIRMED_BY_PATIENT", string(appointment.Status))
 	}
 
@@ -27,7 +27,7 @@ func (s ConfirmAppointmentByPsychologistService) Execute(id string, psychologist
 		return errors.New("resource not found")
 	}
 
-	if appointment.Status == appointments_models.EditedByPsychologist || appointment.Status == appointments_models.ConfirmedByPsychologist || appointment.Status == appointments_models.CanceledByPatient {
+	if appointment.Status == appointments_models.EditedByPsychologist || appointment.Status == appointments_models.ConfirmedByPsychologist || appointment.Status == appointments_models.CanceledByPatient || appointment.Status == appointments_models.Skipped {
 		return fmt.Errorf("appointment status cannot change from %s to CONFIRMED_BY_PSYCHOLOGIST", string(appointment.Status))
 	}
 
@@ -0,0 +1,49 @@
+package appointments_services
+
+import (
+	"errors"
+	"time"
+
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// CreateAbsenceService is a service that creates a period in which a psychologist will not attend sessions
+type CreateAbsenceService struct {
+	IdentifierUtil identifier.IIdentifierUtil
+	OrmUtil        orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s CreateAbsenceService) Execute(psychologistID string, input appointments_models.CreateAbsenceInput) error {
+
+	if !input.End.After(input.Start) {
+		return errors.New("absence cannot have negative duration")
+	}
+
+	if time.Now().After(input.End) {
+		return errors.New("absence cannot end in the past")
+	}
+
+	_, absenceID, absenceIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if absenceIDErr != nil {
+		return absenceIDErr
+	}
+
+	absence := appointments_models.Absence{
+		ID:             absenceID,
+		PsychologistID: psychologistID,
+		Start:          input.Start,
+		End:            input.End,
+		Reason:         input.Reason,
+	}
+
+	result := s.OrmUtil.Db().Create(&absence)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
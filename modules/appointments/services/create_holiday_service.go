@@ -0,0 +1,60 @@
+package appointments_services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// CreateHolidayService is a service that creates a holiday, in which no sessions are scheduled
+type CreateHolidayService struct {
+	IdentifierUtil identifier.IIdentifierUtil
+	OrmUtil        orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s CreateHolidayService) Execute(input appointments_models.CreateHolidayInput) error {
+
+	_, dateErr := time.Parse("2006-01-02", input.Date)
+	if dateErr != nil {
+		return errors.New("invalid date")
+	}
+
+	if strings.TrimSpace(input.Name) == "" {
+		return errors.New("holiday name cannot be empty")
+	}
+
+	existingHoliday := appointments_models.Holiday{}
+
+	result := s.OrmUtil.Db().Where("date = ?", input.Date).Limit(1).Find(&existingHoliday)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if existingHoliday.ID != "" {
+		return errors.New("there is already a holiday in this date")
+	}
+
+	_, holidayID, holidayIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if holidayIDErr != nil {
+		return holidayIDErr
+	}
+
+	holiday := appointments_models.Holiday{
+		ID:   holidayID,
+		Date: input.Date,
+		Name: strings.TrimSpace(input.Name),
+	}
+
+	result = s.OrmUtil.Db().Create(&holiday)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -2,8 +2,12 @@ package appointments_services
 
 import (
 	"bytes"
+	"fmt"
 	"html/template"
+	"math"
 	"os"
+	"sort"
+	"strconv"
 	"time"
 
 	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
@@ -11,67 +15,172 @@ import (
 	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
 	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
 	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	treatments_services "github.com/guicostaarantes/psi-server/modules/treatments/services"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/orm"
+	"gorm.io/gorm/clause"
 )
 
-// CreatePendingAppointmentsService is a service that creates appointments for all active treatments that have no appointments scheduled to the future
+// CreatePendingAppointmentsService is a service that creates appointments for the next sessions of all active treatments,
+// up to the horizon of occurrences. Each appointment is keyed by its treatment and occurrence index, so sessions that
+// already have an appointment, even if it was canceled or edited, are never generated again. Sessions that fall on a
+// holiday or in an absence of the psychologist are created as skipped, and so are untouched appointments that are found
+// in one of these periods afterwards. The patient gets a single mail with all the changes of each treatment
 type CreatePendingAppointmentsService struct {
-	IdentifierUtil           identifier.IIdentifierUtil
-	OrmUtil                  orm.IOrmUtil
-	ScheduleIntervalDuration time.Duration
+	IdentifierUtil                 identifier.IIdentifierUtil
+	OrmUtil                        orm.IOrmUtil
+	GetTreatmentOccurrencesService *treatments_services.GetTreatmentOccurrencesService
+	AppointmentHorizonOccurrences  int64
 }
 
 // Execute is the method that runs the business logic of the service
 func (s CreatePendingAppointmentsService) Execute() error {
 
-	activeTreatmentsWithoutFutureAppointments := []*treatments_models.Treatment{}
+	now := time.Now()
+	activeTreatments := []*treatments_models.Treatment{}
+	holidays := []*appointments_models.Holiday{}
 
-	result := s.OrmUtil.Db().Raw(
-		"SELECT * FROM treatments WHERE id IN (SELECT DISTINCT treatments.id FROM treatments LEFT JOIN appointments ON appointments.treatment_id = treatments.id WHERE treatments.status = ? EXCEPT SELECT treatment_id FROM appointments WHERE start > ?)",
-		treatments_models.Active,
-		time.Now(),
-	).Find(&activeTreatmentsWithoutFutureAppointments)
+	result := s.OrmUtil.Db().Where("status = ?", treatments_models.Active).Find(&activeTreatments)
 	if result.Error != nil {
 		return result.Error
 	}
 
-	for _, treatment := range activeTreatmentsWithoutFutureAppointments {
-		currentTime := time.Now()
-		intervalDuration := int64(s.ScheduleIntervalDuration/time.Second) * treatment.Frequency
-		currentInterval := currentTime.Unix() / intervalDuration
-		nextAppointmentStart := time.Unix(intervalDuration*currentInterval+treatment.Phase, 10)
-		// if the start time of the current interval has already passed, send it to the next interval
-		if currentTime.After(nextAppointmentStart) {
-			nextAppointmentStart = nextAppointmentStart.Add(time.Duration(intervalDuration) * time.Second)
-		}
-
-		_, appoID, appoIDErr := s.IdentifierUtil.GenerateIdentifier()
-		if appoIDErr != nil {
-			return appoIDErr
-		}
+	result = s.OrmUtil.Db().Where("date >= ?", now.AddDate(0, 0, -1).Format("2006-01-02")).Find(&holidays)
+	if result.Error != nil {
+		return result.Error
+	}
 
-		newAppointment := appointments_models.Appointment{
-			ID:             appoID,
-			TreatmentID:    treatment.ID,
-			PatientID:      treatment.PatientID,
-			PsychologistID: treatment.PsychologistID,
-			Start:          nextAppointmentStart,
-			End:            nextAppointmentStart.Add(time.Duration(treatment.Duration) * time.Second),
-			PriceRangeName: treatment.PriceRangeName,
-			Status:         appointments_models.Created,
-		}
+	holidayNames := map[string]string{}
+	for _, holiday := range holidays {
+		holidayNames[holiday.Date] = holiday.Name
+	}
 
+	for _, treatment := range activeTreatments {
 		psychologist := profiles_models.Psychologist{}
 		patient := profiles_models.Patient{}
 		patientUser := users_models.User{}
+		absences := []*appointments_models.Absence{}
+
+		occurrences, occurrencesErr := s.GetTreatmentOccurrencesService.Execute(treatment, now, s.AppointmentHorizonOccurrences)
+		if occurrencesErr != nil {
+			return occurrencesErr
+		}
 
 		result = s.OrmUtil.Db().Where("id = ?", treatment.PsychologistID).Limit(1).Find(&psychologist)
 		if result.Error != nil {
 			return result.Error
 		}
 
+		location, locationErr := time.LoadLocation(psychologist.TimeZone)
+		if locationErr != nil {
+			return locationErr
+		}
+
+		result = s.OrmUtil.Db().Where("psychologist_id = ? AND \"end\" > ?", treatment.PsychologistID, now).Find(&absences)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// treatments whose recurrence rule has ended have no more sessions, but their future appointments are still checked
+		firstOccurrence := int64(math.MaxInt64)
+		if len(occurrences) > 0 {
+			firstOccurrence = occurrences[0].Index
+		}
+
+		existingAppointments := []*appointments_models.Appointment{}
+
+		// appointments created before occurrences were counted are matched by their start instead
+		result = s.OrmUtil.Db().Where("treatment_id = ? AND (occurrence >= ? OR start >= ?)", treatment.ID, firstOccurrence, now).Find(&existingAppointments)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		scheduledOccurrences := map[int64]bool{}
+		scheduledStarts := map[int64]bool{}
+		skippedAppointments := []*appointments_models.Appointment{}
+
+		for _, appointment := range existingAppointments {
+			if appointment.Occurrence != nil {
+				scheduledOccurrences[*appointment.Occurrence] = true
+			}
+			scheduledStarts[appointment.Start.Unix()] = true
+
+			// holidays and absences declared after the appointment was created only affect it if nobody has acted on it yet
+			if appointment.Status != appointments_models.Created || appointment.Start.Before(now) {
+				continue
+			}
+
+			reason := blackoutReason(appointment.Start, appointment.End, location, holidayNames, absences)
+			if reason == "" {
+				continue
+			}
+
+			appointment.Status = appointments_models.Skipped
+			appointment.Reason = reason
+
+			result = s.OrmUtil.Db().Save(appointment)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			skippedAppointments = append(skippedAppointments, appointment)
+		}
+
+		createdQuantity := 0
+
+		for _, occurrence := range occurrences {
+			if scheduledOccurrences[occurrence.Index] || scheduledStarts[occurrence.Start.Unix()] {
+				continue
+			}
+
+			_, appoID, appoIDErr := s.IdentifierUtil.GenerateIdentifier()
+			if appoIDErr != nil {
+				return appoIDErr
+			}
+
+			occurrenceIndex := occurrence.Index
+
+			appointment := &appointments_models.Appointment{
+				ID:             appoID,
+				TreatmentID:    treatment.ID,
+				Occurrence:     &occurrenceIndex,
+				PatientID:      treatment.PatientID,
+				PsychologistID: treatment.PsychologistID,
+				Start:          occurrence.Start,
+				End:            occurrence.End,
+				PriceRangeName: treatment.PriceRangeName,
+				Status:         appointments_models.Created,
+			}
+
+			// skipped sessions are kept as appointments, so that they are not generated again
+			reason := blackoutReason(occurrence.Start, occurrence.End, location, holidayNames, absences)
+			if reason != "" {
+				appointment.Status = appointments_models.Skipped
+				appointment.Reason = reason
+			}
+
+			// another run of the job may have created the appointment of this occurrence in the meantime
+			result = s.OrmUtil.Db().Clauses(clause.OnConflict{DoNothing: true}).Create(appointment)
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			if appointment.Status == appointments_models.Skipped {
+				skippedAppointments = append(skippedAppointments, appointment)
+			} else {
+				createdQuantity++
+			}
+		}
+
+		if createdQuantity == 0 && len(skippedAppointments) == 0 {
+			continue
+		}
+
 		result = s.OrmUtil.Db().Where("id = ?", treatment.PatientID).Limit(1).Find(&patient)
 		if result.Error != nil {
 			return result.Error
@@ -82,6 +191,25 @@ func (s CreatePendingAppointmentsService) Execute() error {
 			return result.Error
 		}
 
+		patientLocation, patientLocationErr := time.LoadLocation(patient.TimeZone)
+		if patientLocationErr != nil {
+			patientLocation = time.UTC
+		}
+
+		sort.Slice(skippedAppointments, func(i int, j int) bool {
+			return skippedAppointments[i].Start.Before(skippedAppointments[j].Start)
+		})
+
+		skippedSessions := []string{}
+		for _, appointment := range skippedAppointments {
+			skippedSessions = append(skippedSessions, fmt.Sprintf("%s - %s", appointment.Start.In(patientLocation).Format("02/01/2006 15:04"), appointment.Reason))
+		}
+
+		subject := "Consulta criada no PSI"
+		if createdQuantity == 0 {
+			subject = "Consultas suspensas no PSI"
+		}
+
 		_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
 		if mailIDErr != nil {
 			return mailIDErr
@@ -94,10 +222,12 @@ func (s CreatePendingAppointmentsService) Execute() error {
 
 		buff := new(bytes.Buffer)
 
-		templ.Execute(buff, map[string]string{
-			"SiteURL":     os.Getenv("PSI_SITE_URL"),
-			"LikeName":    patient.LikeName,
-			"PsyFullName": psychologist.FullName,
+		templ.Execute(buff, map[string]interface{}{
+			"SiteURL":         os.Getenv("PSI_SITE_URL"),
+			"LikeName":        patient.LikeName,
+			"PsyFullName":     psychologist.FullName,
+			"Quantity":        strconv.Itoa(createdQuantity),
+			"SkippedSessions": skippedSessions,
 		})
 
 		mail := &mails_models.TransientMailMessage{
@@ -107,7 +237,7 @@ func (s CreatePendingAppointmentsService) Execute() error {
 			To:          patientUser.Email,
 			Cc:          "",
 			Cco:         "",
-			Subject:     "Consulta criada no PSI",
+			Subject:     subject,
 			Html:        buff.String(),
 			Processed:   false,
 		}
@@ -116,13 +246,30 @@ func (s CreatePendingAppointmentsService) Execute() error {
 		if result.Error != nil {
 			return result.Error
 		}
+	}
 
-		result = s.OrmUtil.Db().Create(&newAppointment)
-		if result.Error != nil {
-			return result.Error
+	return nil
+
+}
+
+// blackoutReason returns the name of the holiday or the reason of the absence of the psychologist that prevents a session
+// from happening, or an empty string if the session can happen. Holidays are compared to the date of the session in the
+// time zone of the psychologist
+func blackoutReason(start time.Time, end time.Time, location *time.Location, holidayNames map[string]string, absences []*appointments_models.Absence) string {
+
+	if name, ok := holidayNames[start.In(location).Format("2006-01-02")]; ok {
+		return name
+	}
+
+	for _, absence := range absences {
+		if start.Before(absence.End) && absence.Start.Before(end) {
+			if absence.Reason == "" {
+				return "Ausência do psicólogo"
+			}
+			return absence.Reason
 		}
 	}
 
-	return nil
+	return ""
 
 }
@@ -0,0 +1,37 @@
+package appointments_services
+
+import (
+	"errors"
+
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DeleteAbsenceService is a service that deletes an absence of a psychologist. Sessions already marked as skipped because
+// of it are kept
+type DeleteAbsenceService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s DeleteAbsenceService) Execute(id string, psychologistID string) error {
+
+	absence := appointments_models.Absence{}
+
+	result := s.OrmUtil.Db().Where("id = ? AND psychologist_id = ?", id, psychologistID).Limit(1).Find(&absence)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if absence.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	result = s.OrmUtil.Db().Delete(&absence)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -0,0 +1,36 @@
+package appointments_services
+
+import (
+	"errors"
+
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DeleteHolidayService is a service that deletes a holiday. Sessions already marked as skipped because of it are kept
+type DeleteHolidayService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s DeleteHolidayService) Execute(id string) error {
+
+	holiday := appointments_models.Holiday{}
+
+	result := s.OrmUtil.Db().Where("id = ?", id).Limit(1).Find(&holiday)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if holiday.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	result = s.OrmUtil.Db().Delete(&holiday)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -55,7 +55,7 @@ func (s EditAppointmentByPatientService) Execute(id string, patientID string, in
 		return result.Error
 	}
 
-	if appointment.Status == appointments_models.CanceledByPsychologist {
+	if appointment.Status == appointments_models.CanceledByPsychologist || appointment.Status == appointments_models.Skipped {
 		return fmt.Errorf("appointment status cannot change from %s to EDITED_BY_PATIENT", string(appointment.Status))
 	}
 
@@ -0,0 +1,25 @@
+package appointments_services
+
+import (
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetAbsencesService is a service that gets the absences of a psychologist, ordered by their start
+type GetAbsencesService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetAbsencesService) Execute(psychologistID string) ([]*appointments_models.Absence, error) {
+
+	absences := []*appointments_models.Absence{}
+
+	result := s.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Order("start ASC").Find(&absences)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return absences, nil
+
+}
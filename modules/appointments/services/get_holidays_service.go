@@ -0,0 +1,25 @@
+package appointments_services
+
+import (
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetHolidaysService is a service that gets all holidays, ordered by their date
+type GetHolidaysService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetHolidaysService) Execute() ([]*appointments_models.Holiday, error) {
+
+	holidays := []*appointments_models.Holiday{}
+
+	result := s.OrmUtil.Db().Order("date ASC").Find(&holidays)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return holidays, nil
+
+}
@@ -0,0 +1,87 @@
+package appointments_services
+
+import (
+	"io"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	"github.com/guicostaarantes/psi-server/utils/calendar"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ImportHolidaysService is a service that creates holidays from the events of an ICS file. Events that last many days
+// create one holiday for each day, and days that already are holidays have their name replaced
+type ImportHolidaysService struct {
+	CalendarUtil   calendar.ICalendarUtil
+	IdentifierUtil identifier.IIdentifierUtil
+	OrmUtil        orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ImportHolidaysService) Execute(file graphql.Upload) ([]*appointments_models.Holiday, error) {
+
+	data, readErr := io.ReadAll(file.File)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	events, parseErr := s.CalendarUtil.Parse(data)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	holidays := []*appointments_models.Holiday{}
+	importedHolidays := map[string]*appointments_models.Holiday{}
+
+	for _, event := range events {
+		name := strings.TrimSpace(event.Summary)
+		if name == "" {
+			name = "Feriado"
+		}
+
+		// events with a time only mark the day in which they start
+		last := event.Start
+		if event.AllDay && event.End.After(event.Start) {
+			last = event.End.AddDate(0, 0, -1)
+		}
+
+		for day := event.Start; !day.After(last); day = day.AddDate(0, 0, 1) {
+			date := day.Format("2006-01-02")
+
+			holiday, imported := importedHolidays[date]
+			if !imported {
+				holiday = &appointments_models.Holiday{}
+
+				result := s.OrmUtil.Db().Where("date = ?", date).Limit(1).Find(holiday)
+				if result.Error != nil {
+					return nil, result.Error
+				}
+
+				importedHolidays[date] = holiday
+				holidays = append(holidays, holiday)
+			}
+
+			if holiday.ID == "" {
+				_, holidayID, holidayIDErr := s.IdentifierUtil.GenerateIdentifier()
+				if holidayIDErr != nil {
+					return nil, holidayIDErr
+				}
+
+				holiday.ID = holidayID
+				holiday.Date = date
+			}
+
+			holiday.Name = name
+
+			result := s.OrmUtil.Db().Save(holiday)
+			if result.Error != nil {
+				return nil, result.Error
+			}
+		}
+	}
+
+	return holidays, nil
+
+}
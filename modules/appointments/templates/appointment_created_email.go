@@ -1,7 +1,11 @@
 package appointments_templates
 
-// AppointmentCreatedEmailTemplate is an email template used to tell the patient when a appointment is created
+// AppointmentCreatedEmailTemplate is an email template used to tell the patient when appointments are created for a treatment,
+// listing the sessions that were skipped because of holidays or absences of the psychologist
 var AppointmentCreatedEmailTemplate = `<h2>Olá {{ .LikeName }} 😊</h2>
-<p>Viemos te informar que nosso sistema gerou uma nova consulta para seu tratamento no PSI com {{ .PsyFullName }}.</p>
-<p>Entre no nosso site para confirmar, alterar ou cancelar essa consulta.</p>
+{{ if eq .Quantity "1" }}<p>Viemos te informar que nosso sistema gerou uma nova consulta para seu tratamento no PSI com {{ .PsyFullName }}.</p>
+<p>Entre no nosso site para confirmar, alterar ou cancelar essa consulta.</p>{{ else if ne .Quantity "0" }}<p>Viemos te informar que nosso sistema gerou {{ .Quantity }} novas consultas para seu tratamento no PSI com {{ .PsyFullName }}.</p>
+<p>Entre no nosso site para confirmar, alterar ou cancelar essas consultas.</p>{{ end }}{{ if .SkippedSessions }}
+<p>As seguintes sessões do seu tratamento no PSI com {{ .PsyFullName }} não acontecerão:</p>
+<ul>{{ range .SkippedSessions }}<li>{{ . }}</li>{{ end }}</ul>{{ end }}
 <a href="{{ .SiteURL }}">Ir para o site</a>`
@@ -0,0 +1,40 @@
+package audits_models
+
+import "time"
+
+// AuditOutcome represents the possible results of an audited operation
+type AuditOutcome string
+
+const (
+	// Success means that the operation was completed without errors
+	Success AuditOutcome = "SUCCESS"
+	// Failure means that the operation returned an error, including authorization errors
+	Failure AuditOutcome = "FAILURE"
+)
+
+// RedactedArguments are the names of arguments whose values are never stored in the audit log
+var RedactedArguments = []string{
+	"avatar",
+	"code",
+	"currentPassword",
+	"newPassword",
+	"otpCode",
+	"password",
+	"refreshToken",
+	"token",
+}
+
+// AuditEntry is the schema for the record of an operation performed in the application
+type AuditEntry struct {
+	ID             string       `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time    `json:"createdAt" gorm:"index"`
+	ActorID        string       `json:"actorId" gorm:"index"`
+	ActorRole      string       `json:"actorRole"`
+	ImpersonatorID string       `json:"impersonatorId" gorm:"index"`
+	Operation      string       `json:"operation" gorm:"index"`
+	TargetIDs      string       `json:"targetIds"`
+	Arguments      string       `json:"arguments"`
+	IPAddress      string       `json:"ipAddress"`
+	Outcome        AuditOutcome `json:"outcome"`
+	Error          string       `json:"error"`
+}
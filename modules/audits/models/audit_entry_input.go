@@ -0,0 +1,27 @@
+package audits_models
+
+import "time"
+
+// RecordAuditEntryInput is the schema for the information needed to record an operation in the audit log
+type RecordAuditEntryInput struct {
+	ActorID        string
+	ActorRole      string
+	ImpersonatorID string
+	Operation      string
+	TargetIDs      []string
+	Arguments      map[string]interface{}
+	IPAddress      string
+	Outcome        AuditOutcome
+	Error          string
+}
+
+// AuditLogFilterInput is the schema for the filters that can be applied when reading the audit log
+type AuditLogFilterInput struct {
+	ActorID        *string       `json:"actorId"`
+	ImpersonatorID *string       `json:"impersonatorId"`
+	Operation      *string       `json:"operation"`
+	TargetID       *string       `json:"targetId"`
+	Outcome        *AuditOutcome `json:"outcome"`
+	Since          *time.Time    `json:"since"`
+	Until          *time.Time    `json:"until"`
+}
@@ -0,0 +1,7 @@
+package audits_models
+
+// AuditLogPage is the schema for a page of entries of the audit log
+type AuditLogPage struct {
+	TotalCount int64         `json:"totalCount"`
+	Entries    []*AuditEntry `json:"entries"`
+}
@@ -0,0 +1,78 @@
+package audits_services
+
+import (
+	"errors"
+	"strings"
+
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"gorm.io/gorm"
+)
+
+// GetAuditLogService is a service that gets a page of the audit log, from the newest to the oldest entry
+type GetAuditLogService struct {
+	OrmUtil             orm.IOrmUtil
+	MaxAuditLogPageSize int64
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetAuditLogService) Execute(filter *audits_models.AuditLogFilterInput, offset int64, limit int64) (*audits_models.AuditLogPage, error) {
+
+	if offset < 0 {
+		return nil, errors.New("offset cannot be negative")
+	}
+
+	if limit < 1 || limit > s.MaxAuditLogPageSize {
+		return nil, errors.New("limit is out of range")
+	}
+
+	page := &audits_models.AuditLogPage{
+		Entries: []*audits_models.AuditEntry{},
+	}
+
+	result := s.filter(filter).Count(&page.TotalCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = s.filter(filter).Order("created_at DESC").Offset(int(offset)).Limit(int(limit)).Find(&page.Entries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return page, nil
+
+}
+
+func (s GetAuditLogService) filter(filter *audits_models.AuditLogFilterInput) *gorm.DB {
+
+	query := s.OrmUtil.Db().Model(&audits_models.AuditEntry{})
+
+	if filter != nil {
+		if filter.ActorID != nil {
+			query = query.Where("actor_id = ?", *filter.ActorID)
+		}
+		if filter.ImpersonatorID != nil {
+			query = query.Where("impersonator_id = ?", *filter.ImpersonatorID)
+		}
+		if filter.Operation != nil {
+			query = query.Where("operation = ?", *filter.Operation)
+		}
+		if filter.TargetID != nil {
+			escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(*filter.TargetID)
+			query = query.Where("(',' || target_ids || ',') LIKE ? ESCAPE '\\'", "%,"+escaped+",%")
+		}
+		if filter.Outcome != nil {
+			query = query.Where("outcome = ?", *filter.Outcome)
+		}
+		if filter.Since != nil {
+			query = query.Where("created_at >= ?", *filter.Since)
+		}
+		if filter.Until != nil {
+			query = query.Where("created_at < ?", *filter.Until)
+		}
+	}
+
+	return query
+
+}
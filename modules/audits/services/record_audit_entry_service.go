@@ -0,0 +1,77 @@
+package audits_services
+
+import (
+	"strings"
+
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/serializing"
+)
+
+// RecordAuditEntryService is a service that stores an operation in the audit log, redacting sensitive arguments
+type RecordAuditEntryService struct {
+	IdentifierUtil  identifier.IIdentifierUtil
+	OrmUtil         orm.IOrmUtil
+	SerializingUtil serializing.ISerializingUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RecordAuditEntryService) Execute(input *audits_models.RecordAuditEntryInput) error {
+
+	_, entryID, entryIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if entryIDErr != nil {
+		return entryIDErr
+	}
+
+	arguments, argumentsErr := s.SerializingUtil.VariableToBytes(redact(input.Arguments))
+	if argumentsErr != nil {
+		return argumentsErr
+	}
+
+	entry := audits_models.AuditEntry{
+		ID:             entryID,
+		ActorID:        input.ActorID,
+		ActorRole:      input.ActorRole,
+		ImpersonatorID: input.ImpersonatorID,
+		Operation:      input.Operation,
+		TargetIDs:      strings.Join(input.TargetIDs, ","),
+		Arguments:      string(arguments),
+		IPAddress:      input.IPAddress,
+		Outcome:        input.Outcome,
+		Error:          input.Error,
+	}
+
+	result := s.OrmUtil.Db().Create(&entry)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
+
+// redact returns a copy of the value where the arguments listed in RedactedArguments are replaced, at any depth
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := map[string]interface{}{}
+		for key, item := range v {
+			redacted[key] = redact(item)
+			for _, name := range audits_models.RedactedArguments {
+				if key == name {
+					redacted[key] = "[REDACTED]"
+				}
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := []interface{}{}
+		for _, item := range v {
+			redacted = append(redacted, redact(item))
+		}
+		return redacted
+	default:
+		return v
+	}
+}
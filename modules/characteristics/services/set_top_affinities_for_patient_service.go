@@ -8,12 +8,13 @@ import (
 	characteristics_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
 	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
 	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
 	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/orm"
 )
 
-// SetTopAffinitiesForPatientService is a service that calculates the affinity between a given patient and all psychologists with pending treatments, and saves the most relevant ones to a table
+// SetTopAffinitiesForPatientService is a service that calculates the affinity between a given patient and all verified psychologists with pending treatments, and saves the most relevant ones to a table
 type SetTopAffinitiesForPatientService struct {
 	IdentifierUtil      identifier.IIdentifierUtil
 	OrmUtil             orm.IOrmUtil
@@ -65,6 +66,20 @@ func (s SetTopAffinitiesForPatientService) Execute(patientID string) error {
 		}
 	}
 
+	// Only psychologists whose CRP was verified can be matched with patients
+	verifiedPsychologists := []*profiles_models.Psychologist{}
+
+	result = s.OrmUtil.Db().Select("id").Where("verification_status = ?", profiles_models.Verified).Find(&verifiedPsychologists)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	isVerified := map[string]bool{}
+
+	for _, psychologist := range verifiedPsychologists {
+		isVerified[psychologist.ID] = true
+	}
+
 	// Check if psychologist has at least one treatment price range offering with a possible price range
 	priceRangesOfferings := []*treatments_models.TreatmentPriceRangeOffering{}
 
@@ -74,7 +89,7 @@ func (s SetTopAffinitiesForPatientService) Execute(patientID string) error {
 	}
 
 	for _, priceRangeOffering := range priceRangesOfferings {
-		if _, exists := possiblePriceRanges[priceRangeOffering.PriceRangeName]; exists {
+		if _, exists := possiblePriceRanges[priceRangeOffering.PriceRangeName]; exists && isVerified[priceRangeOffering.PsychologistID] {
 			if _, exists := affinityResult[priceRangeOffering.PsychologistID]; !exists {
 				affinityResult[priceRangeOffering.PsychologistID] = &characteristics_models.AffinityScore{}
 			}
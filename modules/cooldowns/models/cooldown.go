@@ -12,6 +12,10 @@ const (
 	Patient CooldownProfileType = "PATIENT"
 	// PsychologistTarget means that the cooldown is related to a psychologist
 	Psychologist CooldownProfileType = "PSYCHOLOGIST"
+	// User means that the cooldown is related to a user
+	User CooldownProfileType = "USER"
+	// IPAddress means that the cooldown is related to an IP address
+	IPAddress CooldownProfileType = "IP_ADDRESS"
 )
 
 // CooldownType represents the possible types of a cooldown
@@ -22,6 +26,10 @@ const (
 	TreatmentInterrupted CooldownType = "TREATMENT_INTERRUPTED"
 	// TopAffinitiesSet means that the user set their top affinities for a psychologist
 	TopAffinitiesSet CooldownType = "TOP_AFFINITIES_SET"
+	// LoginFailed means that an authentication attempt failed due to incorrect credentials
+	LoginFailed CooldownType = "LOGIN_FAILED"
+	// LoginLocked means that authentication is blocked after too many failed attempts
+	LoginLocked CooldownType = "LOGIN_LOCKED"
 )
 
 // Cooldown holds information about the usage of the system
@@ -0,0 +1,25 @@
+package cooldowns_services
+
+import (
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DeleteCooldownsService is a service that removes all cooldowns of a type from a database
+type DeleteCooldownsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+func (s DeleteCooldownsService) Execute(profileID string, profileType cooldowns_models.CooldownProfileType, cooldownType cooldowns_models.CooldownType) error {
+	result := s.OrmUtil.Db().Where(
+		"profile_id = ? AND profile_type = ? AND cooldown_type = ?",
+		profileID,
+		profileType,
+		cooldownType,
+	).Delete(&cooldowns_models.Cooldown{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+}
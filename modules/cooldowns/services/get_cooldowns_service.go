@@ -0,0 +1,30 @@
+package cooldowns_services
+
+import (
+	"time"
+
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetCooldownsService is a service that retrieves all valid cooldowns of a type in a database, oldest first
+type GetCooldownsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+func (s GetCooldownsService) Execute(profileID string, profileType cooldowns_models.CooldownProfileType, cooldownType cooldowns_models.CooldownType) ([]*cooldowns_models.Cooldown, error) {
+	cooldowns := []*cooldowns_models.Cooldown{}
+
+	result := s.OrmUtil.Db().Where(
+		"profile_id = ? AND profile_type = ? AND cooldown_type = ? AND valid_until > ?",
+		profileID,
+		profileType,
+		cooldownType,
+		time.Now(),
+	).Order("created_at ASC").Find(&cooldowns)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return cooldowns, nil
+}
@@ -15,6 +15,8 @@ type SaveCooldownService struct {
 	OrmUtil                            orm.IOrmUtil
 	InterruptTreatmentCooldownDuration time.Duration
 	TopAffinitiesCooldownDuration      time.Duration
+	LoginFailedCooldownDuration        time.Duration
+	LoginLockedCooldownDuration        time.Duration
 }
 
 func (s SaveCooldownService) Execute(profileID string, profileType cooldowns_models.CooldownProfileType, cooldownType cooldowns_models.CooldownType) error {
@@ -29,6 +31,10 @@ func (s SaveCooldownService) Execute(profileID string, profileType cooldowns_mod
 		duration = s.InterruptTreatmentCooldownDuration
 	case cooldowns_models.TopAffinitiesSet:
 		duration = s.TopAffinitiesCooldownDuration
+	case cooldowns_models.LoginFailed:
+		duration = s.LoginFailedCooldownDuration
+	case cooldowns_models.LoginLocked:
+		duration = s.LoginLockedCooldownDuration
 	default:
 		return errors.New("cooldownType does not have a duration")
 	}
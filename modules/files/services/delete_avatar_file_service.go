@@ -0,0 +1,42 @@
+package files_services
+
+import (
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	"github.com/guicostaarantes/psi-server/utils/file_storage"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DeleteAvatarFileService is a service that removes an avatar file from the storage if no profile is using it anymore
+type DeleteAvatarFileService struct {
+	FileStorageUtil file_storage.IFileStorageUtil
+	OrmUtil         orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s DeleteAvatarFileService) Execute(fileName string) error {
+
+	if fileName == "" {
+		return nil
+	}
+
+	var patientsUsingFile int64
+
+	result := s.OrmUtil.Db().Model(&profiles_models.Patient{}).Where("avatar = ?", fileName).Count(&patientsUsingFile)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	var psychologistsUsingFile int64
+
+	result = s.OrmUtil.Db().Model(&profiles_models.Psychologist{}).Where("avatar = ?", fileName).Count(&psychologistsUsingFile)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if patientsUsingFile+psychologistsUsingFile > 0 {
+		return nil
+	}
+
+	return s.FileStorageUtil.DeleteFile(fileName)
+
+}
@@ -9,7 +9,8 @@ import (
 	"github.com/guicostaarantes/psi-server/utils/orm"
 )
 
-// ProcessPendingMailsService is a service that checks the mails table and processes pending messages
+// ProcessPendingMailsService is a service that checks the mails table and processes pending messages. The content of
+// messages is erased once they are sent, since it may hold tokens that give access to accounts
 type ProcessPendingMailsService struct {
 	MailUtil mail.IMailUtil
 	OrmUtil  orm.IOrmUtil
@@ -42,6 +43,7 @@ func (s ProcessPendingMailsService) Execute() error {
 
 			if sendErr == nil {
 				msg.Processed = true
+				msg.Html = ""
 				s.OrmUtil.Db().Save(msg)
 			}
 
@@ -52,5 +54,11 @@ func (s ProcessPendingMailsService) Execute() error {
 
 	wg.Wait()
 
+	// messages sent before their content started to be erased are cleaned too
+	result := s.OrmUtil.Db().Model(&mails_models.TransientMailMessage{}).Where("processed = ? AND html <> ?", true, "").Update("html", "")
+	if result.Error != nil {
+		return result.Error
+	}
+
 	return nil
 }
@@ -18,4 +18,5 @@ type Patient struct {
 	BirthDate time.Time      `json:"birthDate"`
 	City      string         `json:"city"`
 	Avatar    string         `json:"avatar"`
+	TimeZone  string         `json:"timeZone" gorm:"default:UTC"`
 }
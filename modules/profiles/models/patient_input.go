@@ -14,4 +14,5 @@ type UpsertPatientInput struct {
 	BirthDate time.Time       `json:"birthDate"`
 	City      string          `json:"city"`
 	Avatar    *graphql.Upload `json:"avatar"`
+	TimeZone  string          `json:"timeZone"`
 }
@@ -6,20 +6,41 @@ import (
 	"gorm.io/gorm"
 )
 
-// Psychologist is the schema for the profile of a psychologist
+// VerificationStatus represents the progress of the verification of the CRP informed by a psychologist
+type VerificationStatus string
+
+const (
+	// Unverified means that the psychologist did not inform a CRP yet
+	Unverified VerificationStatus = "UNVERIFIED"
+	// PendingVerification means that the CRP was informed and waits for a coordinator to review it
+	PendingVerification VerificationStatus = "PENDING"
+	// Verified means that a coordinator confirmed the CRP, so the psychologist can be matched with patients
+	Verified VerificationStatus = "VERIFIED"
+	// Rejected means that a coordinator could not confirm the CRP
+	Rejected VerificationStatus = "REJECTED"
+)
+
+// Psychologist is the schema for the profile of a psychologist. Profiles created before the verification of CRPs existed
+// are left pending, so that they go through the review too
 type Psychologist struct {
-	ID        string         `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time      `json:"createdAt`
-	UpdatedAt time.Time      `json:"updatedAt`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
-	UserID    string         `json:"userId" gorm:"index"`
-	FullName  string         `json:"fullName"`
-	LikeName  string         `json:"likeName"`
-	BirthDate time.Time      `json:"birthDate"`
-	City      string         `json:"city"`
-	Crp       string         `json:"crp"`
-	Whatsapp  string         `json:"whatsapp"`
-	Instagram string         `json:"instagram"`
-	Bio       string         `json:"bio"`
-	Avatar    string         `json:"avatar"`
+	ID                     string             `json:"id" gorm:"primaryKey"`
+	CreatedAt              time.Time          `json:"createdAt`
+	UpdatedAt              time.Time          `json:"updatedAt`
+	DeletedAt              gorm.DeletedAt     `gorm:"index"`
+	UserID                 string             `json:"userId" gorm:"index"`
+	FullName               string             `json:"fullName"`
+	LikeName               string             `json:"likeName"`
+	BirthDate              time.Time          `json:"birthDate"`
+	City                   string             `json:"city"`
+	Crp                    string             `json:"crp"`
+	Whatsapp               string             `json:"whatsapp"`
+	Instagram              string             `json:"instagram"`
+	Bio                    string             `json:"bio"`
+	Avatar                 string             `json:"avatar"`
+	TimeZone               string             `json:"timeZone" gorm:"default:UTC"`
+	VerificationStatus     VerificationStatus `json:"verificationStatus" gorm:"index;default:PENDING"`
+	VerificationAskedAt    *time.Time         `json:"verificationAskedAt"`
+	VerificationReason     string             `json:"verificationReason"`
+	VerificationReviewedBy string             `json:"verificationReviewedBy"`
+	VerificationReviewedAt *time.Time         `json:"verificationReviewedAt"`
 }
@@ -18,4 +18,13 @@ type UpsertPsychologistInput struct {
 	Instagram string          `json:"instagram"`
 	Bio       string          `json:"bio"`
 	Avatar    *graphql.Upload `json:"avatar"`
+	TimeZone  string          `json:"timeZone"`
+}
+
+// ReviewPsychologistVerificationInput is the schema for information needed to approve or reject the CRP of a psychologist
+type ReviewPsychologistVerificationInput struct {
+	ID         string             `json:"id"`
+	ReviewerID string             `json:"reviewerId"`
+	Status     VerificationStatus `json:"status"`
+	Reason     string             `json:"reason"`
 }
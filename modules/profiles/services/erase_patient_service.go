@@ -0,0 +1,102 @@
+package services
+
+import (
+	"time"
+
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	characteristics_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	files_services "github.com/guicostaarantes/psi-server/modules/files/services"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	treatments_services "github.com/guicostaarantes/psi-server/modules/treatments/services"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ErasePatientService is a service that anonymizes the patient profile of a user and removes its personal data,
+// interrupting its active treatment. Treatments, appointments and agreements are kept without personal data
+type ErasePatientService struct {
+	OrmUtil                            orm.IOrmUtil
+	DeleteAvatarFileService            *files_services.DeleteAvatarFileService
+	InterruptTreatmentByPatientService *treatments_services.InterruptTreatmentByPatientService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ErasePatientService) Execute(userID string) error {
+
+	patient := profiles_models.Patient{}
+
+	result := s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&patient)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if patient.ID == "" {
+		return nil
+	}
+
+	activeTreatments := []*treatments_models.Treatment{}
+
+	result = s.OrmUtil.Db().Where("patient_id = ? AND status = ?", patient.ID, treatments_models.Active).Find(&activeTreatments)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, treatment := range activeTreatments {
+		interruptErr := s.InterruptTreatmentByPatientService.Execute(treatment.ID, patient.ID, "")
+		if interruptErr != nil {
+			return interruptErr
+		}
+	}
+
+	result = s.OrmUtil.Db().Model(&treatments_models.Treatment{}).Where("patient_id = ?", patient.ID).Update("reason", "")
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Model(&appointments_models.Appointment{}).Where("patient_id = ?", patient.ID).Update("reason", "")
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", patient.ID).Delete(&characteristics_models.CharacteristicChoice{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", patient.ID).Delete(&characteristics_models.Preference{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("patient_id = ?", patient.ID).Delete(&characteristics_models.Affinity{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", patient.ID).Delete(&cooldowns_models.Cooldown{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	avatar := patient.Avatar
+
+	patient.FullName = ""
+	patient.LikeName = ""
+	patient.BirthDate = time.Time{}
+	patient.City = ""
+	patient.Avatar = ""
+
+	result = s.OrmUtil.Db().Save(&patient)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Delete(&patient)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return s.DeleteAvatarFileService.Execute(avatar)
+
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"time"
+
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	characteristics_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	files_services "github.com/guicostaarantes/psi-server/modules/files/services"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	treatments_services "github.com/guicostaarantes/psi-server/modules/treatments/services"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ErasePsychologistService is a service that anonymizes the psychologist profile of a user and removes its personal data,
+// interrupting its active treatments and deleting its pending ones. Treatments, appointments and agreements are kept without personal data
+type ErasePsychologistService struct {
+	OrmUtil                                 orm.IOrmUtil
+	DeleteAvatarFileService                 *files_services.DeleteAvatarFileService
+	InterruptTreatmentByPsychologistService *treatments_services.InterruptTreatmentByPsychologistService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ErasePsychologistService) Execute(userID string) error {
+
+	psychologist := profiles_models.Psychologist{}
+
+	result := s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&psychologist)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if psychologist.ID == "" {
+		return nil
+	}
+
+	activeTreatments := []*treatments_models.Treatment{}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ? AND status = ?", psychologist.ID, treatments_models.Active).Find(&activeTreatments)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, treatment := range activeTreatments {
+		interruptErr := s.InterruptTreatmentByPsychologistService.Execute(treatment.ID, psychologist.ID, "")
+		if interruptErr != nil {
+			return interruptErr
+		}
+	}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ? AND status = ?", psychologist.ID, treatments_models.Pending).Delete(&treatments_models.Treatment{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ?", psychologist.ID).Delete(&treatments_models.TreatmentPriceRangeOffering{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Model(&treatments_models.Treatment{}).Where("psychologist_id = ?", psychologist.ID).Update("reason", "")
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Model(&appointments_models.Appointment{}).Where("psychologist_id = ?", psychologist.ID).Update("reason", "")
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ?", psychologist.ID).Delete(&appointments_models.Absence{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", psychologist.ID).Delete(&characteristics_models.CharacteristicChoice{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", psychologist.ID).Delete(&characteristics_models.Preference{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ?", psychologist.ID).Delete(&characteristics_models.Affinity{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", psychologist.ID).Delete(&cooldowns_models.Cooldown{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	avatar := psychologist.Avatar
+
+	psychologist.FullName = ""
+	psychologist.LikeName = ""
+	psychologist.BirthDate = time.Time{}
+	psychologist.City = ""
+	psychologist.Crp = ""
+	psychologist.Whatsapp = ""
+	psychologist.Instagram = ""
+	psychologist.Bio = ""
+	psychologist.Avatar = ""
+
+	result = s.OrmUtil.Db().Save(&psychologist)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Delete(&psychologist)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return s.DeleteAvatarFileService.Execute(avatar)
+
+}
@@ -0,0 +1,26 @@
+package services
+
+import (
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetPendingVerificationsService is a service that gets the psychologist profiles whose CRP waits for a review,
+// from the one waiting for the longest time
+type GetPendingVerificationsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetPendingVerificationsService) Execute() ([]*profiles_models.Psychologist, error) {
+
+	psychologists := []*profiles_models.Psychologist{}
+
+	result := s.OrmUtil.Db().Where("verification_status = ?", profiles_models.PendingVerification).Order("COALESCE(verification_asked_at, created_at) ASC").Find(&psychologists)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return psychologists, nil
+
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetVerifiedPsychologistService is a service that gets the psychologist profile based on id, as long as a coordinator
+// has verified their CRP. Profiles that were not verified yet are reviewed through GetPendingVerificationsService
+type GetVerifiedPsychologistService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetVerifiedPsychologistService) Execute(id string) (*profiles_models.Psychologist, error) {
+
+	psy := &profiles_models.Psychologist{}
+
+	result := s.OrmUtil.Db().Where("id = ? AND verification_status = ?", id, profiles_models.Verified).Limit(1).Find(&psy)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if psy.ID == "" {
+		return nil, nil
+	}
+
+	return psy, nil
+
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ReviewPsychologistVerificationService is a service that approves or rejects the CRP informed by a psychologist
+type ReviewPsychologistVerificationService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ReviewPsychologistVerificationService) Execute(input *profiles_models.ReviewPsychologistVerificationInput) error {
+
+	if input.Status != profiles_models.Verified && input.Status != profiles_models.Rejected {
+		return errors.New("invalid verification status")
+	}
+
+	if strings.TrimSpace(input.Reason) == "" {
+		return errors.New("reason is required")
+	}
+
+	psy := profiles_models.Psychologist{}
+
+	result := s.OrmUtil.Db().Where("id = ?", input.ID).Limit(1).Find(&psy)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if psy.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	if psy.VerificationStatus != profiles_models.PendingVerification {
+		return errors.New("psychologist is not pending verification")
+	}
+
+	now := time.Now()
+
+	psy.VerificationStatus = input.Status
+	psy.VerificationReason = strings.TrimSpace(input.Reason)
+	psy.VerificationReviewedBy = input.ReviewerID
+	psy.VerificationReviewedAt = &now
+
+	result = s.OrmUtil.Db().Save(&psy)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
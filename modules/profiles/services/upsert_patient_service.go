@@ -19,6 +19,11 @@ type UpsertPatientService struct {
 // Execute is the method that runs the business logic of the service
 func (s UpsertPatientService) Execute(userID string, input *profiles_models.UpsertPatientInput) error {
 
+	timeZoneErr := validateTimeZone(input.TimeZone)
+	if timeZoneErr != nil {
+		return timeZoneErr
+	}
+
 	existingPatient := profiles_models.Patient{}
 
 	result := s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&existingPatient)
@@ -32,6 +37,10 @@ func (s UpsertPatientService) Execute(userID string, input *profiles_models.Upse
 		existingPatient.BirthDate = input.BirthDate
 		existingPatient.City = input.City
 
+		if input.TimeZone != "" {
+			existingPatient.TimeZone = input.TimeZone
+		}
+
 		if input.Avatar != nil {
 			data, readErr := io.ReadAll(input.Avatar.File)
 			if readErr != nil {
@@ -82,6 +91,7 @@ func (s UpsertPatientService) Execute(userID string, input *profiles_models.Upse
 		BirthDate: input.BirthDate,
 		City:      input.City,
 		Avatar:    avatar,
+		TimeZone:  input.TimeZone,
 	}
 
 	result = s.OrmUtil.Db().Create(&newPatient)
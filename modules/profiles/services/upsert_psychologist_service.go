@@ -2,23 +2,32 @@ package services
 
 import (
 	"io"
+	"strings"
+	"time"
 
 	files_services "github.com/guicostaarantes/psi-server/modules/files/services"
 	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_services "github.com/guicostaarantes/psi-server/modules/treatments/services"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/orm"
 )
 
 // UpsertPsychologistService is a service that creates a psychologist profile
 type UpsertPsychologistService struct {
-	IdentifierUtil          identifier.IIdentifierUtil
-	OrmUtil                 orm.IOrmUtil
-	UploadAvatarFileService *files_services.UploadAvatarFileService
+	IdentifierUtil                  identifier.IIdentifierUtil
+	OrmUtil                         orm.IOrmUtil
+	ChangeTreatmentsTimeZoneService *treatments_services.ChangeTreatmentsTimeZoneService
+	UploadAvatarFileService         *files_services.UploadAvatarFileService
 }
 
 // Execute is the method that runs the business logic of the service
 func (s UpsertPsychologistService) Execute(userID string, input *profiles_models.UpsertPsychologistInput) error {
 
+	timeZoneErr := validateTimeZone(input.TimeZone)
+	if timeZoneErr != nil {
+		return timeZoneErr
+	}
+
 	existingPsy := profiles_models.Psychologist{}
 
 	result := s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&existingPsy)
@@ -27,6 +36,15 @@ func (s UpsertPsychologistService) Execute(userID string, input *profiles_models
 	}
 
 	if existingPsy.ID != "" {
+		// Changing the CRP discards the previous review, so the new one needs to be verified again
+		if existingPsy.Crp != input.Crp {
+			existingPsy.VerificationStatus = s.initialVerificationStatus(input.Crp)
+			existingPsy.VerificationAskedAt = s.verificationAskedAt(input.Crp)
+			existingPsy.VerificationReason = ""
+			existingPsy.VerificationReviewedBy = ""
+			existingPsy.VerificationReviewedAt = nil
+		}
+
 		existingPsy.FullName = input.FullName
 		existingPsy.LikeName = input.LikeName
 		existingPsy.BirthDate = input.BirthDate
@@ -49,6 +67,15 @@ func (s UpsertPsychologistService) Execute(userID string, input *profiles_models
 			existingPsy.Avatar = fileName
 		}
 
+		// Phases of treatments are counted in the time zone of the psychologist, so they are moved together with it
+		if input.TimeZone != "" && input.TimeZone != existingPsy.TimeZone {
+			changeErr := s.ChangeTreatmentsTimeZoneService.Execute(existingPsy.ID, input.TimeZone)
+			if changeErr != nil {
+				return changeErr
+			}
+			existingPsy.TimeZone = input.TimeZone
+		}
+
 		result = s.OrmUtil.Db().Save(&existingPsy)
 		if result.Error != nil {
 			return result.Error
@@ -78,17 +105,20 @@ func (s UpsertPsychologistService) Execute(userID string, input *profiles_models
 	}
 
 	newPsy := profiles_models.Psychologist{
-		ID:        psyID,
-		UserID:    userID,
-		FullName:  input.FullName,
-		LikeName:  input.LikeName,
-		BirthDate: input.BirthDate,
-		City:      input.City,
-		Crp:       input.Crp,
-		Whatsapp:  input.Whatsapp,
-		Instagram: input.Instagram,
-		Bio:       input.Bio,
-		Avatar:    avatar,
+		ID:                  psyID,
+		UserID:              userID,
+		FullName:            input.FullName,
+		LikeName:            input.LikeName,
+		BirthDate:           input.BirthDate,
+		City:                input.City,
+		Crp:                 input.Crp,
+		Whatsapp:            input.Whatsapp,
+		Instagram:           input.Instagram,
+		Bio:                 input.Bio,
+		Avatar:              avatar,
+		TimeZone:            input.TimeZone,
+		VerificationStatus:  s.initialVerificationStatus(input.Crp),
+		VerificationAskedAt: s.verificationAskedAt(input.Crp),
 	}
 
 	result = s.OrmUtil.Db().Create(&newPsy)
@@ -99,3 +129,25 @@ func (s UpsertPsychologistService) Execute(userID string, input *profiles_models
 	return nil
 
 }
+
+func (s UpsertPsychologistService) initialVerificationStatus(crp string) profiles_models.VerificationStatus {
+
+	if strings.TrimSpace(crp) == "" {
+		return profiles_models.Unverified
+	}
+
+	return profiles_models.PendingVerification
+
+}
+
+func (s UpsertPsychologistService) verificationAskedAt(crp string) *time.Time {
+
+	if strings.TrimSpace(crp) == "" {
+		return nil
+	}
+
+	now := time.Now()
+
+	return &now
+
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+// validateTimeZone checks if a time zone is known by its IANA name, such as America/Sao_Paulo. An empty time zone is
+// accepted, meaning that the current one must be kept
+func validateTimeZone(timeZone string) error {
+
+	if timeZone == "" {
+		return nil
+	}
+
+	// time.LoadLocation would resolve Local to the time zone of the server, which is not meaningful for a profile
+	if timeZone == "Local" {
+		return errors.New("invalid time zone")
+	}
+
+	_, loadErr := time.LoadLocation(timeZone)
+	if loadErr != nil {
+		return errors.New("invalid time zone")
+	}
+
+	return nil
+
+}
@@ -0,0 +1,41 @@
+package treatments_models
+
+// Weekday represents a day of the week
+type Weekday string
+
+const (
+	// Sunday is the first day of the week
+	Sunday Weekday = "SUNDAY"
+	// Monday is the second day of the week
+	Monday Weekday = "MONDAY"
+	// Tuesday is the third day of the week
+	Tuesday Weekday = "TUESDAY"
+	// Wednesday is the fourth day of the week
+	Wednesday Weekday = "WEDNESDAY"
+	// Thursday is the fifth day of the week
+	Thursday Weekday = "THURSDAY"
+	// Friday is the sixth day of the week
+	Friday Weekday = "FRIDAY"
+	// Saturday is the seventh day of the week
+	Saturday Weekday = "SATURDAY"
+)
+
+// Weekdays lists the days of the week in the same order as time.Weekday
+var Weekdays = []Weekday{Sunday, Monday, Tuesday, Wednesday, Thursday, Friday, Saturday}
+
+// AvailabilityWindow is the schema for a recurring period of the week in which a psychologist accepts treatment sessions.
+// Times are in the HH:MM format, in the time zone of the psychologist, and the end of the day can be informed as 24:00
+type AvailabilityWindow struct {
+	ID             string  `json:"id" gorm:"primaryKey"`
+	PsychologistID string  `json:"psychologistId" gorm:"index"`
+	Weekday        Weekday `json:"weekday"`
+	StartTime      string  `json:"startTime"`
+	EndTime        string  `json:"endTime"`
+}
+
+// SetAvailabilityWindowInput is the schema for information needed to declare a period of availability of a psychologist
+type SetAvailabilityWindowInput struct {
+	Weekday   Weekday `json:"weekday"`
+	StartTime string  `json:"startTime"`
+	EndTime   string  `json:"endTime"`
+}
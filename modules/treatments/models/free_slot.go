@@ -0,0 +1,9 @@
+package treatments_models
+
+// FreeSlot is the schema for a moment in which a psychologist could start the sessions of a new treatment without colliding
+// with their other treatments, in the same form used to create a treatment
+type FreeSlot struct {
+	Week      int64   `json:"week"`
+	Weekday   Weekday `json:"weekday"`
+	StartTime string  `json:"startTime"`
+}
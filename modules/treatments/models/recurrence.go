@@ -0,0 +1,35 @@
+package treatments_models
+
+import "time"
+
+// RecurrenceFrequency represents the unit in which the sessions of a treatment repeat themselves
+type RecurrenceFrequency string
+
+const (
+	// Weekly means that sessions happen on some weekdays every given number of weeks
+	Weekly RecurrenceFrequency = "WEEKLY"
+	// Monthly means that sessions happen on a weekday in a given position of the month, such as the second tuesday,
+	// every given number of months
+	Monthly RecurrenceFrequency = "MONTHLY"
+)
+
+// RecurrenceRule is the schema for the rule that defines when the sessions of a treatment happen. It is a subset of the
+// RRULE of iCalendar (RFC 5545), such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10 or FREQ=MONTHLY;BYDAY=2TU;UNTIL=20271231.
+// Position is only used in monthly rules, where -1 means the last weekday of the month. Count limits the number of
+// sessions since the treatment was assigned, and Until is the last day in which sessions can happen
+type RecurrenceRule struct {
+	Frequency RecurrenceFrequency
+	Interval  int64
+	Weekdays  []Weekday
+	Position  int64
+	Count     int64
+	Until     *time.Time
+}
+
+// TreatmentOccurrence is the schema for a session of a treatment calculated from its recurrence rule. Index counts the
+// sessions since the treatment was assigned, starting at zero
+type TreatmentOccurrence struct {
+	Index int64
+	Start time.Time
+	End   time.Time
+}
@@ -23,7 +23,11 @@ const (
 )
 
 // Treatment represents the intention from a psychologist to treat a patient, defining the sessions' duration, price, interval and phase.
-// The next session of a specific treatment will be scheduled to the UNIX timestamp T, where T = (ScheduleIntervalDuration * Frequency * N) + Phase, and N is the smallest natural number that makes T superior to the current timestamp.
+// The next session of a specific treatment will be scheduled to the wall clock time T in the time zone of the psychologist, where T = (ScheduleIntervalDuration * Frequency * N) + Phase,
+// T is counted in seconds as if the time zone was UTC, and N is the smallest natural number that makes T superior to the current wall clock time.
+// Since the wall clock is used instead of the UNIX timestamp, sessions keep their local time when daylight saving time starts or ends.
+// Recurrence optionally holds a rule in the RRULE format (see RecurrenceRule) whose interval is the Frequency and whose sessions start at the
+// time of the day of the Phase. Weekly rules can have sessions in more than one weekday, and in monthly rules the week of the Phase chooses the month of the cycle instead.
 type Treatment struct {
 	ID             string          `json:"id" gorm:"primaryKey"`
 	CreatedAt      time.Time       `json:"createdAt`
@@ -33,6 +37,7 @@ type Treatment struct {
 	PatientID      string          `json:"patientId"`
 	Frequency      int64           `json:"frequency"`
 	Phase          int64           `json:"phase"`
+	Recurrence     string          `json:"recurrence"`
 	Duration       int64           `json:"duration"`
 	PriceRangeName string          `json:"priceRangeName"`
 	Status         TreatmentStatus `json:"status"`
@@ -0,0 +1,20 @@
+package treatments_models
+
+// TreatmentCollisionError is the error returned when the sessions of a treatment would happen in the same period as the
+// sessions of another treatment of the same psychologist. Occurrence is the index of the first colliding session of the
+// other treatment, counted from the start of the cycle formed by the frequencies of both treatments, and Week, Weekday and
+// StartTime describe when that session starts within the cycle. When the sessions of one of the treatments do not repeat
+// themselves every few weeks, such as in monthly rules or rules with an end, Occurrence counts the sessions since the
+// treatment was assigned and Date is the day of the colliding session in the YYYY-MM-DD format
+type TreatmentCollisionError struct {
+	TreatmentID string
+	Occurrence  int64
+	Week        int64
+	Weekday     Weekday
+	StartTime   string
+	Date        string
+}
+
+func (e *TreatmentCollisionError) Error() string {
+	return "there is another treatment in the same period"
+}
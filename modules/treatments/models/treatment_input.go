@@ -1,17 +1,26 @@
 package treatments_models
 
-// CreateTreatmentInput is the schema for information needed to create a new treatment
+// CreateTreatmentInput is the schema for information needed to create a new treatment.
+// Sessions start at StartTime (HH:MM) on Weekday in the time zone of the psychologist. Week chooses in which week of the
+// cycle they happen when Frequency is bigger than one, from 0 to Frequency - 1. Recurrence is an optional rule in the RRULE format,
+// such as FREQ=WEEKLY;BYDAY=MO,TH or FREQ=MONTHLY;BYDAY=2TU;COUNT=6, and Weekday must be one of its weekdays
 type CreateTreatmentInput struct {
-	Frequency      int64  `json:"frequency"`
-	Phase          int64  `json:"phase"`
-	Duration       int64  `json:"duration"`
-	PriceRangeName string `json:"priceRangeName"`
+	Frequency      int64   `json:"frequency"`
+	Week           int64   `json:"week"`
+	Weekday        Weekday `json:"weekday"`
+	StartTime      string  `json:"startTime"`
+	Recurrence     string  `json:"recurrence"`
+	Duration       int64   `json:"duration"`
+	PriceRangeName string  `json:"priceRangeName"`
 }
 
 // UpdateTreatmentInput is the schema for information needed to update a treatment
 type UpdateTreatmentInput struct {
-	Frequency      int64  `json:"frequency"`
-	Phase          int64  `json:"phase"`
-	Duration       int64  `json:"duration"`
-	PriceRangeName string `json:"priceRangeName"`
+	Frequency      int64   `json:"frequency"`
+	Week           int64   `json:"week"`
+	Weekday        Weekday `json:"weekday"`
+	StartTime      string  `json:"startTime"`
+	Recurrence     string  `json:"recurrence"`
+	Duration       int64   `json:"duration"`
+	PriceRangeName string  `json:"priceRangeName"`
 }
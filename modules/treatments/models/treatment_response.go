@@ -6,6 +6,10 @@ type GetPsychologistTreatmentsResponse struct {
 	PatientID      string          `json:"patientId"`
 	Frequency      int64           `json:"frequency"`
 	Phase          int64           `json:"phase"`
+	Week           int64           `json:"week" gorm:"-"`
+	Weekday        Weekday         `json:"weekday" gorm:"-"`
+	StartTime      string          `json:"startTime" gorm:"-"`
+	Recurrence     string          `json:"recurrence"`
 	Duration       int64           `json:"duration"`
 	PriceRangeName string          `json:"priceRangeName"`
 	Status         TreatmentStatus `json:"status"`
@@ -17,6 +21,10 @@ type GetPatientTreatmentsResponse struct {
 	PsychologistID string          `json:"psychologistId"`
 	Frequency      int64           `json:"frequency"`
 	Phase          int64           `json:"phase"`
+	Week           int64           `json:"week" gorm:"-"`
+	Weekday        Weekday         `json:"weekday" gorm:"-"`
+	StartTime      string          `json:"startTime" gorm:"-"`
+	Recurrence     string          `json:"recurrence"`
 	Duration       int64           `json:"duration"`
 	PriceRangeName string          `json:"priceRangeName"`
 	Status         TreatmentStatus `json:"status"`
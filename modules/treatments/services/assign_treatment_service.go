@@ -9,6 +9,7 @@ import (
 	characteristic_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
 	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
 	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
 	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
 	"github.com/guicostaarantes/psi-server/utils/orm"
 )
@@ -57,6 +58,17 @@ func (s AssignTreatmentService) Execute(id string, priceRangeName string, patien
 		return fmt.Errorf("treatments can only be assigned if their current status is PENDING. current status is %s", string(treatment.Status))
 	}
 
+	psychologist := profiles_models.Psychologist{}
+
+	result = s.OrmUtil.Db().Where("id = ?", treatment.PsychologistID).Limit(1).Find(&psychologist)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if psychologist.VerificationStatus != profiles_models.Verified {
+		return errors.New("psychologist is not verified")
+	}
+
 	treatmentPriceRangeOffering := treatments_models.TreatmentPriceRangeOffering{}
 
 	result = s.OrmUtil.Db().Where("psychologist_id = ? AND price_range_name = ?", treatment.PsychologistID, priceRangeName).Limit(1).Find(&treatmentPriceRangeOffering)
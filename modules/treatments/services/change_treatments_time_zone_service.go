@@ -0,0 +1,187 @@
+package treatments_services
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"text/template"
+	"time"
+
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	treatments_templates "github.com/guicostaarantes/psi-server/modules/treatments/templates"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"gorm.io/gorm"
+)
+
+// ChangeTreatmentsTimeZoneService is a service that changes the time zone of a psychologist. Phases are counted in the
+// time zone of the psychologist, so the phases of their treatments are moved by the difference between the offsets of
+// both time zones, keeping their sessions at the same moment. The moved treatments need to fit in the availability of
+// the psychologist and not collide with each other, and the patients of the active ones are told about the change
+type ChangeTreatmentsTimeZoneService struct {
+	IdentifierUtil                    identifier.IIdentifierUtil
+	OrmUtil                           orm.IOrmUtil
+	CheckTreatmentAvailabilityService *CheckTreatmentAvailabilityService
+	ScheduleIntervalDuration          time.Duration
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ChangeTreatmentsTimeZoneService) Execute(psychologistID string, timeZone string) error {
+
+	psychologist := profiles_models.Psychologist{}
+
+	result := s.OrmUtil.Db().Where("id = ?", psychologistID).Limit(1).Find(&psychologist)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if psychologist.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	if psychologist.TimeZone == timeZone {
+		return nil
+	}
+
+	oldLocation, locationErr := time.LoadLocation(psychologist.TimeZone)
+	if locationErr != nil {
+		return locationErr
+	}
+
+	newLocation, locationErr := time.LoadLocation(timeZone)
+	if locationErr != nil {
+		return locationErr
+	}
+
+	now := time.Now()
+	_, oldOffset := now.In(oldLocation).Zone()
+	_, newOffset := now.In(newLocation).Zone()
+	shift := int64(newOffset - oldOffset)
+
+	interval := int64(s.ScheduleIntervalDuration / time.Second)
+
+	psychologistTreatments := []*treatments_models.Treatment{}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ? AND status IN ?", psychologistID, []treatments_models.TreatmentStatus{treatments_models.Pending, treatments_models.Active}).Order("created_at ASC").Find(&psychologistTreatments)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	movedTreatments := []*treatments_models.Treatment{}
+
+	for _, treatment := range psychologistTreatments {
+		phase, phaseErr := shiftTreatmentPhase(treatment, shift, interval)
+		if phaseErr != nil {
+			return phaseErr
+		}
+
+		if phase != treatment.Phase {
+			treatment.Phase = phase
+			movedTreatments = append(movedTreatments, treatment)
+		}
+	}
+
+	rules, rulesErr := treatmentRecurrenceRules(psychologistTreatments)
+	if rulesErr != nil {
+		return rulesErr
+	}
+
+	// availability windows are kept in the wall clock, so the moved sessions may not fit in them anymore
+	for index, treatment := range psychologistTreatments {
+		availabilityErr := s.CheckTreatmentAvailabilityService.Execute(psychologistID, treatment.Frequency, treatment.Phase, treatment.Duration, treatment.Recurrence)
+		if availabilityErr != nil {
+			return availabilityErr
+		}
+
+		for otherIndex := index + 1; otherIndex < len(psychologistTreatments); otherIndex++ {
+			collision, collisionErr := findCollision(treatment, rules[index], psychologistTreatments[otherIndex], rules[otherIndex], interval, now, newLocation)
+			if collisionErr != nil {
+				return collisionErr
+			}
+
+			if collision != nil {
+				return collision
+			}
+		}
+	}
+
+	mails := []*mails_models.TransientMailMessage{}
+
+	for _, treatment := range movedTreatments {
+		if treatment.Status != treatments_models.Active {
+			continue
+		}
+
+		patient := profiles_models.Patient{}
+		patientUser := users_models.User{}
+
+		result = s.OrmUtil.Db().Where("id = ?", treatment.PatientID).Limit(1).Find(&patient)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = s.OrmUtil.Db().Where("id = ?", patient.UserID).Limit(1).Find(&patientUser)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
+		if mailIDErr != nil {
+			return mailIDErr
+		}
+
+		templ, templErr := template.New("TreatmentTimeZoneChangedEmail").Parse(treatments_templates.TreatmentTimeZoneChangedEmailTemplate)
+		if templErr != nil {
+			return templErr
+		}
+
+		buff := new(bytes.Buffer)
+
+		templ.Execute(buff, map[string]string{
+			"SiteURL":     os.Getenv("PSI_SITE_URL"),
+			"LikeName":    patient.LikeName,
+			"PsyFullName": psychologist.FullName,
+		})
+
+		mails = append(mails, &mails_models.TransientMailMessage{
+			ID:          mailID,
+			FromAddress: "relacionamento@psi.com.br",
+			FromName:    "Relacionamento PSI",
+			To:          patientUser.Email,
+			Cc:          "",
+			Cco:         "",
+			Subject:     "Fuso horário do tratamento modificado no PSI",
+			Html:        buff.String(),
+			Processed:   false,
+		})
+	}
+
+	return s.OrmUtil.Db().Transaction(func(tx *gorm.DB) error {
+
+		for _, treatment := range movedTreatments {
+			result := tx.Model(treatment).Update("phase", treatment.Phase)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		for _, mail := range mails {
+			result := tx.Create(mail)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		result := tx.Model(&psychologist).Update("time_zone", timeZone)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return nil
+
+	})
+
+}
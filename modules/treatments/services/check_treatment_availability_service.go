@@ -0,0 +1,140 @@
+package treatments_services
+
+import (
+	"errors"
+	"sort"
+
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// CheckTreatmentAvailabilityService is a service that checks if a treatment period fits in the availability declared by the psychologist.
+// Psychologists that did not declare any availability accept treatments in any period
+type CheckTreatmentAvailabilityService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s CheckTreatmentAvailabilityService) Execute(psychologistID string, frequency int64, phase int64, duration int64, recurrence string) error {
+
+	rule, ruleErr := treatmentRecurrenceRule(recurrence, frequency, phase)
+	if ruleErr != nil {
+		return ruleErr
+	}
+
+	windows := []*treatments_models.AvailabilityWindow{}
+
+	result := s.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Find(&windows)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if len(windows) == 0 {
+		return nil
+	}
+
+	intervals, intervalsErr := availabilityIntervals(windows)
+	if intervalsErr != nil {
+		return intervalsErr
+	}
+
+	for _, occurrencePhase := range occurrencePhases(rule, phase) {
+		if !fitsInAvailability(intervals, occurrencePhase, duration) {
+			return errors.New("treatment is outside the availability of the psychologist")
+		}
+	}
+
+	return nil
+
+}
+
+// availabilityIntervals converts windows to sorted intervals of seconds elapsed since the start of the week. Each window is
+// repeated in the following week, so that treatments that cross the end of the week can fit in, and contiguous windows,
+// such as one that ends at 24:00 and another that starts at 00:00 in the next day, are merged
+func availabilityIntervals(windows []*treatments_models.AvailabilityWindow) ([][]int64, error) {
+
+	intervals := [][]int64{}
+
+	for _, window := range windows {
+		start, end, intervalErr := windowInterval(window.Weekday, window.StartTime, window.EndTime)
+		if intervalErr != nil {
+			return nil, intervalErr
+		}
+
+		intervals = append(intervals, []int64{start, end}, []int64{start + secondsInWeek, end + secondsInWeek})
+	}
+
+	return mergeIntervals(intervals), nil
+
+}
+
+// fitsInAvailability checks if the period of a treatment is contained by one of the intervals of availability
+func fitsInAvailability(intervals [][]int64, phase int64, duration int64) bool {
+
+	start := phase % secondsInWeek
+	end := start + duration
+
+	for _, interval := range intervals {
+		if interval[0] <= start && end <= interval[1] {
+			return true
+		}
+	}
+
+	return false
+
+}
+
+// mergeIntervals sorts intervals by their start and merges the ones that overlap or touch each other
+func mergeIntervals(intervals [][]int64) [][]int64 {
+
+	if len(intervals) == 0 {
+		return intervals
+	}
+
+	sort.Slice(intervals, func(i int, j int) bool {
+		return intervals[i][0] < intervals[j][0]
+	})
+
+	merged := [][]int64{intervals[0]}
+
+	for _, interval := range intervals[1:] {
+		last := merged[len(merged)-1]
+		if interval[0] <= last[1] {
+			if interval[1] > last[1] {
+				last[1] = interval[1]
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+
+	return merged
+
+}
+
+// windowInterval converts a window to seconds elapsed since the start of the week of the UNIX epoch, which is a thursday,
+// the same reference used by the phase of treatments
+func windowInterval(weekday treatments_models.Weekday, startTime string, endTime string) (int64, int64, error) {
+
+	start, startErr := parseTimeOfDay(startTime)
+	if startErr != nil {
+		return 0, 0, startErr
+	}
+
+	end, endErr := parseTimeOfDay(endTime)
+	if endErr != nil {
+		return 0, 0, endErr
+	}
+
+	if end <= start {
+		return 0, 0, errors.New("availability windows must end after they start")
+	}
+
+	offset, offsetErr := weeklyOffset(weekday, 0)
+	if offsetErr != nil {
+		return 0, 0, offsetErr
+	}
+
+	return offset + start, offset + end, nil
+
+}
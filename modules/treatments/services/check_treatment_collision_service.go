@@ -14,78 +14,203 @@ type CheckTreatmentCollisionService struct {
 	ScheduleIntervalDuration time.Duration
 }
 
-func LCM(a, b int64) int64 {
-	c := a
-	d := b
-	for d != 0 {
-		t := d
-		d = c % d
-		c = t
+// GCD returns the greatest common divisor of two numbers
+func GCD(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
 	}
-	return a * b / c
+	return a
+}
+
+// LCM returns the least common multiple of two numbers
+func LCM(a, b int64) int64 {
+	return a * b / GCD(a, b)
 }
 
 // Execute is the method that runs the business logic of the service
-func (s CheckTreatmentCollisionService) Execute(psychologistID string, frequency int64, phase int64, duration int64, updatingID string) error {
+func (s CheckTreatmentCollisionService) Execute(psychologistID string, frequency int64, phase int64, duration int64, recurrence string, updatingID string) error {
 
-	if phase >= int64(s.ScheduleIntervalDuration/time.Second)*frequency {
+	interval := int64(s.ScheduleIntervalDuration / time.Second)
+
+	if phase >= interval*frequency {
 		return errors.New("phase cannot be bigger than the schedule interval")
 	}
 
+	location, locationErr := psychologistLocation(s.OrmUtil, psychologistID)
+	if locationErr != nil {
+		return locationErr
+	}
+
 	psychologistTreatments := []*treatments_models.Treatment{}
 
-	result := s.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Find(&psychologistTreatments)
+	query := s.OrmUtil.Db().Where("psychologist_id = ? AND status IN ?", psychologistID, []treatments_models.TreatmentStatus{treatments_models.Pending, treatments_models.Active})
+	if updatingID != "" {
+		query = query.Where("id <> ?", updatingID)
+	}
+
+	result := query.Order("created_at ASC").Find(&psychologistTreatments)
 	if result.Error != nil {
 		return result.Error
 	}
 
-	for _, treatment := range psychologistTreatments {
-		if treatment.Status != treatments_models.Pending && treatment.Status != treatments_models.Active {
-			continue
+	candidate := &treatments_models.Treatment{
+		Frequency:  frequency,
+		Phase:      phase,
+		Duration:   duration,
+		Recurrence: recurrence,
+	}
+
+	candidateRule, candidateRuleErr := treatmentRecurrenceRule(candidate.Recurrence, candidate.Frequency, candidate.Phase)
+	if candidateRuleErr != nil {
+		return candidateRuleErr
+	}
+
+	existingRules, existingRulesErr := treatmentRecurrenceRules(psychologistTreatments)
+	if existingRulesErr != nil {
+		return existingRulesErr
+	}
+
+	now := time.Now()
+
+	for index, treatment := range psychologistTreatments {
+		collision, collisionErr := findCollision(candidate, candidateRule, treatment, existingRules[index], interval, now, location)
+		if collisionErr != nil {
+			return collisionErr
 		}
 
-		lcm := LCM(frequency, treatment.Frequency)
+		if collision != nil {
+			return collision
+		}
+	}
 
-		candidateDates := [][]int64{}
-		treatmentDates := [][]int64{}
+	return nil
 
-		for counter := int64(0); counter < lcm; counter++ {
-			if counter%frequency == 0 {
-				candidateStart := counter*int64(s.ScheduleIntervalDuration/time.Second) + phase
-				candidateEnd := (candidateStart + duration) % (lcm * int64(s.ScheduleIntervalDuration/time.Second))
-				candidateDates = append(candidateDates, []int64{candidateStart, candidateEnd})
-			}
-			if counter%treatment.Frequency == 0 {
-				treatmentStart := counter*int64(s.ScheduleIntervalDuration/time.Second) + treatment.Phase
-				treatmentEnd := (treatmentStart + treatment.Duration) % (lcm * int64(s.ScheduleIntervalDuration/time.Second))
-				treatmentDates = append(treatmentDates, []int64{treatmentStart, treatmentEnd})
-			}
+}
+
+// treatmentRecurrenceRules gets the rules of many treatments, in the same order, so that they are parsed only once when
+// compared with many candidates
+func treatmentRecurrenceRules(treatments []*treatments_models.Treatment) ([]*treatments_models.RecurrenceRule, error) {
+
+	rules := make([]*treatments_models.RecurrenceRule, len(treatments))
+
+	for index, treatment := range treatments {
+		rule, ruleErr := treatmentRecurrenceRule(treatment.Recurrence, treatment.Frequency, treatment.Phase)
+		if ruleErr != nil {
+			return nil, ruleErr
 		}
+		rules[index] = rule
+	}
 
-		for _, candidateValues := range candidateDates {
-			for _, treatmentValues := range treatmentDates {
-				// If 3 of the 4 conditions below are true, it means there is no clash between treatments
-				noClash := 0
-				if candidateValues[0] < candidateValues[1] {
-					noClash++
-				}
-				if candidateValues[1] <= treatmentValues[0] {
-					noClash++
-				}
-				if treatmentValues[0] < treatmentValues[1] {
-					noClash++
-				}
-				if treatmentValues[1] <= candidateValues[0] {
-					noClash++
-				}
+	return rules, nil
 
-				if noClash < 3 && treatment.ID != updatingID {
-					return errors.New("there is another treatment in the same period")
+}
+
+// findCollision checks if the sessions of a candidate treatment overlap the sessions of an existing one. Rules that repeat
+// themselves every few weeks are compared with collidingOccurrence for each pair of their weekdays. Rules that end or follow
+// the months of the calendar have their sessions compared one by one, from now until the collision horizon
+func findCollision(candidate *treatments_models.Treatment, candidateRule *treatments_models.RecurrenceRule, existing *treatments_models.Treatment, existingRule *treatments_models.RecurrenceRule, interval int64, now time.Time, location *time.Location) (*treatments_models.TreatmentCollisionError, error) {
+
+	if isPeriodicRule(candidateRule) && isPeriodicRule(existingRule) {
+		existingPhases := occurrencePhases(existingRule, existing.Phase)
+
+		for _, candidatePhase := range occurrencePhases(candidateRule, candidate.Phase) {
+			for index, existingPhase := range existingPhases {
+				occurrence, collides := collidingOccurrence(
+					&treatments_models.Treatment{Frequency: candidate.Frequency, Phase: candidatePhase, Duration: candidate.Duration},
+					&treatments_models.Treatment{Frequency: existing.Frequency, Phase: existingPhase, Duration: existing.Duration},
+					interval,
+				)
+				if !collides {
+					continue
 				}
+
+				week, weekday, startTime := describeTreatmentPhase(existingPhase + occurrence*existing.Frequency*interval)
+
+				return &treatments_models.TreatmentCollisionError{
+					TreatmentID: existing.ID,
+					Occurrence:  occurrence*int64(len(existingPhases)) + int64(index),
+					Week:        week,
+					Weekday:     weekday,
+					StartTime:   startTime,
+				}, nil
 			}
 		}
+
+		return nil, nil
 	}
 
-	return nil
+	from := wallClock(now, location)
+	to := from + collisionHorizon
+
+	existingOrigin := from
+	if existing.StartDate != nil && existing.StartDate.Before(now) {
+		existingOrigin = wallClock(*existing.StartDate, location)
+	}
+
+	candidateOccurrences := listOccurrences(candidateRule, candidate.Phase, from, from, to, 0)
+	existingOccurrences := listOccurrences(existingRule, existing.Phase, existingOrigin, from, to, 0)
+
+	// both lists are sorted by their start, so the one whose session ends first moves forward until two sessions overlap
+	for i, j := 0, 0; i < len(candidateOccurrences) && j < len(existingOccurrences); {
+		candidateStart := candidateOccurrences[i].start
+		existingStart := existingOccurrences[j].start
+
+		switch {
+		case candidateStart+candidate.Duration <= existingStart:
+			i++
+		case existingStart+existing.Duration <= candidateStart:
+			j++
+		default:
+			week, weekday, startTime := describeOccurrence(existingRule, existingStart)
+
+			return &treatments_models.TreatmentCollisionError{
+				TreatmentID: existing.ID,
+				Occurrence:  existingOccurrences[j].index,
+				Week:        week,
+				Weekday:     weekday,
+				StartTime:   startTime,
+				Date:        time.Unix(existingStart, 0).UTC().Format("2006-01-02"),
+			}, nil
+		}
+	}
+
+	return nil, nil
+
+}
+
+// collidingOccurrence checks if the sessions of a candidate treatment overlap the sessions of an existing one, returning the
+// index of the first session of the existing treatment that is hit, counted from the start of the cycle of both treatments.
+// The distance between the starts of any two sessions of both treatments can only be the distance between their phases plus
+// a multiple of GCD(frequencies) * interval, so it is enough to check that distance modulo this period, including the case
+// in which a session crosses the end of the period
+func collidingOccurrence(candidate *treatments_models.Treatment, existing *treatments_models.Treatment, interval int64) (int64, bool) {
+
+	period := GCD(candidate.Frequency, existing.Frequency) * interval
+	distance := ((candidate.Phase-existing.Phase)%period + period) % period
+
+	var shift int64
+
+	switch {
+	case distance < existing.Duration:
+		// the candidate starts while a session of the existing treatment is happening
+		shift = distance
+	case distance > period-candidate.Duration:
+		// the candidate starts before a session of the existing treatment and is still happening when it starts
+		shift = distance - period
+	default:
+		return 0, false
+	}
+
+	cycle := LCM(candidate.Frequency, existing.Frequency) * interval
+	candidatePeriod := candidate.Frequency * interval
+
+	for occurrence := int64(0); occurrence*existing.Frequency*interval < cycle; occurrence++ {
+		candidateStart := existing.Phase + occurrence*existing.Frequency*interval + shift
+		if ((candidateStart-candidate.Phase)%candidatePeriod+candidatePeriod)%candidatePeriod == 0 {
+			return occurrence, true
+		}
+	}
+
+	return 0, false
 
 }
@@ -10,19 +10,35 @@ import (
 
 // CreateTreatmentService is a service that creates a new treatment for a psychologist
 type CreateTreatmentService struct {
-	IdentifierUtil                 identifier.IIdentifierUtil
-	OrmUtil                        orm.IOrmUtil
-	CheckTreatmentCollisionService *CheckTreatmentCollisionService
+	IdentifierUtil                    identifier.IIdentifierUtil
+	OrmUtil                           orm.IOrmUtil
+	CheckTreatmentAvailabilityService *CheckTreatmentAvailabilityService
+	CheckTreatmentCollisionService    *CheckTreatmentCollisionService
 }
 
 // Execute is the method that runs the business logic of the service
 func (s CreateTreatmentService) Execute(psychologistID string, input treatments_models.CreateTreatmentInput) error {
 
-	checkErr := s.CheckTreatmentCollisionService.Execute(psychologistID, input.Frequency, input.Phase, input.Duration, "")
+	phase, phaseErr := treatmentPhase(input.Frequency, input.Week, input.Weekday, input.StartTime)
+	if phaseErr != nil {
+		return phaseErr
+	}
+
+	recurrence, recurrenceErr := resolveRecurrenceRule(input.Recurrence, input.Frequency, input.Weekday)
+	if recurrenceErr != nil {
+		return recurrenceErr
+	}
+
+	checkErr := s.CheckTreatmentCollisionService.Execute(psychologistID, input.Frequency, phase, input.Duration, recurrence, "")
 	if checkErr != nil {
 		return checkErr
 	}
 
+	availabilityErr := s.CheckTreatmentAvailabilityService.Execute(psychologistID, input.Frequency, phase, input.Duration, recurrence)
+	if availabilityErr != nil {
+		return availabilityErr
+	}
+
 	priceRange := treatments_models.TreatmentPriceRange{}
 
 	result := s.OrmUtil.Db().Where("name = ?", input.PriceRangeName).Limit(1).Find(&priceRange)
@@ -43,7 +59,8 @@ func (s CreateTreatmentService) Execute(psychologistID string, input treatments_
 		ID:             treatmentID,
 		PsychologistID: psychologistID,
 		Frequency:      input.Frequency,
-		Phase:          input.Phase,
+		Phase:          phase,
+		Recurrence:     recurrence,
 		Duration:       input.Duration,
 		Status:         treatments_models.Pending,
 	}
@@ -0,0 +1,39 @@
+package treatments_services
+
+import (
+	"sort"
+
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetAvailabilityWindowsService is a service that gets the availability declared by a psychologist, from sunday to saturday
+type GetAvailabilityWindowsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetAvailabilityWindowsService) Execute(psychologistID string) ([]*treatments_models.AvailabilityWindow, error) {
+
+	windows := []*treatments_models.AvailabilityWindow{}
+
+	result := s.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Find(&windows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	order := map[treatments_models.Weekday]int{}
+	for index, weekday := range treatments_models.Weekdays {
+		order[weekday] = index
+	}
+
+	sort.Slice(windows, func(i int, j int) bool {
+		if windows[i].Weekday != windows[j].Weekday {
+			return order[windows[i].Weekday] < order[windows[j].Weekday]
+		}
+		return windows[i].StartTime < windows[j].StartTime
+	})
+
+	return windows, nil
+
+}
@@ -0,0 +1,122 @@
+package treatments_services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetFreeSlotsService is a service that lists the moments in which a psychologist could start a new treatment with a given
+// frequency and duration, respecting their pending and active treatments and their declared availability
+type GetFreeSlotsService struct {
+	OrmUtil                  orm.IOrmUtil
+	ScheduleIntervalDuration time.Duration
+	SlotStepDuration         time.Duration
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetFreeSlotsService) Execute(psychologistID string, frequency int64, duration int64) ([]*treatments_models.FreeSlot, error) {
+
+	interval := int64(s.ScheduleIntervalDuration / time.Second)
+	step := int64(s.SlotStepDuration / time.Second)
+
+	if step < 1 {
+		return nil, errors.New("slot step must be at least one second")
+	}
+
+	frequencyErr := checkFrequency(frequency)
+	if frequencyErr != nil {
+		return nil, frequencyErr
+	}
+
+	if duration < 1 || duration > interval*frequency {
+		return nil, errors.New("duration must fit in the interval between sessions")
+	}
+
+	location, locationErr := psychologistLocation(s.OrmUtil, psychologistID)
+	if locationErr != nil {
+		return nil, locationErr
+	}
+
+	treatments := []*treatments_models.Treatment{}
+
+	result := s.OrmUtil.Db().Where("psychologist_id = ? AND status IN ?", psychologistID, []treatments_models.TreatmentStatus{treatments_models.Pending, treatments_models.Active}).Find(&treatments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	existingRules, existingRulesErr := treatmentRecurrenceRules(treatments)
+	if existingRulesErr != nil {
+		return nil, existingRulesErr
+	}
+
+	windows := []*treatments_models.AvailabilityWindow{}
+
+	result = s.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Find(&windows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	availability, availabilityErr := availabilityIntervals(windows)
+	if availabilityErr != nil {
+		return nil, availabilityErr
+	}
+
+	slots := []*treatments_models.FreeSlot{}
+	now := time.Now()
+
+	for week := int64(0); week < frequency; week++ {
+		for _, weekday := range treatments_models.Weekdays {
+			for seconds := int64(0); seconds < secondsInDay; seconds += step {
+				offset, offsetErr := weeklyOffset(weekday, seconds)
+				if offsetErr != nil {
+					return nil, offsetErr
+				}
+
+				phase := week*secondsInWeek + offset
+
+				if len(windows) > 0 && !fitsInAvailability(availability, phase, duration) {
+					continue
+				}
+
+				candidate := &treatments_models.Treatment{
+					Frequency: frequency,
+					Phase:     phase,
+					Duration:  duration,
+				}
+
+				candidateRule, candidateRuleErr := treatmentRecurrenceRule(candidate.Recurrence, candidate.Frequency, candidate.Phase)
+				if candidateRuleErr != nil {
+					return nil, candidateRuleErr
+				}
+
+				free := true
+				for index, treatment := range treatments {
+					collision, collisionErr := findCollision(candidate, candidateRule, treatment, existingRules[index], interval, now, location)
+					if collisionErr != nil {
+						return nil, collisionErr
+					}
+
+					if collision != nil {
+						free = false
+						break
+					}
+				}
+
+				if free {
+					slots = append(slots, &treatments_models.FreeSlot{
+						Week:      week,
+						Weekday:   weekday,
+						StartTime: fmt.Sprintf("%02d:%02d", seconds/3600, seconds%3600/60),
+					})
+				}
+			}
+		}
+	}
+
+	return slots, nil
+
+}
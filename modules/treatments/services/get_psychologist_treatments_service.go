@@ -20,6 +20,11 @@ func (s GetPsychologistTreatmentsService) Execute(psychologistID string) ([]*tre
 		return nil, result.Error
 	}
 
+	for _, treatment := range treatments {
+		treatment.Week, treatment.Weekday, treatment.StartTime = describeTreatmentPhase(treatment.Phase)
+		treatment.Recurrence = describeRecurrenceRule(treatment.Recurrence, treatment.Frequency, treatment.Phase)
+	}
+
 	return treatments, nil
 
 }
@@ -20,6 +20,9 @@ func (s GetTreatmentForPatientService) Execute(id string) (*treatments_models.Ge
 		return nil, result.Error
 	}
 
+	treatment.Week, treatment.Weekday, treatment.StartTime = describeTreatmentPhase(treatment.Phase)
+	treatment.Recurrence = describeRecurrenceRule(treatment.Recurrence, treatment.Frequency, treatment.Phase)
+
 	return treatment, nil
 
 }
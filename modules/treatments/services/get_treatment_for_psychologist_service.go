@@ -20,6 +20,9 @@ func (s GetTreatmentForPsychologistService) Execute(id string) (*treatments_mode
 		return nil, result.Error
 	}
 
+	treatment.Week, treatment.Weekday, treatment.StartTime = describeTreatmentPhase(treatment.Phase)
+	treatment.Recurrence = describeRecurrenceRule(treatment.Recurrence, treatment.Frequency, treatment.Phase)
+
 	return treatment, nil
 
 }
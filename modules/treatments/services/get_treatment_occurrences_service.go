@@ -0,0 +1,53 @@
+package treatments_services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetTreatmentOccurrencesService is a service that calculates the next sessions of a treatment from its recurrence rule,
+// in the time zone of its psychologist. Sessions are counted since the treatment was assigned, so that rules with a count end
+type GetTreatmentOccurrencesService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetTreatmentOccurrencesService) Execute(treatment *treatments_models.Treatment, from time.Time, limit int64) ([]*treatments_models.TreatmentOccurrence, error) {
+
+	if limit < 1 {
+		return nil, errors.New("limit must be at least one")
+	}
+
+	rule, ruleErr := treatmentRecurrenceRule(treatment.Recurrence, treatment.Frequency, treatment.Phase)
+	if ruleErr != nil {
+		return nil, ruleErr
+	}
+
+	location, locationErr := psychologistLocation(s.OrmUtil, treatment.PsychologistID)
+	if locationErr != nil {
+		return nil, locationErr
+	}
+
+	origin := from
+	if treatment.StartDate != nil && treatment.StartDate.Before(from) {
+		origin = *treatment.StartDate
+	}
+
+	occurrences := []*treatments_models.TreatmentOccurrence{}
+
+	for _, occurrence := range listOccurrences(rule, treatment.Phase, wallClock(origin, location), wallClock(from, location), math.MaxInt64, limit) {
+		start := fromWallClock(occurrence.start, location)
+		occurrences = append(occurrences, &treatments_models.TreatmentOccurrence{
+			Index: occurrence.index,
+			Start: start,
+			End:   start.Add(time.Duration(treatment.Duration) * time.Second),
+		})
+	}
+
+	return occurrences, nil
+
+}
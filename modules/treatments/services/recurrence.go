@@ -0,0 +1,379 @@
+package treatments_services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+)
+
+// collisionHorizon is how far ahead the sessions of rules that do not repeat themselves every few weeks are compared
+const collisionHorizon = 53 * secondsInWeek
+
+var recurrenceWeekdays = map[string]treatments_models.Weekday{
+	"SU": treatments_models.Sunday,
+	"MO": treatments_models.Monday,
+	"TU": treatments_models.Tuesday,
+	"WE": treatments_models.Wednesday,
+	"TH": treatments_models.Thursday,
+	"FR": treatments_models.Friday,
+	"SA": treatments_models.Saturday,
+}
+
+// occurrence is a session calculated from a recurrence rule, with its start in wall clock seconds
+type occurrence struct {
+	index int64
+	start int64
+}
+
+// parseRecurrenceRule reads a rule in the RRULE format. Interval is left as zero if it is not informed
+func parseRecurrenceRule(value string) (*treatments_models.RecurrenceRule, error) {
+
+	rule := &treatments_models.RecurrenceRule{}
+
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+
+	for _, part := range strings.Split(value, ";") {
+		pair := strings.SplitN(part, "=", 2)
+		if len(pair) != 2 {
+			return nil, errors.New("invalid recurrence rule")
+		}
+
+		switch pair[0] {
+		case "FREQ":
+			rule.Frequency = treatments_models.RecurrenceFrequency(pair[1])
+		case "INTERVAL":
+			interval, parseErr := strconv.ParseInt(pair[1], 10, 64)
+			if parseErr != nil || interval < 1 {
+				return nil, errors.New("invalid recurrence rule")
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(pair[1], ",") {
+				if len(day) < 2 {
+					return nil, errors.New("invalid recurrence rule")
+				}
+
+				weekday, ok := recurrenceWeekdays[day[len(day)-2:]]
+				if !ok {
+					return nil, errors.New("invalid recurrence rule")
+				}
+
+				// the position comes before the weekday, such as 2TU or -1FR
+				if day[:len(day)-2] != "" {
+					position, parseErr := strconv.ParseInt(day[:len(day)-2], 10, 64)
+					if parseErr != nil || position < -1 || position == 0 || position > 5 || (rule.Position != 0 && rule.Position != position) {
+						return nil, errors.New("invalid recurrence rule")
+					}
+					rule.Position = position
+				}
+
+				if !containsWeekday(rule.Weekdays, weekday) {
+					rule.Weekdays = append(rule.Weekdays, weekday)
+				}
+			}
+		case "COUNT":
+			count, parseErr := strconv.ParseInt(pair[1], 10, 64)
+			if parseErr != nil || count < 1 {
+				return nil, errors.New("invalid recurrence rule")
+			}
+			rule.Count = count
+		case "UNTIL":
+			if len(pair[1]) < 8 {
+				return nil, errors.New("invalid recurrence rule")
+			}
+			until, parseErr := time.Parse("20060102", pair[1][:8])
+			if parseErr != nil {
+				return nil, errors.New("invalid recurrence rule")
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("recurrence rule part %s is not supported", pair[0])
+		}
+	}
+
+	switch rule.Frequency {
+	case treatments_models.Weekly:
+		if rule.Position != 0 {
+			return nil, errors.New("positions of weekdays are only allowed in monthly recurrence rules")
+		}
+	case treatments_models.Monthly:
+		if len(rule.Weekdays) != 1 || rule.Position == 0 {
+			return nil, errors.New("monthly recurrence rules need one weekday with its position, such as 2TU")
+		}
+	default:
+		return nil, errors.New("recurrence rule frequency must be WEEKLY or MONTHLY")
+	}
+
+	sort.Slice(rule.Weekdays, func(i int, j int) bool {
+		return weekdayIndex(rule.Weekdays[i]) < weekdayIndex(rule.Weekdays[j])
+	})
+
+	return rule, nil
+
+}
+
+// formatRecurrenceRule writes a rule in the RRULE format, always informing the interval and the weekdays
+func formatRecurrenceRule(rule *treatments_models.RecurrenceRule) string {
+
+	days := []string{}
+	for _, weekday := range rule.Weekdays {
+		code := string(weekday)[:2]
+		if rule.Position != 0 {
+			code = fmt.Sprintf("%d%s", rule.Position, code)
+		}
+		days = append(days, code)
+	}
+
+	value := fmt.Sprintf("FREQ=%s;INTERVAL=%d;BYDAY=%s", rule.Frequency, rule.Interval, strings.Join(days, ","))
+
+	if rule.Count > 0 {
+		value += fmt.Sprintf(";COUNT=%d", rule.Count)
+	}
+
+	if rule.Until != nil {
+		value += ";UNTIL=" + rule.Until.Format("20060102")
+	}
+
+	return value
+
+}
+
+// resolveRecurrenceRule completes the rule informed when creating or updating a treatment with its frequency and weekday,
+// returning it in the RRULE format. An empty rule means that the treatment happens on the weekday every frequency weeks,
+// as treatments did before rules existed
+func resolveRecurrenceRule(value string, frequency int64, weekday treatments_models.Weekday) (string, error) {
+
+	if value == "" {
+		return "", nil
+	}
+
+	rule, parseErr := parseRecurrenceRule(value)
+	if parseErr != nil {
+		return "", parseErr
+	}
+
+	if rule.Interval == 0 {
+		rule.Interval = frequency
+	}
+
+	if rule.Interval != frequency {
+		return "", errors.New("frequency must match the interval of the recurrence rule")
+	}
+
+	if len(rule.Weekdays) == 0 {
+		rule.Weekdays = []treatments_models.Weekday{weekday}
+	}
+
+	if !containsWeekday(rule.Weekdays, weekday) {
+		return "", errors.New("weekday must be one of the weekdays of the recurrence rule")
+	}
+
+	return formatRecurrenceRule(rule), nil
+
+}
+
+// treatmentRecurrenceRule gets the rule of a treatment, building the weekly one of treatments without a rule
+func treatmentRecurrenceRule(recurrence string, frequency int64, phase int64) (*treatments_models.RecurrenceRule, error) {
+
+	if recurrence != "" {
+		rule, parseErr := parseRecurrenceRule(recurrence)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		if rule.Interval == 0 {
+			rule.Interval = 1
+		}
+		return rule, nil
+	}
+
+	_, weekday, _ := describeTreatmentPhase(phase)
+
+	return &treatments_models.RecurrenceRule{
+		Frequency: treatments_models.Weekly,
+		Interval:  frequency,
+		Weekdays:  []treatments_models.Weekday{weekday},
+	}, nil
+
+}
+
+// describeRecurrenceRule gets the rule of a treatment in the RRULE format, including treatments without a rule
+func describeRecurrenceRule(recurrence string, frequency int64, phase int64) string {
+
+	rule, ruleErr := treatmentRecurrenceRule(recurrence, frequency, phase)
+	if ruleErr != nil {
+		return recurrence
+	}
+
+	return formatRecurrenceRule(rule)
+
+}
+
+// isPeriodicRule checks if the sessions of a rule repeat themselves every few weeks forever
+func isPeriodicRule(rule *treatments_models.RecurrenceRule) bool {
+	return rule.Frequency == treatments_models.Weekly && rule.Count == 0 && rule.Until == nil
+}
+
+// occurrencePhases lists the phases of each weekday of a rule, given the phase of the treatment. The time of the day and
+// the week of the cycle are the same for all weekdays
+func occurrencePhases(rule *treatments_models.RecurrenceRule, phase int64) []int64 {
+
+	if rule.Frequency != treatments_models.Weekly {
+		return []int64{phase}
+	}
+
+	week := phase / secondsInWeek
+	seconds := phase % secondsInWeek % secondsInDay
+
+	phases := []int64{}
+	for _, weekday := range rule.Weekdays {
+		offset, _ := weeklyOffset(weekday, seconds)
+		phases = append(phases, week*secondsInWeek+offset)
+	}
+
+	sort.Slice(phases, func(i int, j int) bool {
+		return phases[i] < phases[j]
+	})
+
+	return phases
+
+}
+
+// nextOccurrence returns the start of the first session of a rule at or after a moment, both in wall clock seconds
+func nextOccurrence(rule *treatments_models.RecurrenceRule, phase int64, moment int64) (int64, bool) {
+
+	if rule.Frequency == treatments_models.Weekly {
+		period := rule.Interval * secondsInWeek
+		next := int64(-1)
+
+		for _, occurrencePhase := range occurrencePhases(rule, phase) {
+			start := occurrencePhase
+			if moment > start {
+				start += (moment - start + period - 1) / period * period
+			}
+			if next == -1 || start < next {
+				next = start
+			}
+		}
+
+		return next, next != -1
+	}
+
+	// in monthly rules, the week of the cycle chooses the month of the cycle instead, counted from january of 1970
+	cycleOffset := phase / secondsInWeek
+	seconds := phase % secondsInWeek % secondsInDay
+	current := time.Unix(moment, 0).UTC()
+	month := int64(current.Year()-1970)*12 + int64(current.Month()) - 1
+
+	// a fifth weekday does not exist in every month, so some more months are checked before giving up
+	for last := month + 12*rule.Interval + 12; month <= last; month++ {
+		if (month-cycleOffset)%rule.Interval != 0 {
+			continue
+		}
+
+		day, ok := positionedWeekday(int(month/12)+1970, time.Month(month%12+1), rule.Weekdays[0], rule.Position)
+		if !ok {
+			continue
+		}
+
+		start := day.Unix() + seconds
+		if start >= moment {
+			return start, true
+		}
+	}
+
+	return 0, false
+
+}
+
+// listOccurrences lists the sessions of a rule that start between from and to, in wall clock seconds. Sessions are counted
+// since origin, so that the count of the rule is respected, and at most limit sessions are returned if limit is positive
+func listOccurrences(rule *treatments_models.RecurrenceRule, phase int64, origin int64, from int64, to int64, limit int64) []occurrence {
+
+	if rule.Until != nil {
+		endOfUntil := rule.Until.Unix() + secondsInDay
+		if endOfUntil < to {
+			to = endOfUntil
+		}
+	}
+
+	occurrences := []occurrence{}
+	moment := origin
+
+	for index := int64(0); rule.Count == 0 || index < rule.Count; index++ {
+		start, ok := nextOccurrence(rule, phase, moment)
+		if !ok || start >= to {
+			break
+		}
+
+		if start >= from {
+			occurrences = append(occurrences, occurrence{index: index, start: start})
+			if limit > 0 && int64(len(occurrences)) == limit {
+				break
+			}
+		}
+
+		moment = start + 1
+	}
+
+	return occurrences
+
+}
+
+// describeOccurrence converts the start of a session in wall clock seconds to the week of the cycle, the weekday and the
+// local time in which it starts. In monthly rules, the week of the cycle is the month of the cycle instead
+func describeOccurrence(rule *treatments_models.RecurrenceRule, start int64) (int64, treatments_models.Weekday, string) {
+
+	if rule.Frequency == treatments_models.Weekly {
+		return describeTreatmentPhase(start % (rule.Interval * secondsInWeek))
+	}
+
+	current := time.Unix(start, 0).UTC()
+	month := int64(current.Year()-1970)*12 + int64(current.Month()) - 1
+	_, weekday, startTime := describeTreatmentPhase(start % secondsInWeek)
+
+	return month % rule.Interval, weekday, startTime
+
+}
+
+// positionedWeekday finds the date of a weekday in a position of a month, such as the second tuesday, where -1 is the last
+func positionedWeekday(year int, month time.Month, weekday treatments_models.Weekday, position int64) (time.Time, bool) {
+
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	index := weekdayIndex(weekday)
+
+	if position == -1 {
+		return last.AddDate(0, 0, -((int(last.Weekday()) - index + 7) % 7)), true
+	}
+
+	day := first.AddDate(0, 0, (index-int(first.Weekday())+7)%7+int(position-1)*7)
+	if day.Month() != month {
+		return time.Time{}, false
+	}
+
+	return day, true
+
+}
+
+func containsWeekday(weekdays []treatments_models.Weekday, weekday treatments_models.Weekday) bool {
+	for _, value := range weekdays {
+		if value == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func weekdayIndex(weekday treatments_models.Weekday) int {
+	for index, value := range treatments_models.Weekdays {
+		if value == weekday {
+			return index
+		}
+	}
+	return -1
+}
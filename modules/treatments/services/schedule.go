@@ -0,0 +1,150 @@
+package treatments_services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+const secondsInDay = int64(24 * 60 * 60)
+const secondsInWeek = 7 * secondsInDay
+
+// maxFrequency is the longest number of schedule intervals between two sessions of a treatment. It also limits how many
+// candidates the search for free slots has to check
+const maxFrequency = int64(12)
+
+// checkFrequency validates the number of schedule intervals between two sessions of a treatment
+func checkFrequency(frequency int64) error {
+
+	if frequency < 1 {
+		return errors.New("frequency must be at least one")
+	}
+
+	if frequency > maxFrequency {
+		return fmt.Errorf("frequency must be at most %d", maxFrequency)
+	}
+
+	return nil
+
+}
+
+// parseTimeOfDay converts a time in the HH:MM format to the seconds elapsed since the start of the day
+func parseTimeOfDay(value string) (int64, error) {
+
+	var hours, minutes int64
+
+	_, scanErr := fmt.Sscanf(value, "%02d:%02d", &hours, &minutes)
+	if scanErr != nil || len(value) != 5 || hours < 0 || minutes < 0 || minutes > 59 || hours*60+minutes > 24*60 {
+		return 0, errors.New("invalid time")
+	}
+
+	return hours*60*60 + minutes*60, nil
+
+}
+
+// weeklyOffset converts a weekday and the seconds elapsed since the start of that day to the seconds elapsed since the
+// start of the week, which begins on thursdays since the UNIX epoch was a thursday
+func weeklyOffset(weekday treatments_models.Weekday, seconds int64) (int64, error) {
+
+	for index, value := range treatments_models.Weekdays {
+		if value == weekday {
+			return int64((index-int(time.Thursday)+7)%7)*secondsInDay + seconds, nil
+		}
+	}
+
+	return 0, errors.New("invalid weekday")
+
+}
+
+// treatmentPhase converts the week of the cycle, the weekday and the local time in which the sessions of a treatment
+// start to the phase of the treatment
+func treatmentPhase(frequency int64, week int64, weekday treatments_models.Weekday, startTime string) (int64, error) {
+
+	frequencyErr := checkFrequency(frequency)
+	if frequencyErr != nil {
+		return 0, frequencyErr
+	}
+
+	if week < 0 || week >= frequency {
+		return 0, errors.New("week must be smaller than the frequency")
+	}
+
+	seconds, timeErr := parseTimeOfDay(startTime)
+	if timeErr != nil {
+		return 0, timeErr
+	}
+
+	if seconds == secondsInDay {
+		return 0, errors.New("invalid time")
+	}
+
+	offset, offsetErr := weeklyOffset(weekday, seconds)
+	if offsetErr != nil {
+		return 0, offsetErr
+	}
+
+	return week*secondsInWeek + offset, nil
+
+}
+
+// describeTreatmentPhase converts the phase of a treatment back to the week of the cycle, the weekday and the local time
+// in which its sessions start
+func describeTreatmentPhase(phase int64) (int64, treatments_models.Weekday, string) {
+
+	offset := phase % secondsInWeek
+	weekday := treatments_models.Weekdays[(offset/secondsInDay+int64(time.Thursday))%7]
+	seconds := offset % secondsInDay
+
+	return phase / secondsInWeek, weekday, fmt.Sprintf("%02d:%02d", seconds/3600, seconds%3600/60)
+
+}
+
+// psychologistLocation loads the time zone of a psychologist, in which the phases of their treatments are counted
+func psychologistLocation(ormUtil orm.IOrmUtil, psychologistID string) (*time.Location, error) {
+
+	psychologist := profiles_models.Psychologist{}
+
+	result := ormUtil.Db().Where("id = ?", psychologistID).Limit(1).Find(&psychologist)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return time.LoadLocation(psychologist.TimeZone)
+
+}
+
+// wallClock converts a moment to the seconds elapsed since the UNIX epoch in the wall clock of a time zone, as if it was UTC
+func wallClock(moment time.Time, location *time.Location) int64 {
+	local := moment.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC).Unix()
+}
+
+// fromWallClock converts seconds in the wall clock of a time zone back to a moment. time.Date applies the offset of the
+// time zone in that specific date, so daylight saving time is respected
+func fromWallClock(seconds int64, location *time.Location) time.Time {
+	local := time.Unix(seconds, 0).UTC()
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, location)
+}
+
+// shiftTreatmentPhase moves the phase of a treatment by some seconds, so that its sessions keep happening at the same moment
+// when the time zone of its psychologist changes. Sessions of treatments with a recurrence rule cannot move to another day,
+// since they would not happen on the weekdays of the rule anymore
+func shiftTreatmentPhase(treatment *treatments_models.Treatment, shift int64, interval int64) (int64, error) {
+
+	if treatment.Recurrence != "" {
+		seconds := treatment.Phase%secondsInWeek%secondsInDay + shift
+		if seconds < 0 || seconds >= secondsInDay {
+			return 0, errors.New("sessions of treatments with a recurrence rule cannot move to another day in the new time zone")
+		}
+		return treatment.Phase + shift, nil
+	}
+
+	cycle := treatment.Frequency * interval
+
+	return ((treatment.Phase+shift)%cycle + cycle) % cycle, nil
+
+}
@@ -0,0 +1,66 @@
+package treatments_services
+
+import (
+	"errors"
+	"sort"
+
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// SetAvailabilityWindowsService is a service that replaces the availability declared by a psychologist
+type SetAvailabilityWindowsService struct {
+	IdentifierUtil identifier.IIdentifierUtil
+	OrmUtil        orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s SetAvailabilityWindowsService) Execute(psychologistID string, input []*treatments_models.SetAvailabilityWindowInput) error {
+
+	intervals := [][]int64{}
+
+	for _, window := range input {
+		start, end, intervalErr := windowInterval(window.Weekday, window.StartTime, window.EndTime)
+		if intervalErr != nil {
+			return intervalErr
+		}
+		intervals = append(intervals, []int64{start, end})
+	}
+
+	sort.Slice(intervals, func(i int, j int) bool {
+		return intervals[i][0] < intervals[j][0]
+	})
+
+	for index := 1; index < len(intervals); index++ {
+		if intervals[index][0] < intervals[index-1][1] {
+			return errors.New("availability windows cannot overlap")
+		}
+	}
+
+	result := s.OrmUtil.Db().Where("psychologist_id = ?", psychologistID).Delete(&treatments_models.AvailabilityWindow{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, window := range input {
+		_, windowID, windowIDErr := s.IdentifierUtil.GenerateIdentifier()
+		if windowIDErr != nil {
+			return windowIDErr
+		}
+
+		result = s.OrmUtil.Db().Create(&treatments_models.AvailabilityWindow{
+			ID:             windowID,
+			PsychologistID: psychologistID,
+			Weekday:        window.Weekday,
+			StartTime:      window.StartTime,
+			EndTime:        window.EndTime,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"os"
 	"text/template"
+	"time"
 
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
 	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
 	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
 	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
@@ -13,13 +15,16 @@ import (
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/orm"
+	"gorm.io/gorm"
 )
 
-// UpdateTreatmentService is a service that changes data from a treatment
+// UpdateTreatmentService is a service that changes data from a treatment. When the schedule of an active treatment changes,
+// its future appointments that nobody acted on are removed so that the jobrunner creates them again with the new schedule
 type UpdateTreatmentService struct {
-	IdentifierUtil                 identifier.IIdentifierUtil
-	OrmUtil                        orm.IOrmUtil
-	CheckTreatmentCollisionService *CheckTreatmentCollisionService
+	IdentifierUtil                    identifier.IIdentifierUtil
+	OrmUtil                           orm.IOrmUtil
+	CheckTreatmentAvailabilityService *CheckTreatmentAvailabilityService
+	CheckTreatmentCollisionService    *CheckTreatmentCollisionService
 }
 
 // Execute is the method that runs the business logic of the service
@@ -66,16 +71,37 @@ func (s UpdateTreatmentService) Execute(id string, psychologistID string, input
 		return errors.New("non-pending treatments must have a price range")
 	}
 
-	checkErr := s.CheckTreatmentCollisionService.Execute(psychologistID, input.Frequency, input.Phase, input.Duration, id)
+	phase, phaseErr := treatmentPhase(input.Frequency, input.Week, input.Weekday, input.StartTime)
+	if phaseErr != nil {
+		return phaseErr
+	}
+
+	recurrence, recurrenceErr := resolveRecurrenceRule(input.Recurrence, input.Frequency, input.Weekday)
+	if recurrenceErr != nil {
+		return recurrenceErr
+	}
+
+	checkErr := s.CheckTreatmentCollisionService.Execute(psychologistID, input.Frequency, phase, input.Duration, recurrence, id)
 	if checkErr != nil {
 		return checkErr
 	}
 
+	availabilityErr := s.CheckTreatmentAvailabilityService.Execute(psychologistID, input.Frequency, phase, input.Duration, recurrence)
+	if availabilityErr != nil {
+		return availabilityErr
+	}
+
+	rescheduled := treatment.Status == treatments_models.Active &&
+		(treatment.Frequency != input.Frequency || treatment.Phase != phase || treatment.Recurrence != recurrence || treatment.Duration != input.Duration)
+
 	treatment.Frequency = input.Frequency
-	treatment.Phase = input.Phase
+	treatment.Phase = phase
+	treatment.Recurrence = recurrence
 	treatment.Duration = input.Duration
 	treatment.PriceRangeName = input.PriceRangeName
 
+	var mail *mails_models.TransientMailMessage
+
 	if treatment.PatientID != "" {
 
 		_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
@@ -96,7 +122,7 @@ func (s UpdateTreatmentService) Execute(id string, psychologistID string, input
 			"PsyFullName": psychologist.FullName,
 		})
 
-		mail := &mails_models.TransientMailMessage{
+		mail = &mails_models.TransientMailMessage{
 			ID:          mailID,
 			FromAddress: "relacionamento@psi.com.br",
 			FromName:    "Relacionamento PSI",
@@ -107,18 +133,38 @@ func (s UpdateTreatmentService) Execute(id string, psychologistID string, input
 			Html:        buff.String(),
 			Processed:   false,
 		}
+	}
+
+	return s.OrmUtil.Db().Transaction(func(tx *gorm.DB) error {
 
-		result = s.OrmUtil.Db().Create(&mail)
+		if rescheduled {
+			result := tx.Where("treatment_id = ? AND status IN ? AND start > ?", treatment.ID, []appointments_models.AppointmentStatus{appointments_models.Created, appointments_models.Skipped}, time.Now()).Delete(&appointments_models.Appointment{})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			// occurrences are counted with the new schedule from now on, so the appointments that are kept, including the
+			// canceled and edited ones, are matched by their start instead of an index that now means another session
+			result = tx.Unscoped().Model(&appointments_models.Appointment{}).Where("treatment_id = ? AND occurrence IS NOT NULL", treatment.ID).Update("occurrence", nil)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		if mail != nil {
+			result := tx.Create(&mail)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		result := tx.Save(&treatment)
 		if result.Error != nil {
 			return result.Error
 		}
-	}
 
-	result = s.OrmUtil.Db().Save(&treatment)
-	if result.Error != nil {
-		return result.Error
-	}
+		return nil
 
-	return nil
+	})
 
 }
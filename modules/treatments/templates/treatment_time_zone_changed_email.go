@@ -0,0 +1,7 @@
+package treatments_templates
+
+// TreatmentTimeZoneChangedEmailTemplate is an email template used to tell the patient that the psychologist of a treatment changed their time zone
+var TreatmentTimeZoneChangedEmailTemplate = `<h2>Olá {{ .LikeName }} 😊</h2>
+<p>Viemos te informar que {{ .PsyFullName }} mudou de fuso horário no PSI.</p>
+<p>As sessões do seu tratamento continuam acontecendo no mesmo momento. Entre no site para verificar os horários das suas próximas consultas.</p>
+<a href="{{ .SiteURL }}">Ir para o site</a>`
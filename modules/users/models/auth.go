@@ -2,10 +2,28 @@ package users_models
 
 import "time"
 
-// Authentication is the schema for saving successful authentication attempts for a user
+// Authentication is the schema for a session created by a successful authentication attempt of a user
 type Authentication struct {
-	UserID    string    `json:"userId" gorm:"primaryKey"`
-	IssuedAt  time.Time `json:"issuedAt"`
-	ExpiresAt time.Time `json:"expiresAt"`
-	Token     string    `json:"token" gorm:"index"`
+	ID               string    `json:"id" gorm:"primaryKey"`
+	UserID           string    `json:"userId" gorm:"index"`
+	IssuedAt         time.Time `json:"issuedAt"`
+	ExpiresAt        time.Time `json:"expiresAt" gorm:"-"`
+	LastSeenAt       time.Time `json:"lastSeenAt"`
+	Token            string    `json:"token" gorm:"-"`
+	RefreshToken     string    `json:"refreshToken" gorm:"-"`
+	RefreshTokenHash string    `json:"refreshTokenHash" gorm:"index"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	TwoFactorPending bool      `json:"twoFactorPending" gorm:"-"`
+	Device           string    `json:"device"`
+	IPAddress        string    `json:"ipAddress"`
+	UserAgent        string    `json:"userAgent"`
+}
+
+// AuthClaims is the schema for the information carried inside a signed access token
+type AuthClaims struct {
+	UserID                string `json:"userId"`
+	SessionID             string `json:"sessionId"`
+	TwoFactorPending      bool   `json:"twoFactorPending,omitempty"`
+	ImpersonatorID        string `json:"impersonatorId,omitempty"`
+	ImpersonatorSessionID string `json:"impersonatorSessionId,omitempty"`
 }
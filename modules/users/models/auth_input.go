@@ -2,11 +2,26 @@ package users_models
 
 // AuthenticateUserInput is the schema for information needed to authenticate a user
 type AuthenticateUserInput struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	OtpCode   string `json:"otpCode"`
+	Device    string `json:"device"`
+	IPAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
 }
 
 // ValidateUserTokenInput is the schema for information needed to validate a user's token
 type ValidateUserTokenInput struct {
 	Token string `json:"token"`
 }
+
+// AuthenticateWithOidcInput is the schema for information needed to authenticate a user that returned from the OpenID Connect provider
+type AuthenticateWithOidcInput struct {
+	State     string `json:"state"`
+	Code      string `json:"code"`
+	Binding   string `json:"binding"`
+	OtpCode   string `json:"otpCode"`
+	Device    string `json:"device"`
+	IPAddress string `json:"ipAddress"`
+	UserAgent string `json:"userAgent"`
+}
@@ -0,0 +1,14 @@
+package users_models
+
+import "time"
+
+// DataExport is the schema for a request of a user to get a copy of their personal data in the database
+type DataExport struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UserID    string    `json:"userId" gorm:"index"`
+	Processed bool      `json:"processed" gorm:"index"`
+	FileName  string    `json:"fileName"`
+	TokenHash string    `json:"tokenHash" gorm:"index"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,12 @@
+package users_models
+
+import "time"
+
+// EmailChange is the schema for a pending request of a user to change their email in the database
+type EmailChange struct {
+	UserID    string    `json:"userId" gorm:"primaryKey"`
+	NewEmail  string    `json:"newEmail"`
+	TokenHash string    `json:"tokenHash" gorm:"index"`
+	IssuedAt  time.Time `json:"issuedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
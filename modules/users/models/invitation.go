@@ -0,0 +1,52 @@
+package users_models
+
+import "time"
+
+// InvitationStatus represents the possible states of an invitation
+type InvitationStatus string
+
+const (
+	// InvitationPending means that the invited user did not create their password yet
+	InvitationPending InvitationStatus = "PENDING"
+	// InvitationAccepted means that the invited user created their password
+	InvitationAccepted InvitationStatus = "ACCEPTED"
+	// InvitationExpired means that the invited user did not create their password before the invitation expired.
+	// It is never stored, pending invitations are informed as expired when they are read after the expiration
+	InvitationExpired InvitationStatus = "EXPIRED"
+	// InvitationRevoked means that the invitation was cancelled and the invited user was removed
+	InvitationRevoked InvitationStatus = "REVOKED"
+)
+
+// Invitation is the schema for the invitation sent to a user created without a password
+type Invitation struct {
+	ID        string           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+	UserID    string           `json:"userId" gorm:"index"`
+	Email     string           `json:"email"`
+	Role      Role             `json:"role"`
+	Template  string           `json:"template"`
+	InvitedBy string           `json:"invitedBy"`
+	Status    InvitationStatus `json:"status" gorm:"index"`
+	ExpiresAt time.Time        `json:"expiresAt"`
+}
+
+// InvitationRowStatus represents the possible results of inviting the user informed in a row of a file
+type InvitationRowStatus string
+
+const (
+	// InvitationCreated means that the user was created and the invitation email was sent
+	InvitationCreated InvitationRowStatus = "CREATED"
+	// InvitationDuplicate means that a user with the same email already exists or appeared before in the file
+	InvitationDuplicate InvitationRowStatus = "DUPLICATE"
+	// InvitationInvalid means that the row could not be used to create a user
+	InvitationInvalid InvitationRowStatus = "INVALID"
+)
+
+// InvitationRowResult is the schema for the result of inviting the user informed in a row of a file
+type InvitationRowResult struct {
+	Line    int64               `json:"line"`
+	Email   string              `json:"email"`
+	Status  InvitationRowStatus `json:"status"`
+	Message string              `json:"message"`
+}
@@ -0,0 +1,13 @@
+package users_models
+
+import "time"
+
+// OidcAuthorization is the schema for an authorization request sent to the OpenID Connect provider that did not return yet
+type OidcAuthorization struct {
+	StateHash    string    `json:"stateHash" gorm:"primaryKey"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"codeVerifier"`
+	BindingHash  string    `json:"bindingHash"`
+	IssuedAt     time.Time `json:"issuedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
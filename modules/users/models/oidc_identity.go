@@ -0,0 +1,12 @@
+package users_models
+
+import "time"
+
+// OidcIdentity is the schema for the link between a user and their account in an OpenID Connect provider. It is created
+// in the first login through the provider, and later logins are matched by issuer and subject, which never change, instead of by email
+type OidcIdentity struct {
+	Issuer    string    `json:"issuer" gorm:"primaryKey"`
+	Subject   string    `json:"subject" gorm:"primaryKey"`
+	UserID    string    `json:"userId" gorm:"index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
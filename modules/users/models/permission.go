@@ -0,0 +1,77 @@
+package users_models
+
+import "time"
+
+// Permission is the schema for an action in the application that can be granted to roles
+type Permission struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RoleDefinition is the schema for a role that can be given to users. Besides the default roles, coordinators can register
+// new ones, such as a supervisor or a receptionist, and grant them permissions without changes in the application
+type RoleDefinition struct {
+	Name      Role      `json:"name" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RolePermission is the schema for the grant of a permission to a role
+type RolePermission struct {
+	Role       Role   `json:"role" gorm:"primaryKey"`
+	Permission string `json:"permission" gorm:"primaryKey"`
+}
+
+// ManagePermissionsPermission is the permission that allows a user to change the permissions of the roles.
+// Coordinators cannot lose it, otherwise nobody would be able to change the permissions anymore
+const ManagePermissionsPermission = "permissions:manage"
+
+// SetupTwoFactorPermission is the permission that allows a user to set up two factor authentication.
+// It is the only permission that users of roles that require a second factor have until they set it up
+const SetupTwoFactorPermission = "two-factor:setup"
+
+// DefaultRoles holds the roles the application relies on, which are registered when it starts
+var DefaultRoles = []Role{Coordinator, JobRunner, Patient, Psychologist}
+
+// DefaultRolePermissions holds all permissions known by the application and the roles that receive them
+// when they are registered for the first time. Changes made afterwards by coordinators are kept
+var DefaultRolePermissions = map[string][]Role{
+	"account:manage":                       {Coordinator, Psychologist, Patient},
+	"affinities:read-own":                  {Coordinator, Psychologist, Patient},
+	"agreements:sign-as-patient":           {Patient},
+	"agreements:sign-as-psychologist":      {Psychologist},
+	"appointments:manage-as-patient":       {Coordinator, Psychologist, Patient},
+	"appointments:manage-as-psychologist":  {Coordinator, Psychologist},
+	"appointments:schedule":                {JobRunner},
+	"audit-log:read":                       {Coordinator},
+	"characteristics:manage":               {Coordinator},
+	"characteristics:read":                 {Coordinator, Psychologist, Patient},
+	"data-exports:process":                 {JobRunner},
+	"holidays:manage":                      {Coordinator},
+	"holidays:read":                        {Coordinator, Psychologist, Patient},
+	"mails:process":                        {JobRunner},
+	"patient-profiles:manage-own":          {Coordinator, Psychologist, Patient},
+	"patient-profiles:read":                {Coordinator, Psychologist},
+	ManagePermissionsPermission:            {Coordinator},
+	"price-ranges:manage":                  {Coordinator},
+	"price-ranges:read":                    {Coordinator, Psychologist},
+	"psychologist-profiles:manage-own":     {Coordinator, Psychologist},
+	"psychologist-profiles:read":           {Coordinator, Psychologist, Patient},
+	"psychologist-profiles:verify":         {Coordinator},
+	"terms:manage":                         {Coordinator},
+	"terms:read-patient":                   {Coordinator, Psychologist, Patient},
+	"terms:read-psychologist":              {Coordinator, Psychologist},
+	"translations:manage":                  {Coordinator},
+	SetupTwoFactorPermission:               {Coordinator, Psychologist, Patient},
+	"treatments:assign":                    {Coordinator, Psychologist, Patient},
+	"treatments:interrupt-as-patient":      {Coordinator, Psychologist, Patient},
+	"treatments:interrupt-as-psychologist": {Coordinator, Psychologist},
+	"treatments:manage":                    {Coordinator, Psychologist},
+	"users:create":                         {Coordinator},
+	"users:erase":                          {Coordinator},
+	"users:impersonate":                    {Coordinator},
+	"users:invite":                         {Coordinator},
+	"users:list":                           {Coordinator},
+	"users:read":                           {Coordinator, Psychologist},
+	"users:unlock":                         {Coordinator},
+	"users:update":                         {Coordinator},
+}
@@ -5,7 +5,7 @@ import "time"
 // ResetPassword is the schema for a reset password request in the database
 type ResetPassword struct {
 	UserID    string    `json:"userId" gorm:"primaryKey"`
-	Token     string    `json:"token" gorm:"index"`
+	TokenHash string    `json:"tokenHash" gorm:"index"`
 	IssuedAt  time.Time `json:"issuedAt"`
 	ExpiresAt time.Time `json:"expiresAt"`
 	Redeemed  bool      `json:"redeemed"`
@@ -0,0 +1,26 @@
+package users_models
+
+import "time"
+
+// TwoFactor is the schema for the time-based one-time password secret of a user in the database
+type TwoFactor struct {
+	UserID       string    `json:"userId" gorm:"primaryKey"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Secret       string    `json:"secret"`
+	Enabled      bool      `json:"enabled"`
+	LastUsedStep int64     `json:"lastUsedStep"`
+}
+
+// TwoFactorRecoveryCode is the schema for a single-use code that replaces a one-time password in the database
+type TwoFactorRecoveryCode struct {
+	ID       string `json:"id" gorm:"primaryKey"`
+	UserID   string `json:"userId" gorm:"index"`
+	CodeHash string `json:"codeHash" gorm:"index"`
+}
+
+// TwoFactorSetup is the schema for the information a user needs to register an authenticator app
+type TwoFactorSetup struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioningUri"`
+	RecoveryCodes   []string `json:"recoveryCodes"`
+}
@@ -1,12 +1,16 @@
 package users_models
 
 import (
+	"encoding/json"
+	"errors"
+	"io"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// Role represents the level of authorization of the user
+// Role represents the level of authorization of the user. Roles are registered in the database, so the constants below
+// are only the ones the application relies on
 type Role string
 
 const (
@@ -20,6 +24,24 @@ const (
 	Patient Role = "PATIENT"
 )
 
+// MarshalGQL writes the role as a GraphQL string
+func (r Role) MarshalGQL(w io.Writer) {
+	data, _ := json.Marshal(string(r))
+	w.Write(data)
+}
+
+// UnmarshalGQL reads the role from a GraphQL string
+func (r *Role) UnmarshalGQL(v interface{}) error {
+	value, ok := v.(string)
+	if !ok {
+		return errors.New("roles must be strings")
+	}
+
+	*r = Role(value)
+
+	return nil
+}
+
 // User is the schema for a user in the database
 type User struct {
 	ID        string         `json:"id" gorm:"primaryKey"`
@@ -31,3 +53,10 @@ type User struct {
 	Active    bool           `json:"active"`
 	Role      Role           `json:"role"`
 }
+
+// LockedUser is the schema for a user that cannot authenticate due to too many failed attempts
+type LockedUser struct {
+	ID          string    `json:"id"`
+	Email       string    `json:"email"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
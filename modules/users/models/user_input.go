@@ -1,9 +1,12 @@
 package users_models
 
+import "time"
+
 // CreateUserInput is the schema for information needed to create a user
 type CreateUserInput struct {
-	Email string `json:"email"`
-	Role  Role   `json:"role"`
+	Email     string `json:"email"`
+	Role      Role   `json:"role"`
+	InvitedBy string `json:"invitedBy"`
 }
 
 // CreateUserWithPasswordInput is the schema for information needed to create a user
@@ -18,3 +21,39 @@ type UpdateUserInput struct {
 	Active bool `json:"active"`
 	Role   Role `json:"role"`
 }
+
+// ChangeMyPasswordInput is the schema for information needed for a user to change their own password
+type ChangeMyPasswordInput struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+}
+
+// ChangeMyEmailInput is the schema for information needed for a user to change their own email
+type ChangeMyEmailInput struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewEmail        string `json:"newEmail"`
+}
+
+// UserSort represents the order in which users are listed
+type UserSort string
+
+const (
+	// CreatedAtAsc lists the oldest users first
+	CreatedAtAsc UserSort = "CREATED_AT_ASC"
+	// CreatedAtDesc lists the newest users first
+	CreatedAtDesc UserSort = "CREATED_AT_DESC"
+	// EmailAsc lists users alphabetically by email
+	EmailAsc UserSort = "EMAIL_ASC"
+	// EmailDesc lists users in reverse alphabetical order by email
+	EmailDesc UserSort = "EMAIL_DESC"
+)
+
+// UsersFilterInput is the schema for the filters that can be applied when listing users
+type UsersFilterInput struct {
+	Role               *Role      `json:"role"`
+	Active             *bool      `json:"active"`
+	CreatedSince       *time.Time `json:"createdSince"`
+	CreatedUntil       *time.Time `json:"createdUntil"`
+	HasProfile         *bool      `json:"hasProfile"`
+	HasActiveTreatment *bool      `json:"hasActiveTreatment"`
+}
@@ -0,0 +1,16 @@
+package users_models
+
+// UserPage is the schema for a page of users, along with the cursor needed to get the next one
+type UserPage struct {
+	TotalCount  int64   `json:"totalCount"`
+	Users       []*User `json:"users"`
+	EndCursor   *string `json:"endCursor"`
+	HasNextPage bool    `json:"hasNextPage"`
+}
+
+// UserCursor is the schema for the position of a user in a list, encoded in the cursors of UserPage
+type UserCursor struct {
+	Sort  UserSort `json:"sort"`
+	Value string   `json:"value"`
+	ID    string   `json:"id"`
+}
@@ -9,6 +9,7 @@ import (
 	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 	users_templates "github.com/guicostaarantes/psi-server/modules/users/templates"
+	"github.com/guicostaarantes/psi-server/utils/digest"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/orm"
 	"github.com/guicostaarantes/psi-server/utils/token"
@@ -16,6 +17,7 @@ import (
 
 // AskResetPasswordService is a service that sends a token to the user's email so that they can reset their password
 type AskResetPasswordService struct {
+	DigestUtil               digest.IDigestUtil
 	IdentifierUtil           identifier.IIdentifierUtil
 	OrmUtil                  orm.IOrmUtil
 	TokenUtil                token.ITokenUtil
@@ -59,11 +61,16 @@ func (s AskResetPasswordService) Execute(email string) error {
 		return tokenErr
 	}
 
+	tokenHash, digestErr := s.DigestUtil.Digest(token)
+	if digestErr != nil {
+		return digestErr
+	}
+
 	reset := &users_models.ResetPassword{
 		UserID:    user.ID,
 		IssuedAt:  time.Now(),
 		ExpiresAt: time.Now().Add(s.ExpireResetTokenDuration),
-		Token:     token,
+		TokenHash: tokenHash,
 		Redeemed:  false,
 	}
 
@@ -2,27 +2,42 @@ package users_services
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 	"github.com/guicostaarantes/psi-server/utils/hash"
 	"github.com/guicostaarantes/psi-server/utils/orm"
-	"github.com/guicostaarantes/psi-server/utils/serializing"
-	"github.com/guicostaarantes/psi-server/utils/token"
 )
 
 // AuthenticateUserService is a service that exchanges credentials for an access token
 type AuthenticateUserService struct {
-	HashUtil                hash.IHashUtil
-	OrmUtil                 orm.IOrmUtil
-	SerializingUtil         serializing.ISerializingUtil
-	TokenUtil               token.ITokenUtil
-	ExpireAuthTokenDuration time.Duration
+	HashUtil                    hash.IHashUtil
+	OrmUtil                     orm.IOrmUtil
+	DeleteCooldownsService      *cooldowns_services.DeleteCooldownsService
+	GetCooldownService          *cooldowns_services.GetCooldownService
+	GetCooldownsService         *cooldowns_services.GetCooldownsService
+	CreateSessionService        *CreateSessionService
+	RegisterLoginFailureService *RegisterLoginFailureService
+	LoginFailedDelayDuration    time.Duration
 }
 
 // Execute is the method that runs the business logic of the service
 func (s AuthenticateUserService) Execute(authInput *users_models.AuthenticateUserInput) (*users_models.Authentication, error) {
 
+	if authInput.IPAddress != "" {
+		ipLock, getErr := s.GetCooldownService.Execute(authInput.IPAddress, cooldowns_models.IPAddress, cooldowns_models.LoginLocked)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if ipLock != nil {
+			return nil, fmt.Errorf("authentication is blocked for this ip address until %s", ipLock.ValidUntil.Format(time.RFC3339))
+		}
+	}
+
 	user := users_models.User{}
 
 	result := s.OrmUtil.Db().Where("email = ?", authInput.Email).Limit(1).Find(&user)
@@ -31,37 +46,72 @@ func (s AuthenticateUserService) Execute(authInput *users_models.AuthenticateUse
 	}
 
 	if user.ID == "" || !user.Active {
+		registerErr := s.RegisterLoginFailureService.Execute("", authInput.IPAddress)
+		if registerErr != nil {
+			return nil, registerErr
+		}
 		return nil, errors.New("incorrect credentials")
 	}
 
+	userLock, getErr := s.GetCooldownService.Execute(user.ID, cooldowns_models.User, cooldowns_models.LoginLocked)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	// Locked users get the same error as unknown emails and wrong passwords, so that the error does not reveal which
+	// emails are registered. Coordinators see until when each user is locked in the list of locked users
+	if userLock != nil {
+		return nil, errors.New("incorrect credentials")
+	}
+
+	// Each failure after the first one doubles the time the user needs to wait before trying again
+	failures, getErr := s.GetCooldownsService.Execute(user.ID, cooldowns_models.User, cooldowns_models.LoginFailed)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if len(failures) > 1 {
+		retryAt := failures[len(failures)-1].CreatedAt.Add(s.LoginFailedDelayDuration * time.Duration(1<<(len(failures)-2)))
+		if retryAt.After(time.Now()) {
+			return nil, errors.New("incorrect credentials")
+		}
+	}
+
 	compareErr := s.HashUtil.Compare(authInput.Password, user.Password)
 	if compareErr != nil {
 		if compareErr.Error() == s.HashUtil.GetWrongPasswordError() {
+			registerErr := s.RegisterLoginFailureService.Execute(user.ID, authInput.IPAddress)
+			if registerErr != nil {
+				return nil, registerErr
+			}
 			return nil, errors.New("incorrect credentials")
 		}
 		return nil, compareErr
 	}
 
-	token, tokenErr := s.TokenUtil.GenerateToken(user.ID, s.ExpireAuthTokenDuration)
-	if tokenErr != nil {
-		return nil, tokenErr
-	}
+	// Hashes created with an outdated algorithm or parameters are replaced while the plain password is at hand
+	if s.HashUtil.NeedsRehash(user.Password) {
+		hashedPwd, hashErr := s.HashUtil.Hash(authInput.Password)
+		if hashErr != nil {
+			return nil, hashErr
+		}
 
-	auth := &users_models.Authentication{
-		UserID:    user.ID,
-		IssuedAt:  time.Now(),
-		ExpiresAt: time.Now().Add(s.ExpireAuthTokenDuration),
-		Token:     token,
+		user.Password = hashedPwd
+
+		result = s.OrmUtil.Db().Save(&user)
+		if result.Error != nil {
+			return nil, result.Error
+		}
 	}
 
-	result = s.OrmUtil.Db().Where("user_id = ?", auth.UserID).Delete(&users_models.Authentication{})
-	if result.Error != nil {
-		return nil, result.Error
+	auth, sessionErr := s.CreateSessionService.Execute(&user, authInput.OtpCode, authInput.Device, authInput.IPAddress, authInput.UserAgent)
+	if sessionErr != nil {
+		return nil, sessionErr
 	}
 
-	result = s.OrmUtil.Db().Create(&auth)
-	if result.Error != nil {
-		return nil, result.Error
+	deleteErr := s.DeleteCooldownsService.Execute(user.ID, cooldowns_models.User, cooldowns_models.LoginFailed)
+	if deleteErr != nil {
+		return nil, deleteErr
 	}
 
 	return auth, nil
@@ -0,0 +1,200 @@
+package users_services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/oidc"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// AuthenticateWithOidcService is a service that exchanges the code given by the OpenID Connect provider for an access token.
+// Users are matched by the identity they linked in their first login through the provider. Otherwise, they are matched by
+// their verified email and, if none is found, a user with the default role is created, linking the identity in both cases.
+// Users and IP addresses locked after too many failed attempts cannot log in through the provider either
+type AuthenticateWithOidcService struct {
+	DigestUtil           digest.IDigestUtil
+	IdentifierUtil       identifier.IIdentifierUtil
+	OidcUtil             oidc.IOidcUtil
+	OrmUtil              orm.IOrmUtil
+	GetCooldownService   *cooldowns_services.GetCooldownService
+	CreateSessionService *CreateSessionService
+	OidcDefaultRole      users_models.Role
+}
+
+// Execute is the method that runs the business logic of the service
+func (s AuthenticateWithOidcService) Execute(authInput *users_models.AuthenticateWithOidcInput) (*users_models.Authentication, error) {
+
+	if authInput.IPAddress != "" {
+		ipLock, getErr := s.GetCooldownService.Execute(authInput.IPAddress, cooldowns_models.IPAddress, cooldowns_models.LoginLocked)
+		if getErr != nil {
+			return nil, getErr
+		}
+
+		if ipLock != nil {
+			return nil, fmt.Errorf("authentication is blocked for this ip address until %s", ipLock.ValidUntil.Format(time.RFC3339))
+		}
+	}
+
+	stateHash, digestErr := s.DigestUtil.Digest(authInput.State)
+	if digestErr != nil {
+		return nil, digestErr
+	}
+
+	authorization := users_models.OidcAuthorization{}
+
+	result := s.OrmUtil.Db().Where("state_hash = ?", stateHash).Limit(1).Find(&authorization)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if authorization.StateHash == "" || authorization.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("invalid state")
+	}
+
+	// Each state can only be used once, even if the exchange of the code fails
+	result = s.OrmUtil.Db().Delete(&authorization)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	// The state must come back to the same client that started the authorization, otherwise someone could make a victim
+	// log in to the account of the person that started it
+	bindingHash, digestErr := s.DigestUtil.Digest(authInput.Binding)
+	if digestErr != nil {
+		return nil, digestErr
+	}
+
+	if bindingHash != authorization.BindingHash {
+		return nil, errors.New("invalid state")
+	}
+
+	identity, exchangeErr := s.OidcUtil.ExchangeCode(authInput.Code, authorization.CodeVerifier, authorization.Nonce)
+	if exchangeErr != nil {
+		return nil, exchangeErr
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, errors.New("email is not verified")
+	}
+
+	link := users_models.OidcIdentity{}
+
+	result = s.OrmUtil.Db().Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).Limit(1).Find(&link)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	user := users_models.User{}
+
+	if link.UserID != "" {
+		result = s.OrmUtil.Db().Where("id = ?", link.UserID).Limit(1).Find(&user)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if user.ID == "" {
+			return nil, errors.New("user is not registered")
+		}
+	} else {
+		linkedUser, linkErr := s.linkUser(identity)
+		if linkErr != nil {
+			return nil, linkErr
+		}
+		user = *linkedUser
+	}
+
+	if !user.Active {
+		return nil, errors.New("user is not active")
+	}
+
+	userLock, getErr := s.GetCooldownService.Execute(user.ID, cooldowns_models.User, cooldowns_models.LoginLocked)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if userLock != nil {
+		return nil, fmt.Errorf("authentication is blocked for this user until %s", userLock.ValidUntil.Format(time.RFC3339))
+	}
+
+	return s.CreateSessionService.Execute(&user, authInput.OtpCode, authInput.Device, authInput.IPAddress, authInput.UserAgent)
+
+}
+
+// linkUser links an identity that logs in for the first time to the user with the same email, creating a user with the
+// default role if none is found
+func (s AuthenticateWithOidcService) linkUser(identity *oidc.Identity) (*users_models.User, error) {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("email = ?", identity.Email).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if user.ID != "" {
+		linked := int64(0)
+
+		// a user linked to another account of the same provider does not get a second one, since the provider may have given
+		// their email to someone else
+		result = s.OrmUtil.Db().Model(&users_models.OidcIdentity{}).Where("issuer = ? AND user_id = ?", identity.Issuer, user.ID).Count(&linked)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		if linked > 0 {
+			return nil, errors.New("user is linked to another identity")
+		}
+	} else {
+		if s.OidcDefaultRole == "" {
+			return nil, errors.New("user is not registered")
+		}
+
+		_, userID, userIDErr := s.IdentifierUtil.GenerateIdentifier()
+		if userIDErr != nil {
+			return nil, userIDErr
+		}
+
+		user = &users_models.User{
+			ID:     userID,
+			Email:  identity.Email,
+			Active: true,
+			Role:   s.OidcDefaultRole,
+		}
+
+		result = s.OrmUtil.Db().Create(&user)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+	}
+
+	if !user.Active {
+		return user, nil
+	}
+
+	link := &users_models.OidcIdentity{
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		UserID:  user.ID,
+	}
+
+	result = s.OrmUtil.Db().Create(&link)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	// invited users that log in through the provider do not need to create a password anymore
+	result = s.OrmUtil.Db().Model(&users_models.Invitation{}).Where("user_id = ? AND status = ?", user.ID, users_models.InvitationPending).Update("status", users_models.InvitationAccepted)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return user, nil
+
+}
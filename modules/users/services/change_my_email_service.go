@@ -0,0 +1,134 @@
+package users_services
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"time"
+
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	users_templates "github.com/guicostaarantes/psi-server/modules/users/templates"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/hash"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/token"
+)
+
+// ChangeMyEmailService is a service that sends a token to the new email of a user so that they can confirm the change
+type ChangeMyEmailService struct {
+	DigestUtil                     digest.IDigestUtil
+	HashUtil                       hash.IHashUtil
+	IdentifierUtil                 identifier.IIdentifierUtil
+	MatchUtil                      match.IMatchUtil
+	OrmUtil                        orm.IOrmUtil
+	TokenUtil                      token.ITokenUtil
+	ExpireEmailChangeTokenDuration time.Duration
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ChangeMyEmailService) Execute(userID string, input *users_models.ChangeMyEmailInput) error {
+
+	emailErr := s.MatchUtil.IsEmailValid(input.NewEmail)
+	if emailErr != nil {
+		return emailErr
+	}
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	compareErr := s.HashUtil.Compare(input.CurrentPassword, user.Password)
+	if compareErr != nil {
+		if compareErr.Error() == s.HashUtil.GetWrongPasswordError() {
+			return errors.New("incorrect credentials")
+		}
+		return compareErr
+	}
+
+	userWithSameEmail := users_models.User{}
+
+	result = s.OrmUtil.Db().Where("email = ?", input.NewEmail).Limit(1).Find(&userWithSameEmail)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if userWithSameEmail.ID != "" {
+		return errors.New("user with same email already exists")
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Delete(&users_models.EmailChange{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	token, tokenErr := s.TokenUtil.GenerateToken(user.ID, s.ExpireEmailChangeTokenDuration)
+	if tokenErr != nil {
+		return tokenErr
+	}
+
+	tokenHash, digestErr := s.DigestUtil.Digest(token)
+	if digestErr != nil {
+		return digestErr
+	}
+
+	emailChange := &users_models.EmailChange{
+		UserID:    user.ID,
+		NewEmail:  input.NewEmail,
+		TokenHash: tokenHash,
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(s.ExpireEmailChangeTokenDuration),
+	}
+
+	_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if mailIDErr != nil {
+		return mailIDErr
+	}
+
+	templ, templErr := template.New("ConfirmEmailChangeEmail").Parse(users_templates.ConfirmEmailChangeEmailTemplate)
+	if templErr != nil {
+		return templErr
+	}
+
+	buff := new(bytes.Buffer)
+
+	templ.Execute(buff, map[string]string{
+		"SiteURL": os.Getenv("PSI_SITE_URL"),
+		"Token":   token,
+	})
+
+	mail := &mails_models.TransientMailMessage{
+		ID:          mailID,
+		FromAddress: "relacionamento@psi.com.br",
+		FromName:    "Relacionamento PSI",
+		To:          input.NewEmail,
+		Cc:          "",
+		Cco:         "",
+		Subject:     "Confirme seu novo email no PSI",
+		Html:        buff.String(),
+		Processed:   false,
+	}
+
+	result = s.OrmUtil.Db().Create(&mail)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Create(&emailChange)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -0,0 +1,62 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/hash"
+	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ChangeMyPasswordService is a service that changes the password of a user based on their current one,
+// ending all of their other sessions
+type ChangeMyPasswordService struct {
+	HashUtil                      hash.IHashUtil
+	MatchUtil                     match.IMatchUtil
+	OrmUtil                       orm.IOrmUtil
+	RevokeAllOtherSessionsService *RevokeAllOtherSessionsService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ChangeMyPasswordService) Execute(userID string, sessionID string, input *users_models.ChangeMyPasswordInput) error {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	compareErr := s.HashUtil.Compare(input.CurrentPassword, user.Password)
+	if compareErr != nil {
+		if compareErr.Error() == s.HashUtil.GetWrongPasswordError() {
+			return errors.New("incorrect credentials")
+		}
+		return compareErr
+	}
+
+	passwordErr := s.MatchUtil.IsPasswordStrong(input.NewPassword, user.Email)
+	if passwordErr != nil {
+		return passwordErr
+	}
+
+	hashedPwd, hashErr := s.HashUtil.Hash(input.NewPassword)
+	if hashErr != nil {
+		return hashErr
+	}
+
+	user.Password = hashedPwd
+
+	result = s.OrmUtil.Db().Save(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return s.RevokeAllOtherSessionsService.Execute(userID, sessionID)
+
+}
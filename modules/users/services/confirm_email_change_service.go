@@ -0,0 +1,73 @@
+package users_services
+
+import (
+	"errors"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ConfirmEmailChangeService is a service that applies the change of email of a user based on a token sent to their new email
+type ConfirmEmailChangeService struct {
+	DigestUtil digest.IDigestUtil
+	OrmUtil    orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ConfirmEmailChangeService) Execute(token string) error {
+
+	tokenHash, digestErr := s.DigestUtil.Digest(token)
+	if digestErr != nil {
+		return digestErr
+	}
+
+	emailChange := &users_models.EmailChange{}
+
+	result := s.OrmUtil.Db().Where("token_hash = ?", tokenHash).Limit(1).Find(&emailChange)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if emailChange.UserID == "" || emailChange.ExpiresAt.Before(time.Now()) {
+		return errors.New("invalid token")
+	}
+
+	user := &users_models.User{}
+
+	result = s.OrmUtil.Db().Where("id = ?", emailChange.UserID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" || !user.Active {
+		return errors.New("invalid token")
+	}
+
+	userWithSameEmail := users_models.User{}
+
+	result = s.OrmUtil.Db().Where("email = ?", emailChange.NewEmail).Limit(1).Find(&userWithSameEmail)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if userWithSameEmail.ID != "" {
+		return errors.New("user with same email already exists")
+	}
+
+	user.Email = emailChange.NewEmail
+
+	result = s.OrmUtil.Db().Save(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Delete(&emailChange)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
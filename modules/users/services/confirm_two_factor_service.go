@@ -0,0 +1,51 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/otp"
+)
+
+// ConfirmTwoFactorService is a service that enables the second authentication factor of a user
+// after they prove their authenticator app generates valid codes
+type ConfirmTwoFactorService struct {
+	OrmUtil orm.IOrmUtil
+	OtpUtil otp.IOtpUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ConfirmTwoFactorService) Execute(userID string, code string) error {
+
+	twoFactor := &users_models.TwoFactor{}
+
+	result := s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&twoFactor)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if twoFactor.UserID == "" {
+		return errors.New("resource not found")
+	}
+
+	if twoFactor.Enabled {
+		return errors.New("two factor authentication is already enabled")
+	}
+
+	step, valid := s.OtpUtil.ValidateCode(code, twoFactor.Secret, twoFactor.LastUsedStep)
+	if !valid {
+		return errors.New("invalid two factor code")
+	}
+
+	twoFactor.Enabled = true
+	twoFactor.LastUsedStep = step
+
+	result = s.OrmUtil.Db().Save(&twoFactor)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -0,0 +1,44 @@
+package users_services
+
+import (
+	"errors"
+	"regexp"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z_]*$`)
+
+// CreateRoleService is a service that registers a new role. It starts without permissions, which are granted to it by
+// SetRolePermissionsService
+type CreateRoleService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s CreateRoleService) Execute(name users_models.Role) error {
+
+	if !roleNamePattern.MatchString(string(name)) {
+		return errors.New("role names must have only uppercase letters and underscores")
+	}
+
+	existingRole := users_models.RoleDefinition{}
+
+	result := s.OrmUtil.Db().Where("name = ?", name).Limit(1).Find(&existingRole)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if existingRole.Name != "" {
+		return errors.New("role already exists")
+	}
+
+	result = s.OrmUtil.Db().Create(&users_models.RoleDefinition{Name: name})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -0,0 +1,118 @@
+package users_services
+
+import (
+	"errors"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/token"
+)
+
+// CreateSessionService is a service that issues an access token for a user whose identity was already confirmed,
+// asking for the second factor if they enabled it
+type CreateSessionService struct {
+	DigestUtil                   digest.IDigestUtil
+	IdentifierUtil               identifier.IIdentifierUtil
+	OrmUtil                      orm.IOrmUtil
+	SerializingUtil              serializing.ISerializingUtil
+	TokenUtil                    token.ITokenUtil
+	RegisterLoginFailureService  *RegisterLoginFailureService
+	ValidateTwoFactorCodeService *ValidateTwoFactorCodeService
+	ExpireAuthTokenDuration      time.Duration
+	ExpireRefreshTokenDuration   time.Duration
+	TwoFactorRequiredRoles       []users_models.Role
+}
+
+// Execute is the method that runs the business logic of the service
+func (s CreateSessionService) Execute(user *users_models.User, otpCode string, device string, ipAddress string, userAgent string) (*users_models.Authentication, error) {
+
+	twoFactor := &users_models.TwoFactor{}
+
+	result := s.OrmUtil.Db().Where("user_id = ? AND enabled = ?", user.ID, true).Limit(1).Find(&twoFactor)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	// Users of roles that require a second factor but did not set it up yet get a session that only allows them to do so
+	twoFactorPending := false
+
+	if twoFactor.UserID != "" {
+		if otpCode == "" {
+			return nil, errors.New("two factor code required")
+		}
+
+		validateErr := s.ValidateTwoFactorCodeService.Execute(user.ID, otpCode)
+		if validateErr != nil {
+			if validateErr.Error() == "invalid two factor code" {
+				registerErr := s.RegisterLoginFailureService.Execute(user.ID, ipAddress)
+				if registerErr != nil {
+					return nil, registerErr
+				}
+			}
+			return nil, validateErr
+		}
+	} else {
+		for _, role := range s.TwoFactorRequiredRoles {
+			if role == user.Role {
+				twoFactorPending = true
+			}
+		}
+	}
+
+	_, authID, authIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if authIDErr != nil {
+		return nil, authIDErr
+	}
+
+	refreshToken, refreshTokenErr := s.TokenUtil.GenerateToken(authID, s.ExpireRefreshTokenDuration)
+	if refreshTokenErr != nil {
+		return nil, refreshTokenErr
+	}
+
+	refreshTokenHash, digestErr := s.DigestUtil.Digest(refreshToken)
+	if digestErr != nil {
+		return nil, digestErr
+	}
+
+	claims, claimsErr := s.SerializingUtil.VariableToBytes(&users_models.AuthClaims{
+		UserID:           user.ID,
+		SessionID:        authID,
+		TwoFactorPending: twoFactorPending,
+	})
+	if claimsErr != nil {
+		return nil, claimsErr
+	}
+
+	token, tokenErr := s.TokenUtil.GenerateToken(string(claims), s.ExpireAuthTokenDuration)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	auth := &users_models.Authentication{
+		ID:               authID,
+		UserID:           user.ID,
+		IssuedAt:         time.Now(),
+		ExpiresAt:        time.Now().Add(s.ExpireAuthTokenDuration),
+		LastSeenAt:       time.Now(),
+		Token:            token,
+		RefreshToken:     refreshToken,
+		RefreshTokenHash: refreshTokenHash,
+		RefreshExpiresAt: time.Now().Add(s.ExpireRefreshTokenDuration),
+		Device:           device,
+		IPAddress:        ipAddress,
+		UserAgent:        userAgent,
+		TwoFactorPending: twoFactorPending,
+	}
+
+	result = s.OrmUtil.Db().Create(&auth)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return auth, nil
+
+}
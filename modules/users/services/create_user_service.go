@@ -1,30 +1,18 @@
 package users_services
 
 import (
-	"bytes"
-	"errors"
-	"html/template"
-	"os"
-	"time"
-
-	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
-	users_templates "github.com/guicostaarantes/psi-server/modules/users/templates"
 	"github.com/guicostaarantes/psi-server/utils/identifier"
 	"github.com/guicostaarantes/psi-server/utils/match"
 	"github.com/guicostaarantes/psi-server/utils/orm"
-	"github.com/guicostaarantes/psi-server/utils/serializing"
-	"github.com/guicostaarantes/psi-server/utils/token"
 )
 
-// CreateUserService is a service that creates users and sends emails so that the owner can assign a password
+// CreateUserService is a service that creates users and sends invitations so that the owner can assign a password
 type CreateUserService struct {
-	IdentifierUtil          identifier.IIdentifierUtil
-	MatchUtil               match.IMatchUtil
-	OrmUtil                 orm.IOrmUtil
-	SerializingUtil         serializing.ISerializingUtil
-	TokenUtil               token.ITokenUtil
-	ExpireAuthTokenDuration time.Duration
+	IdentifierUtil        identifier.IIdentifierUtil
+	MatchUtil             match.IMatchUtil
+	OrmUtil               orm.IOrmUtil
+	SendInvitationService *SendInvitationService
 }
 
 // Execute is the method that runs the business logic of the service
@@ -52,6 +40,11 @@ func (s CreateUserService) Execute(userInput *users_models.CreateUserInput, whic
 		return userIDErr
 	}
 
+	_, invitationID, invitationIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if invitationIDErr != nil {
+		return invitationIDErr
+	}
+
 	user := &users_models.User{
 		ID:     userID,
 		Active: true,
@@ -59,67 +52,23 @@ func (s CreateUserService) Execute(userInput *users_models.CreateUserInput, whic
 		Role:   userInput.Role,
 	}
 
-	token, tokenErr := s.TokenUtil.GenerateToken(user.ID, s.ExpireAuthTokenDuration)
-	if tokenErr != nil {
-		return tokenErr
-	}
-
-	reset := &users_models.ResetPassword{
+	invitation := &users_models.Invitation{
+		ID:        invitationID,
 		UserID:    userID,
-		IssuedAt:  time.Now(),
-		ExpiresAt: time.Now().Add(s.ExpireAuthTokenDuration),
-		Token:     token,
-		Redeemed:  false,
-	}
-
-	_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
-	if mailIDErr != nil {
-		return mailIDErr
-	}
-
-	templateToUse := ""
-
-	switch whichTemplate {
-	case "CREATE_PATIENT":
-		templateToUse = users_templates.CreateUserEmailTemplate
-	case "INVITE_PSYCHOLOGIST":
-		templateToUse = users_templates.InvitePsychologistEmailTemplate
-	default:
-		return errors.New("template not found")
-	}
-
-	templ, templErr := template.New("CreateUserEmail").Parse(templateToUse)
-	if templErr != nil {
-		return templErr
+		Email:     userInput.Email,
+		Role:      userInput.Role,
+		Template:  whichTemplate,
+		InvitedBy: userInput.InvitedBy,
 	}
 
-	buff := new(bytes.Buffer)
-
-	templ.Execute(buff, map[string]string{
-		"SiteURL": os.Getenv("PSI_SITE_URL"),
-		"Token":   token,
-	})
-
-	mail := &mails_models.TransientMailMessage{
-		ID:          mailID,
-		FromAddress: "relacionamento@psi.com.br",
-		FromName:    "Relacionamento PSI",
-		To:          user.Email,
-		Cc:          "",
-		Cco:         "",
-		Subject:     "Bem-vindo ao PSI",
-		Html:        buff.String(),
-		Processed:   false,
-	}
-
-	result = s.OrmUtil.Db().Create(&mail)
+	result = s.OrmUtil.Db().Create(&invitation)
 	if result.Error != nil {
 		return result.Error
 	}
 
-	result = s.OrmUtil.Db().Create(&reset)
-	if result.Error != nil {
-		return result.Error
+	sendErr := s.SendInvitationService.Execute(invitation)
+	if sendErr != nil {
+		return sendErr
 	}
 
 	result = s.OrmUtil.Db().Create(&user)
@@ -28,11 +28,16 @@ func (s CreateUserWithPasswordService) Execute(userInput *users_models.CreateUse
 		return emailErr
 	}
 
-	passwordErr := s.MatchUtil.IsPasswordStrong(userInput.Password)
+	passwordErr := s.MatchUtil.IsPasswordStrong(userInput.Password, userInput.Email)
 	if passwordErr != nil {
 		return passwordErr
 	}
 
+	roleErr := validateRole(s.OrmUtil, userInput.Role)
+	if roleErr != nil {
+		return roleErr
+	}
+
 	userWithSameEmail := users_models.User{}
 
 	result := s.OrmUtil.Db().Where("email = ?", userInput.Email).Limit(1).Find(&userWithSameEmail)
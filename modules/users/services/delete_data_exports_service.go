@@ -0,0 +1,48 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/file_storage"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DeleteDataExportsService is a service that deletes copies of personal data, removing their files from the storage
+// if no other copy is using them
+type DeleteDataExportsService struct {
+	ExportStorageUtil file_storage.IFileStorageUtil
+	OrmUtil           orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s DeleteDataExportsService) Execute(exports []*users_models.DataExport) error {
+
+	for _, export := range exports {
+		result := s.OrmUtil.Db().Delete(&export)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if export.FileName == "" {
+			continue
+		}
+
+		var exportsUsingFile int64
+
+		result = s.OrmUtil.Db().Model(&users_models.DataExport{}).Where("file_name = ?", export.FileName).Count(&exportsUsingFile)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if exportsUsingFile > 0 {
+			continue
+		}
+
+		deleteErr := s.ExportStorageUtil.DeleteFile(export.FileName)
+		if deleteErr != nil {
+			return deleteErr
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,42 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/hash"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DeleteMyAccountService is a service that erases the user that requested it after checking their password
+type DeleteMyAccountService struct {
+	HashUtil         hash.IHashUtil
+	OrmUtil          orm.IOrmUtil
+	EraseUserService *EraseUserService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s DeleteMyAccountService) Execute(userID string, currentPassword string) error {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	compareErr := s.HashUtil.Compare(currentPassword, user.Password)
+	if compareErr != nil {
+		if compareErr.Error() == s.HashUtil.GetWrongPasswordError() {
+			return errors.New("incorrect credentials")
+		}
+		return compareErr
+	}
+
+	return s.EraseUserService.Execute(user.ID)
+
+}
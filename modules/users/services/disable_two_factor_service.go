@@ -0,0 +1,55 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// DisableTwoFactorService is a service that removes the second authentication factor of a user,
+// unless it is mandatory for their role
+type DisableTwoFactorService struct {
+	OrmUtil                      orm.IOrmUtil
+	ValidateTwoFactorCodeService *ValidateTwoFactorCodeService
+	TwoFactorRequiredRoles       []users_models.Role
+}
+
+// Execute is the method that runs the business logic of the service
+func (s DisableTwoFactorService) Execute(userID string, code string) error {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	for _, role := range s.TwoFactorRequiredRoles {
+		if role == user.Role {
+			return errors.New("two factor authentication is mandatory for this role")
+		}
+	}
+
+	validateErr := s.ValidateTwoFactorCodeService.Execute(userID, code)
+	if validateErr != nil {
+		return validateErr
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Delete(&users_models.TwoFactorRecoveryCode{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Delete(&users_models.TwoFactor{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
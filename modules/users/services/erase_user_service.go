@@ -0,0 +1,165 @@
+package users_services
+
+import (
+	"errors"
+	"strings"
+
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	profiles_services "github.com/guicostaarantes/psi-server/modules/profiles/services"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// EraseUserService is a service that fulfills a deletion request of a user, anonymizing their profiles and their entries
+// in the audit log, and removing their personal data, credentials and sessions
+type EraseUserService struct {
+	OrmUtil                  orm.IOrmUtil
+	DeleteDataExportsService *DeleteDataExportsService
+	ErasePatientService      *profiles_services.ErasePatientService
+	ErasePsychologistService *profiles_services.ErasePsychologistService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s EraseUserService) Execute(id string) error {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", id).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	// the profiles are read before being erased, since the audit log refers to them as targets too
+	targetIDs := []string{user.ID}
+
+	patients := []*profiles_models.Patient{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Find(&patients)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, patient := range patients {
+		targetIDs = append(targetIDs, patient.ID)
+	}
+
+	psychologists := []*profiles_models.Psychologist{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Find(&psychologists)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, psychologist := range psychologists {
+		targetIDs = append(targetIDs, psychologist.ID)
+	}
+
+	patientErr := s.ErasePatientService.Execute(user.ID)
+	if patientErr != nil {
+		return patientErr
+	}
+
+	psychologistErr := s.ErasePsychologistService.Execute(user.ID)
+	if psychologistErr != nil {
+		return psychologistErr
+	}
+
+	exports := []*users_models.DataExport{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Find(&exports)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	exportsErr := s.DeleteDataExportsService.Execute(exports)
+	if exportsErr != nil {
+		return exportsErr
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Delete(&users_models.Authentication{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Delete(&users_models.EmailChange{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Delete(&users_models.OidcIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Delete(&users_models.ResetPassword{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// Invitations are kept without the email, and the ones that were not accepted cannot be accepted anymore
+	result = s.OrmUtil.Db().Model(&users_models.Invitation{}).Where("user_id = ? AND status = ?", user.ID, users_models.InvitationPending).Update("status", users_models.InvitationRevoked)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Model(&users_models.Invitation{}).Where("user_id = ?", user.ID).Update("email", "")
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Delete(&users_models.TwoFactor{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", user.ID).Delete(&users_models.TwoFactorRecoveryCode{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("profile_id = ?", user.ID).Delete(&cooldowns_models.Cooldown{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where(&mails_models.TransientMailMessage{To: user.Email}).Delete(&mails_models.TransientMailMessage{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	// entries are kept for accountability, but without the arguments and the address that could identify the user
+	auditQuery := s.OrmUtil.Db().Model(&audits_models.AuditEntry{}).Where("actor_id = ? OR impersonator_id = ?", user.ID, user.ID)
+	for _, targetID := range targetIDs {
+		escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(targetID)
+		auditQuery = auditQuery.Or("(',' || target_ids || ',') LIKE ? ESCAPE '\\'", "%,"+escaped+",%")
+	}
+
+	result = auditQuery.Updates(map[string]interface{}{"arguments": "", "ip_address": ""})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	user.Email = ""
+	user.Password = ""
+	user.Active = false
+
+	result = s.OrmUtil.Db().Save(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Delete(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -0,0 +1,42 @@
+package users_services
+
+import (
+	"errors"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/file_storage"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetDataExportFileService is a service that serves a copy of personal data based on a token sent to the email of its user.
+// The token alone is not enough, the copy is only served to its user while they are logged in
+type GetDataExportFileService struct {
+	DigestUtil        digest.IDigestUtil
+	ExportStorageUtil file_storage.IFileStorageUtil
+	OrmUtil           orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetDataExportFileService) Execute(token string, userID string) ([]byte, error) {
+
+	tokenHash, digestErr := s.DigestUtil.Digest(token)
+	if digestErr != nil {
+		return nil, digestErr
+	}
+
+	export := users_models.DataExport{}
+
+	result := s.OrmUtil.Db().Where("token_hash = ? AND processed = ?", tokenHash, true).Limit(1).Find(&export)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if export.ID == "" || export.UserID != userID || export.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("invalid token")
+	}
+
+	return s.ExportStorageUtil.ReadFile(export.FileName)
+
+}
@@ -0,0 +1,66 @@
+package users_services
+
+import (
+	"time"
+
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetLockedUsersService is a service that gets all users whose authentication is currently locked
+type GetLockedUsersService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetLockedUsersService) Execute() ([]*users_models.LockedUser, error) {
+
+	locks := []*cooldowns_models.Cooldown{}
+
+	result := s.OrmUtil.Db().Where(
+		"profile_type = ? AND cooldown_type = ? AND valid_until > ?",
+		cooldowns_models.User,
+		cooldowns_models.LoginLocked,
+		time.Now(),
+	).Find(&locks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	lockedUntil := map[string]time.Time{}
+	userIDs := []string{}
+
+	for _, lock := range locks {
+		if _, exists := lockedUntil[lock.ProfileID]; !exists {
+			userIDs = append(userIDs, lock.ProfileID)
+		}
+		if lock.ValidUntil.After(lockedUntil[lock.ProfileID]) {
+			lockedUntil[lock.ProfileID] = lock.ValidUntil
+		}
+	}
+
+	lockedUsers := []*users_models.LockedUser{}
+
+	if len(userIDs) == 0 {
+		return lockedUsers, nil
+	}
+
+	users := []*users_models.User{}
+
+	result = s.OrmUtil.Db().Where("id IN ?", userIDs).Order("email ASC").Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, user := range users {
+		lockedUsers = append(lockedUsers, &users_models.LockedUser{
+			ID:          user.ID,
+			Email:       user.Email,
+			LockedUntil: lockedUntil[user.ID],
+		})
+	}
+
+	return lockedUsers, nil
+
+}
@@ -0,0 +1,33 @@
+package users_services
+
+import (
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetPendingInvitationsService is a service that gets the invitations that were not accepted nor revoked, including the expired ones
+type GetPendingInvitationsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetPendingInvitationsService) Execute() ([]*users_models.Invitation, error) {
+
+	invitations := []*users_models.Invitation{}
+
+	result := s.OrmUtil.Db().Where("status = ?", users_models.InvitationPending).Order("created_at ASC").Find(&invitations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, invitation := range invitations {
+		if invitation.ExpiresAt.Before(time.Now()) {
+			invitation.Status = users_models.InvitationExpired
+		}
+	}
+
+	return invitations, nil
+
+}
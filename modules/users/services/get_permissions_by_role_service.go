@@ -0,0 +1,25 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetPermissionsByRoleService is a service that gets the names of the permissions granted to a role
+type GetPermissionsByRoleService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetPermissionsByRoleService) Execute(role users_models.Role) ([]string, error) {
+
+	permissions := []string{}
+
+	result := s.OrmUtil.Db().Model(&users_models.RolePermission{}).Where("role = ?", role).Order("permission ASC").Pluck("permission", &permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return permissions, nil
+
+}
@@ -0,0 +1,25 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetPermissionsService is a service that gets the names of all permissions that can be granted to roles
+type GetPermissionsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetPermissionsService) Execute() ([]string, error) {
+
+	permissions := []string{}
+
+	result := s.OrmUtil.Db().Model(&users_models.Permission{}).Order("name ASC").Pluck("name", &permissions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return permissions, nil
+
+}
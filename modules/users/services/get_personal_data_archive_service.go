@@ -0,0 +1,238 @@
+package users_services
+
+import (
+	"errors"
+
+	agreements_models "github.com/guicostaarantes/psi-server/modules/agreements/models"
+	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	characteristics_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	treatments_models "github.com/guicostaarantes/psi-server/modules/treatments/models"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/archive"
+	"github.com/guicostaarantes/psi-server/utils/file_storage"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/serializing"
+)
+
+// GetPersonalDataArchiveService is a service that packs all personal data of a user in a single machine-readable file
+type GetPersonalDataArchiveService struct {
+	ArchiveUtil     archive.IArchiveUtil
+	FileStorageUtil file_storage.IFileStorageUtil
+	OrmUtil         orm.IOrmUtil
+	SerializingUtil serializing.ISerializingUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetPersonalDataArchiveService) Execute(userID string) ([]byte, error) {
+
+	files := map[string][]byte{}
+
+	addFile := func(name string, content interface{}) error {
+		data, serializeErr := s.SerializingUtil.VariableToBytes(content)
+		if serializeErr != nil {
+			return serializeErr
+		}
+		files[name] = data
+		return nil
+	}
+
+	addAvatar := func(name string, fileName string) error {
+		if fileName == "" {
+			return nil
+		}
+		data, readErr := s.FileStorageUtil.ReadFile(fileName)
+		if readErr != nil {
+			return readErr
+		}
+		if len(data) > 0 {
+			files[name] = data
+		}
+		return nil
+	}
+
+	user := users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if user.ID == "" {
+		return nil, errors.New("resource not found")
+	}
+
+	userErr := addFile("user.json", map[string]interface{}{
+		"id":        user.ID,
+		"email":     user.Email,
+		"role":      user.Role,
+		"active":    user.Active,
+		"createdAt": user.CreatedAt,
+		"updatedAt": user.UpdatedAt,
+	})
+	if userErr != nil {
+		return nil, userErr
+	}
+
+	sessions := []*users_models.Authentication{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	// the digest of the refresh token is a credential, not personal data
+	for _, session := range sessions {
+		session.RefreshTokenHash = ""
+	}
+
+	sessionsErr := addFile("sessions.json", sessions)
+	if sessionsErr != nil {
+		return nil, sessionsErr
+	}
+
+	profileIDs := []string{}
+
+	patient := profiles_models.Patient{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&patient)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if patient.ID != "" {
+		profileIDs = append(profileIDs, patient.ID)
+
+		patientErr := addFile("patient_profile.json", patient)
+		if patientErr != nil {
+			return nil, patientErr
+		}
+
+		avatarErr := addAvatar("patient_avatar", patient.Avatar)
+		if avatarErr != nil {
+			return nil, avatarErr
+		}
+	}
+
+	psychologist := profiles_models.Psychologist{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&psychologist)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if psychologist.ID != "" {
+		profileIDs = append(profileIDs, psychologist.ID)
+
+		psychologistErr := addFile("psychologist_profile.json", psychologist)
+		if psychologistErr != nil {
+			return nil, psychologistErr
+		}
+
+		avatarErr := addAvatar("psychologist_avatar", psychologist.Avatar)
+		if avatarErr != nil {
+			return nil, avatarErr
+		}
+	}
+
+	choices := []*characteristics_models.CharacteristicChoice{}
+
+	result = s.OrmUtil.Db().Where("profile_id IN ?", profileIDs).Find(&choices)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	choicesErr := addFile("characteristic_choices.json", choices)
+	if choicesErr != nil {
+		return nil, choicesErr
+	}
+
+	preferences := []*characteristics_models.Preference{}
+
+	result = s.OrmUtil.Db().Where("profile_id IN ?", profileIDs).Find(&preferences)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	preferencesErr := addFile("preferences.json", preferences)
+	if preferencesErr != nil {
+		return nil, preferencesErr
+	}
+
+	agreements := []*agreements_models.Agreement{}
+
+	result = s.OrmUtil.Db().Where("profile_id IN ?", profileIDs).Find(&agreements)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	agreementsErr := addFile("agreements.json", agreements)
+	if agreementsErr != nil {
+		return nil, agreementsErr
+	}
+
+	treatments := []*treatments_models.Treatment{}
+
+	result = s.OrmUtil.Db().Where("patient_id IN ? OR psychologist_id IN ?", profileIDs, profileIDs).Find(&treatments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	treatmentsErr := addFile("treatments.json", treatments)
+	if treatmentsErr != nil {
+		return nil, treatmentsErr
+	}
+
+	appointments := []*appointments_models.Appointment{}
+
+	result = s.OrmUtil.Db().Where("patient_id IN ? OR psychologist_id IN ?", profileIDs, profileIDs).Find(&appointments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	appointmentsErr := addFile("appointments.json", appointments)
+	if appointmentsErr != nil {
+		return nil, appointmentsErr
+	}
+
+	absences := []*appointments_models.Absence{}
+
+	result = s.OrmUtil.Db().Where("psychologist_id IN ?", profileIDs).Find(&absences)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	absencesErr := addFile("absences.json", absences)
+	if absencesErr != nil {
+		return nil, absencesErr
+	}
+
+	cooldowns := []*cooldowns_models.Cooldown{}
+
+	result = s.OrmUtil.Db().Where("profile_id IN ?", append(profileIDs, userID)).Find(&cooldowns)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	cooldownsErr := addFile("cooldowns.json", cooldowns)
+	if cooldownsErr != nil {
+		return nil, cooldownsErr
+	}
+
+	mails := []*mails_models.TransientMailMessage{}
+
+	result = s.OrmUtil.Db().Where(&mails_models.TransientMailMessage{To: user.Email}).Find(&mails)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	mailsErr := addFile("mails.json", mails)
+	if mailsErr != nil {
+		return nil, mailsErr
+	}
+
+	return s.ArchiveUtil.Pack(files)
+
+}
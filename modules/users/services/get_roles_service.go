@@ -0,0 +1,25 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetRolesService is a service that gets the names of all roles that can be given to users
+type GetRolesService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetRolesService) Execute() ([]users_models.Role, error) {
+
+	roles := []users_models.Role{}
+
+	result := s.OrmUtil.Db().Model(&users_models.RoleDefinition{}).Order("name ASC").Pluck("name", &roles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return roles, nil
+
+}
@@ -0,0 +1,27 @@
+package users_services
+
+import (
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// GetSessionsByUserIDService is a service that gets all the sessions of a user that have not expired yet
+type GetSessionsByUserIDService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetSessionsByUserIDService) Execute(userID string) ([]*users_models.Authentication, error) {
+
+	sessions := []*users_models.Authentication{}
+
+	result := s.OrmUtil.Db().Where("user_id = ? AND refresh_expires_at > ?", userID, time.Now()).Order("last_seen_at DESC").Find(&sessions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sessions, nil
+
+}
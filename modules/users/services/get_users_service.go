@@ -0,0 +1,191 @@
+package users_services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"gorm.io/gorm"
+)
+
+// GetUsersService is a service that gets a page of users matching filters and an email search
+type GetUsersService struct {
+	OrmUtil          orm.IOrmUtil
+	SerializingUtil  serializing.ISerializingUtil
+	MaxUsersPageSize int64
+}
+
+// Execute is the method that runs the business logic of the service
+func (s GetUsersService) Execute(filter *users_models.UsersFilterInput, search *string, sort *users_models.UserSort, first int64, after *string) (*users_models.UserPage, error) {
+
+	if first < 1 || first > s.MaxUsersPageSize {
+		return nil, errors.New("first is out of range")
+	}
+
+	userSort := users_models.CreatedAtDesc
+	if sort != nil {
+		userSort = *sort
+	}
+
+	column, direction := "", ""
+	switch userSort {
+	case users_models.CreatedAtAsc:
+		column, direction = "created_at", "ASC"
+	case users_models.CreatedAtDesc:
+		column, direction = "created_at", "DESC"
+	case users_models.EmailAsc:
+		column, direction = "email", "ASC"
+	case users_models.EmailDesc:
+		column, direction = "email", "DESC"
+	default:
+		return nil, errors.New("invalid sort")
+	}
+
+	page := &users_models.UserPage{
+		Users: []*users_models.User{},
+	}
+
+	result := s.filter(filter, search).Count(&page.TotalCount)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	query := s.filter(filter, search)
+
+	// The id breaks ties between users with the same value in the sorted column, so that no user is skipped or repeated between pages
+	if after != nil {
+		cursor, cursorErr := s.decodeCursor(*after)
+		if cursorErr != nil || cursor.Sort != userSort {
+			return nil, errors.New("invalid cursor")
+		}
+
+		var value interface{} = cursor.Value
+		if column == "created_at" {
+			createdAt, parseErr := time.Parse(time.RFC3339Nano, cursor.Value)
+			if parseErr != nil {
+				return nil, errors.New("invalid cursor")
+			}
+			value = createdAt
+		}
+
+		operator := ">"
+		if direction == "DESC" {
+			operator = "<"
+		}
+
+		query = query.Where("("+column+" "+operator+" ? OR ("+column+" = ? AND id "+operator+" ?))", value, value, cursor.ID)
+	}
+
+	result = query.Order(column + " " + direction).Order("id " + direction).Limit(int(first + 1)).Find(&page.Users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if int64(len(page.Users)) > first {
+		page.Users = page.Users[:first]
+		page.HasNextPage = true
+	}
+
+	if len(page.Users) > 0 {
+		last := page.Users[len(page.Users)-1]
+
+		cursor := users_models.UserCursor{
+			Sort: userSort,
+			ID:   last.ID,
+		}
+
+		if column == "created_at" {
+			cursor.Value = last.CreatedAt.Format(time.RFC3339Nano)
+		} else {
+			cursor.Value = last.Email
+		}
+
+		endCursor, cursorErr := s.encodeCursor(cursor)
+		if cursorErr != nil {
+			return nil, cursorErr
+		}
+
+		page.EndCursor = &endCursor
+	}
+
+	return page, nil
+
+}
+
+func (s GetUsersService) filter(filter *users_models.UsersFilterInput, search *string) *gorm.DB {
+
+	query := s.OrmUtil.Db().Model(&users_models.User{})
+
+	if search != nil && *search != "" {
+		escaped := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(strings.ToLower(*search))
+		query = query.Where("LOWER(email) LIKE ? ESCAPE '\\'", "%"+escaped+"%")
+	}
+
+	if filter != nil {
+		if filter.Role != nil {
+			query = query.Where("role = ?", *filter.Role)
+		}
+		if filter.Active != nil {
+			query = query.Where("active = ?", *filter.Active)
+		}
+		if filter.CreatedSince != nil {
+			query = query.Where("created_at >= ?", *filter.CreatedSince)
+		}
+		if filter.CreatedUntil != nil {
+			query = query.Where("created_at < ?", *filter.CreatedUntil)
+		}
+		if filter.HasProfile != nil {
+			condition := "(EXISTS (SELECT 1 FROM patients WHERE patients.user_id = users.id AND patients.deleted_at IS NULL) OR " +
+				"EXISTS (SELECT 1 FROM psychologists WHERE psychologists.user_id = users.id AND psychologists.deleted_at IS NULL))"
+			if !*filter.HasProfile {
+				condition = "NOT " + condition
+			}
+			query = query.Where(condition)
+		}
+		if filter.HasActiveTreatment != nil {
+			condition := "EXISTS (SELECT 1 FROM treatments WHERE treatments.status = 'ACTIVE' AND treatments.deleted_at IS NULL AND (" +
+				"treatments.patient_id IN (SELECT id FROM patients WHERE patients.user_id = users.id) OR " +
+				"treatments.psychologist_id IN (SELECT id FROM psychologists WHERE psychologists.user_id = users.id)))"
+			if !*filter.HasActiveTreatment {
+				condition = "NOT " + condition
+			}
+			query = query.Where(condition)
+		}
+	}
+
+	return query
+
+}
+
+func (s GetUsersService) encodeCursor(cursor users_models.UserCursor) (string, error) {
+
+	cursorBytes, serializeErr := s.SerializingUtil.VariableToBytes(cursor)
+	if serializeErr != nil {
+		return "", serializeErr
+	}
+
+	return base64.RawURLEncoding.EncodeToString(cursorBytes), nil
+
+}
+
+func (s GetUsersService) decodeCursor(encoded string) (*users_models.UserCursor, error) {
+
+	cursorBytes, decodeErr := base64.RawURLEncoding.DecodeString(encoded)
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	cursor := &users_models.UserCursor{}
+
+	serializeErr := s.SerializingUtil.BytesToVariable(cursorBytes, cursor)
+	if serializeErr != nil {
+		return nil, serializeErr
+	}
+
+	return cursor, nil
+
+}
@@ -0,0 +1,80 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// HashStoredTokensService is a service that replaces the tokens that previous versions stored in plaintext
+// with their digests, dropping the plaintext columns afterwards
+type HashStoredTokensService struct {
+	DigestUtil digest.IDigestUtil
+	OrmUtil    orm.IOrmUtil
+}
+
+type plaintextStoredToken struct {
+	ID    string
+	Token string
+}
+
+// Execute is the method that runs the business logic of the service
+func (s HashStoredTokensService) Execute() error {
+
+	migrator := s.OrmUtil.Db().Migrator()
+
+	if migrator.HasColumn(&users_models.Authentication{}, "refresh_token") {
+		tokens := []*plaintextStoredToken{}
+
+		result := s.OrmUtil.Db().Model(&users_models.Authentication{}).Select("id, refresh_token AS token").Where("refresh_token <> ''").Find(&tokens)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, token := range tokens {
+			tokenHash, digestErr := s.DigestUtil.Digest(token.Token)
+			if digestErr != nil {
+				return digestErr
+			}
+
+			result = s.OrmUtil.Db().Model(&users_models.Authentication{}).Where("id = ?", token.ID).Update("refresh_token_hash", tokenHash)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		dropErr := migrator.DropColumn(&users_models.Authentication{}, "refresh_token")
+		if dropErr != nil {
+			return dropErr
+		}
+	}
+
+	if migrator.HasColumn(&users_models.ResetPassword{}, "token") {
+		tokens := []*plaintextStoredToken{}
+
+		result := s.OrmUtil.Db().Model(&users_models.ResetPassword{}).Select("user_id AS id, token").Where("token <> ''").Find(&tokens)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, token := range tokens {
+			tokenHash, digestErr := s.DigestUtil.Digest(token.Token)
+			if digestErr != nil {
+				return digestErr
+			}
+
+			result = s.OrmUtil.Db().Model(&users_models.ResetPassword{}).Where("user_id = ?", token.ID).Update("token_hash", tokenHash)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		dropErr := migrator.DropColumn(&users_models.ResetPassword{}, "token")
+		if dropErr != nil {
+			return dropErr
+		}
+	}
+
+	return nil
+
+}
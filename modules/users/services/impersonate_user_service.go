@@ -0,0 +1,84 @@
+package users_services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/token"
+)
+
+// ImpersonateUserService is a service that issues a short-lived token that allows a user to see the application as another user
+type ImpersonateUserService struct {
+	IdentifierUtil                   identifier.IIdentifierUtil
+	OrmUtil                          orm.IOrmUtil
+	SerializingUtil                  serializing.ISerializingUtil
+	TokenUtil                        token.ITokenUtil
+	ExpireImpersonationTokenDuration time.Duration
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ImpersonateUserService) Execute(impersonatorID string, impersonatorSessionID string, userID string, reason string) (*users_models.Authentication, error) {
+
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	if impersonatorID == userID {
+		return nil, errors.New("users cannot impersonate themselves")
+	}
+
+	user := users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if user.ID == "" {
+		return nil, errors.New("resource not found")
+	}
+
+	if user.Role != users_models.Patient && user.Role != users_models.Psychologist {
+		return nil, errors.New("only patients and psychologists can be impersonated")
+	}
+
+	if !user.Active {
+		return nil, errors.New("user is not active")
+	}
+
+	_, sessionID, sessionIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if sessionIDErr != nil {
+		return nil, sessionIDErr
+	}
+
+	claims, claimsErr := s.SerializingUtil.VariableToBytes(&users_models.AuthClaims{
+		UserID:                user.ID,
+		SessionID:             sessionID,
+		ImpersonatorID:        impersonatorID,
+		ImpersonatorSessionID: impersonatorSessionID,
+	})
+	if claimsErr != nil {
+		return nil, claimsErr
+	}
+
+	token, tokenErr := s.TokenUtil.GenerateToken(string(claims), s.ExpireImpersonationTokenDuration)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	auth := &users_models.Authentication{
+		ID:        sessionID,
+		UserID:    user.ID,
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(s.ExpireImpersonationTokenDuration),
+		Token:     token,
+	}
+
+	return auth, nil
+
+}
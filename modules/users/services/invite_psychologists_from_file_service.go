@@ -0,0 +1,139 @@
+package users_services
+
+import (
+	"io"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	profiles_models "github.com/guicostaarantes/psi-server/modules/profiles/models"
+	profiles_services "github.com/guicostaarantes/psi-server/modules/profiles/services"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/match"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/table"
+)
+
+// InvitePsychologistsFromFileService is a service that invites psychologists listed in a file with
+// columns email, name and CRP. Each row is handled independently and has its own result
+type InvitePsychologistsFromFileService struct {
+	MatchUtil                 match.IMatchUtil
+	OrmUtil                   orm.IOrmUtil
+	TableUtil                 table.ITableUtil
+	CreateUserService         *CreateUserService
+	UpsertPsychologistService *profiles_services.UpsertPsychologistService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s InvitePsychologistsFromFileService) Execute(invitedBy string, file graphql.Upload) ([]*users_models.InvitationRowResult, error) {
+
+	data, readErr := io.ReadAll(file.File)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	rows, parseErr := s.TableUtil.Parse(data)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	results := []*users_models.InvitationRowResult{}
+	seenEmails := map[string]bool{}
+
+	for index, row := range rows {
+		email := strings.TrimSpace(row[0])
+
+		// A header row is allowed as the first line of the file
+		if index == 0 && strings.EqualFold(email, "email") {
+			continue
+		}
+
+		results = append(results, s.invite(invitedBy, int64(index+1), email, row, seenEmails))
+	}
+
+	return results, nil
+
+}
+
+func (s InvitePsychologistsFromFileService) invite(invitedBy string, line int64, email string, row []string, seenEmails map[string]bool) *users_models.InvitationRowResult {
+
+	result := &users_models.InvitationRowResult{
+		Line:  line,
+		Email: email,
+	}
+
+	name := ""
+	if len(row) > 1 {
+		name = strings.TrimSpace(row[1])
+	}
+
+	crp := ""
+	if len(row) > 2 {
+		crp = strings.TrimSpace(row[2])
+	}
+
+	emailErr := s.MatchUtil.IsEmailValid(email)
+	if emailErr != nil {
+		result.Status = users_models.InvitationInvalid
+		result.Message = emailErr.Error()
+		return result
+	}
+
+	if seenEmails[email] {
+		result.Status = users_models.InvitationDuplicate
+		result.Message = "email appears more than once in the file"
+		return result
+	}
+
+	seenEmails[email] = true
+
+	existingUser := users_models.User{}
+
+	dbResult := s.OrmUtil.Db().Where("email = ?", email).Limit(1).Find(&existingUser)
+	if dbResult.Error != nil {
+		result.Status = users_models.InvitationInvalid
+		result.Message = dbResult.Error.Error()
+		return result
+	}
+
+	if existingUser.ID != "" {
+		result.Status = users_models.InvitationDuplicate
+		result.Message = "user with same email already exists"
+		return result
+	}
+
+	createErr := s.CreateUserService.Execute(&users_models.CreateUserInput{
+		Email:     email,
+		Role:      users_models.Psychologist,
+		InvitedBy: invitedBy,
+	}, "INVITE_PSYCHOLOGIST")
+	if createErr != nil {
+		result.Status = users_models.InvitationInvalid
+		result.Message = createErr.Error()
+		return result
+	}
+
+	result.Status = users_models.InvitationCreated
+
+	// The profile is started with the information known by the coordinator, and completed by the psychologist later
+	if name != "" || crp != "" {
+		createdUser := users_models.User{}
+
+		dbResult = s.OrmUtil.Db().Where("email = ?", email).Limit(1).Find(&createdUser)
+		if dbResult.Error != nil {
+			result.Message = dbResult.Error.Error()
+			return result
+		}
+
+		upsertErr := s.UpsertPsychologistService.Execute(createdUser.ID, &profiles_models.UpsertPsychologistInput{
+			FullName: name,
+			Crp:      crp,
+		})
+		if upsertErr != nil {
+			result.Message = upsertErr.Error()
+			return result
+		}
+	}
+
+	return result
+
+}
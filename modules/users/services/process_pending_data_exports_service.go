@@ -0,0 +1,139 @@
+package users_services
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"time"
+
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	users_templates "github.com/guicostaarantes/psi-server/modules/users/templates"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/file_storage"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/token"
+)
+
+// ProcessPendingDataExportsService is a service that builds the copies of personal data requested by users,
+// sending a time-limited download link to their emails, and deletes the copies that have expired. Copies are kept in
+// their own storage, apart from the public files such as avatars
+type ProcessPendingDataExportsService struct {
+	DigestUtil                    digest.IDigestUtil
+	ExportStorageUtil             file_storage.IFileStorageUtil
+	IdentifierUtil                identifier.IIdentifierUtil
+	OrmUtil                       orm.IOrmUtil
+	TokenUtil                     token.ITokenUtil
+	ExpireDataExportDuration      time.Duration
+	DeleteDataExportsService      *DeleteDataExportsService
+	GetPersonalDataArchiveService *GetPersonalDataArchiveService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ProcessPendingDataExportsService) Execute() error {
+
+	expiredExports := []*users_models.DataExport{}
+
+	result := s.OrmUtil.Db().Where("processed = ? AND expires_at < ?", true, time.Now()).Find(&expiredExports)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	deleteErr := s.DeleteDataExportsService.Execute(expiredExports)
+	if deleteErr != nil {
+		return deleteErr
+	}
+
+	pendingExports := []*users_models.DataExport{}
+
+	result = s.OrmUtil.Db().Where("processed = ?", false).Find(&pendingExports)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for _, export := range pendingExports {
+		user := users_models.User{}
+
+		result = s.OrmUtil.Db().Where("id = ?", export.UserID).Limit(1).Find(&user)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if user.ID == "" {
+			result = s.OrmUtil.Db().Delete(&export)
+			if result.Error != nil {
+				return result.Error
+			}
+			continue
+		}
+
+		data, archiveErr := s.GetPersonalDataArchiveService.Execute(user.ID)
+		if archiveErr != nil {
+			return archiveErr
+		}
+
+		fileName, writeErr := s.ExportStorageUtil.WriteFile(data)
+		if writeErr != nil {
+			return writeErr
+		}
+
+		token, tokenErr := s.TokenUtil.GenerateToken(export.ID, s.ExpireDataExportDuration)
+		if tokenErr != nil {
+			return tokenErr
+		}
+
+		tokenHash, digestErr := s.DigestUtil.Digest(token)
+		if digestErr != nil {
+			return digestErr
+		}
+
+		export.Processed = true
+		export.FileName = fileName
+		export.TokenHash = tokenHash
+		export.ExpiresAt = time.Now().Add(s.ExpireDataExportDuration)
+
+		_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
+		if mailIDErr != nil {
+			return mailIDErr
+		}
+
+		templ, templErr := template.New("DataExportReadyEmail").Parse(users_templates.DataExportReadyEmailTemplate)
+		if templErr != nil {
+			return templErr
+		}
+
+		buff := new(bytes.Buffer)
+
+		templ.Execute(buff, map[string]string{
+			"SiteURL":   os.Getenv("PSI_SITE_URL"),
+			"Token":     token,
+			"ExpiresAt": export.ExpiresAt.Format("02/01/2006 15:04 MST"),
+		})
+
+		mail := &mails_models.TransientMailMessage{
+			ID:          mailID,
+			FromAddress: "relacionamento@psi.com.br",
+			FromName:    "Relacionamento PSI",
+			To:          user.Email,
+			Cc:          "",
+			Cco:         "",
+			Subject:     "Seus dados pessoais no PSI",
+			Html:        buff.String(),
+			Processed:   false,
+		}
+
+		result = s.OrmUtil.Db().Create(&mail)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = s.OrmUtil.Db().Save(&export)
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+
+}
@@ -0,0 +1,117 @@
+package users_services
+
+import (
+	"errors"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/token"
+)
+
+// RefreshAuthenticationService is a service that exchanges a refresh token for a new access token,
+// rotating the refresh token of the session in the process
+type RefreshAuthenticationService struct {
+	DigestUtil                 digest.IDigestUtil
+	OrmUtil                    orm.IOrmUtil
+	SerializingUtil            serializing.ISerializingUtil
+	TokenUtil                  token.ITokenUtil
+	ExpireAuthTokenDuration    time.Duration
+	ExpireRefreshTokenDuration time.Duration
+	TwoFactorRequiredRoles     []users_models.Role
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RefreshAuthenticationService) Execute(refreshToken string) (*users_models.Authentication, error) {
+
+	authID, verifyErr := s.TokenUtil.Verify(refreshToken)
+	if verifyErr != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	refreshTokenHash, digestErr := s.DigestUtil.Digest(refreshToken)
+	if digestErr != nil {
+		return nil, digestErr
+	}
+
+	auth := &users_models.Authentication{}
+
+	result := s.OrmUtil.Db().Where("id = ? AND refresh_token_hash = ?", authID, refreshTokenHash).Limit(1).Find(&auth)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if auth.ID == "" || auth.RefreshExpiresAt.Before(time.Now()) {
+		return nil, errors.New("invalid token")
+	}
+
+	user := users_models.User{}
+
+	result = s.OrmUtil.Db().Where("id = ?", auth.UserID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if user.ID == "" || !user.Active {
+		return nil, errors.New("invalid token")
+	}
+
+	twoFactor := &users_models.TwoFactor{}
+
+	result = s.OrmUtil.Db().Where("user_id = ? AND enabled = ?", user.ID, true).Limit(1).Find(&twoFactor)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	twoFactorPending := false
+
+	if twoFactor.UserID == "" {
+		for _, role := range s.TwoFactorRequiredRoles {
+			if role == user.Role {
+				twoFactorPending = true
+			}
+		}
+	}
+
+	newRefreshToken, refreshTokenErr := s.TokenUtil.GenerateToken(auth.ID, s.ExpireRefreshTokenDuration)
+	if refreshTokenErr != nil {
+		return nil, refreshTokenErr
+	}
+
+	newRefreshTokenHash, digestErr := s.DigestUtil.Digest(newRefreshToken)
+	if digestErr != nil {
+		return nil, digestErr
+	}
+
+	claims, claimsErr := s.SerializingUtil.VariableToBytes(&users_models.AuthClaims{
+		UserID:           auth.UserID,
+		SessionID:        auth.ID,
+		TwoFactorPending: twoFactorPending,
+	})
+	if claimsErr != nil {
+		return nil, claimsErr
+	}
+
+	token, tokenErr := s.TokenUtil.GenerateToken(string(claims), s.ExpireAuthTokenDuration)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	auth.Token = token
+	auth.ExpiresAt = time.Now().Add(s.ExpireAuthTokenDuration)
+	auth.RefreshToken = newRefreshToken
+	auth.RefreshTokenHash = newRefreshTokenHash
+	auth.RefreshExpiresAt = time.Now().Add(s.ExpireRefreshTokenDuration)
+	auth.LastSeenAt = time.Now()
+	auth.TwoFactorPending = twoFactorPending
+
+	result = s.OrmUtil.Db().Save(&auth)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return auth, nil
+
+}
@@ -0,0 +1,137 @@
+package users_services
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	users_templates "github.com/guicostaarantes/psi-server/modules/users/templates"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// RegisterLoginFailureService is a service that counts a failed authentication attempt for a user and an IP address,
+// locking them when the maximum number of failures is reached
+type RegisterLoginFailureService struct {
+	IdentifierUtil               identifier.IIdentifierUtil
+	OrmUtil                      orm.IOrmUtil
+	DeleteCooldownsService       *cooldowns_services.DeleteCooldownsService
+	GetCooldownService           *cooldowns_services.GetCooldownService
+	GetCooldownsService          *cooldowns_services.GetCooldownsService
+	SaveCooldownService          *cooldowns_services.SaveCooldownService
+	MaxLoginFailuresPerUser      int64
+	MaxLoginFailuresPerIPAddress int64
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RegisterLoginFailureService) Execute(userID string, ipAddress string) error {
+
+	if ipAddress != "" {
+		saveErr := s.SaveCooldownService.Execute(ipAddress, cooldowns_models.IPAddress, cooldowns_models.LoginFailed)
+		if saveErr != nil {
+			return saveErr
+		}
+
+		failures, getErr := s.GetCooldownsService.Execute(ipAddress, cooldowns_models.IPAddress, cooldowns_models.LoginFailed)
+		if getErr != nil {
+			return getErr
+		}
+
+		if int64(len(failures)) >= s.MaxLoginFailuresPerIPAddress {
+			saveErr := s.SaveCooldownService.Execute(ipAddress, cooldowns_models.IPAddress, cooldowns_models.LoginLocked)
+			if saveErr != nil {
+				return saveErr
+			}
+
+			deleteErr := s.DeleteCooldownsService.Execute(ipAddress, cooldowns_models.IPAddress, cooldowns_models.LoginFailed)
+			if deleteErr != nil {
+				return deleteErr
+			}
+		}
+	}
+
+	if userID == "" {
+		return nil
+	}
+
+	saveErr := s.SaveCooldownService.Execute(userID, cooldowns_models.User, cooldowns_models.LoginFailed)
+	if saveErr != nil {
+		return saveErr
+	}
+
+	failures, getErr := s.GetCooldownsService.Execute(userID, cooldowns_models.User, cooldowns_models.LoginFailed)
+	if getErr != nil {
+		return getErr
+	}
+
+	if int64(len(failures)) < s.MaxLoginFailuresPerUser {
+		return nil
+	}
+
+	saveErr = s.SaveCooldownService.Execute(userID, cooldowns_models.User, cooldowns_models.LoginLocked)
+	if saveErr != nil {
+		return saveErr
+	}
+
+	deleteErr := s.DeleteCooldownsService.Execute(userID, cooldowns_models.User, cooldowns_models.LoginFailed)
+	if deleteErr != nil {
+		return deleteErr
+	}
+
+	lock, getErr := s.GetCooldownService.Execute(userID, cooldowns_models.User, cooldowns_models.LoginLocked)
+	if getErr != nil {
+		return getErr
+	}
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" || lock == nil {
+		return nil
+	}
+
+	_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if mailIDErr != nil {
+		return mailIDErr
+	}
+
+	templ, templErr := template.New("AccountLockedEmail").Parse(users_templates.AccountLockedEmailTemplate)
+	if templErr != nil {
+		return templErr
+	}
+
+	buff := new(bytes.Buffer)
+
+	templ.Execute(buff, map[string]string{
+		"SiteURL":     os.Getenv("PSI_SITE_URL"),
+		"LockedUntil": lock.ValidUntil.UTC().Format("02/01/2006 15:04 (UTC)"),
+	})
+
+	mail := &mails_models.TransientMailMessage{
+		ID:          mailID,
+		FromAddress: "relacionamento@psi.com.br",
+		FromName:    "Relacionamento PSI",
+		To:          user.Email,
+		Cc:          "",
+		Cco:         "",
+		Subject:     "Acesso bloqueado ao PSI",
+		Html:        buff.String(),
+		Processed:   false,
+	}
+
+	result = s.OrmUtil.Db().Create(&mail)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
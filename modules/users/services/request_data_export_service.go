@@ -0,0 +1,48 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// RequestDataExportService is a service that registers the intention of a user to get a copy of their personal data.
+// The copy is built in the background and sent to their email
+type RequestDataExportService struct {
+	IdentifierUtil identifier.IIdentifierUtil
+	OrmUtil        orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RequestDataExportService) Execute(userID string) error {
+
+	pendingExport := users_models.DataExport{}
+
+	result := s.OrmUtil.Db().Where("user_id = ? AND processed = ?", userID, false).Limit(1).Find(&pendingExport)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if pendingExport.ID != "" {
+		return nil
+	}
+
+	_, id, idErr := s.IdentifierUtil.GenerateIdentifier()
+	if idErr != nil {
+		return idErr
+	}
+
+	export := &users_models.DataExport{
+		ID:        id,
+		UserID:    userID,
+		Processed: false,
+	}
+
+	result = s.OrmUtil.Db().Create(&export)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
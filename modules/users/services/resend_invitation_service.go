@@ -0,0 +1,36 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// ResendInvitationService is a service that sends a pending or expired invitation again with a new expiration
+type ResendInvitationService struct {
+	OrmUtil               orm.IOrmUtil
+	SendInvitationService *SendInvitationService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ResendInvitationService) Execute(id string) error {
+
+	invitation := &users_models.Invitation{}
+
+	result := s.OrmUtil.Db().Where("id = ?", id).Limit(1).Find(&invitation)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if invitation.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	if invitation.Status != users_models.InvitationPending {
+		return errors.New("invitation is not pending")
+	}
+
+	return s.SendInvitationService.Execute(invitation)
+
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
 	"github.com/guicostaarantes/psi-server/utils/hash"
 	"github.com/guicostaarantes/psi-server/utils/match"
 	"github.com/guicostaarantes/psi-server/utils/orm"
@@ -12,22 +13,23 @@ import (
 
 // ResetPasswordService is a service that (re)sets the password for a user based on a token sent to their email
 type ResetPasswordService struct {
-	MatchUtil match.IMatchUtil
-	HashUtil  hash.IHashUtil
-	OrmUtil   orm.IOrmUtil
+	DigestUtil digest.IDigestUtil
+	MatchUtil  match.IMatchUtil
+	HashUtil   hash.IHashUtil
+	OrmUtil    orm.IOrmUtil
 }
 
 // Execute is the method that runs the business logic of the service
 func (s ResetPasswordService) Execute(resetInput *users_models.ResetPasswordInput) error {
 
-	passwordErr := s.MatchUtil.IsPasswordStrong(resetInput.Password)
-	if passwordErr != nil {
-		return passwordErr
+	tokenHash, digestErr := s.DigestUtil.Digest(resetInput.Token)
+	if digestErr != nil {
+		return digestErr
 	}
 
 	reset := &users_models.ResetPassword{}
 
-	result := s.OrmUtil.Db().Where("token = ?", resetInput.Token).Limit(1).Find(&reset)
+	result := s.OrmUtil.Db().Where("token_hash = ?", tokenHash).Limit(1).Find(&reset)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -47,6 +49,11 @@ func (s ResetPasswordService) Execute(resetInput *users_models.ResetPasswordInpu
 		return errors.New("invalid token")
 	}
 
+	passwordErr := s.MatchUtil.IsPasswordStrong(resetInput.Password, user.Email)
+	if passwordErr != nil {
+		return passwordErr
+	}
+
 	hashedPwd, hashErr := s.HashUtil.Hash(resetInput.Password)
 	if hashErr != nil {
 		return hashErr
@@ -66,6 +73,11 @@ func (s ResetPasswordService) Execute(resetInput *users_models.ResetPasswordInpu
 		return result.Error
 	}
 
+	result = s.OrmUtil.Db().Model(&users_models.Invitation{}).Where("user_id = ? AND status = ?", user.ID, users_models.InvitationPending).Update("status", users_models.InvitationAccepted)
+	if result.Error != nil {
+		return result.Error
+	}
+
 	return nil
 
 }
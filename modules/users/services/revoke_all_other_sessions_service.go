@@ -0,0 +1,23 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// RevokeAllOtherSessionsService is a service that ends all sessions of a user except the one being used
+type RevokeAllOtherSessionsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RevokeAllOtherSessionsService) Execute(userID string, currentSessionID string) error {
+
+	result := s.OrmUtil.Db().Where("user_id = ? AND id <> ?", userID, currentSessionID).Delete(&users_models.Authentication{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
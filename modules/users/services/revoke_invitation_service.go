@@ -0,0 +1,38 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// RevokeInvitationService is a service that cancels a pending or expired invitation and erases the invited user,
+// so that the invitation link stops working and the email can be invited again
+type RevokeInvitationService struct {
+	OrmUtil          orm.IOrmUtil
+	EraseUserService *EraseUserService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RevokeInvitationService) Execute(id string) error {
+
+	invitation := &users_models.Invitation{}
+
+	result := s.OrmUtil.Db().Where("id = ?", id).Limit(1).Find(&invitation)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if invitation.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	if invitation.Status != users_models.InvitationPending {
+		return errors.New("invitation is not pending")
+	}
+
+	// Erasing the invited user also revokes their pending invitations
+	return s.EraseUserService.Execute(invitation.UserID)
+
+}
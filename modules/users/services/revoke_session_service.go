@@ -0,0 +1,36 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// RevokeSessionService is a service that ends a specific session of a user
+type RevokeSessionService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s RevokeSessionService) Execute(id string, userID string) error {
+
+	session := users_models.Authentication{}
+
+	result := s.OrmUtil.Db().Where("id = ? AND user_id = ?", id, userID).Limit(1).Find(&session)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if session.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	result = s.OrmUtil.Db().Delete(&session)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
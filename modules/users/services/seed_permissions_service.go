@@ -0,0 +1,62 @@
+package users_services
+
+import (
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// SeedPermissionsService is a service that registers the roles and permissions known by the application that are not yet in the
+// database, granting the permissions to their default roles
+type SeedPermissionsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s SeedPermissionsService) Execute() error {
+
+	for _, role := range users_models.DefaultRoles {
+		existingRole := users_models.RoleDefinition{}
+
+		result := s.OrmUtil.Db().Where("name = ?", role).Limit(1).Find(&existingRole)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if existingRole.Name != "" {
+			continue
+		}
+
+		result = s.OrmUtil.Db().Create(&users_models.RoleDefinition{Name: role})
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	for name, roles := range users_models.DefaultRolePermissions {
+		existingPermission := users_models.Permission{}
+
+		result := s.OrmUtil.Db().Where("name = ?", name).Limit(1).Find(&existingPermission)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if existingPermission.Name != "" {
+			continue
+		}
+
+		result = s.OrmUtil.Db().Create(&users_models.Permission{Name: name})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, role := range roles {
+			result = s.OrmUtil.Db().Create(&users_models.RolePermission{Role: role, Permission: name})
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+	}
+
+	return nil
+
+}
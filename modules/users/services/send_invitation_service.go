@@ -0,0 +1,115 @@
+package users_services
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"time"
+
+	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	users_templates "github.com/guicostaarantes/psi-server/modules/users/templates"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/token"
+)
+
+// SendInvitationService is a service that issues a new token for an invitation and sends it to the invited user,
+// invalidating tokens sent before
+type SendInvitationService struct {
+	DigestUtil               digest.IDigestUtil
+	IdentifierUtil           identifier.IIdentifierUtil
+	OrmUtil                  orm.IOrmUtil
+	TokenUtil                token.ITokenUtil
+	ExpireInvitationDuration time.Duration
+}
+
+// Execute is the method that runs the business logic of the service
+func (s SendInvitationService) Execute(invitation *users_models.Invitation) error {
+
+	templateToUse := ""
+
+	switch invitation.Template {
+	case "CREATE_PATIENT":
+		templateToUse = users_templates.CreateUserEmailTemplate
+	case "INVITE_PSYCHOLOGIST":
+		templateToUse = users_templates.InvitePsychologistEmailTemplate
+	default:
+		return errors.New("template not found")
+	}
+
+	token, tokenErr := s.TokenUtil.GenerateToken(invitation.UserID, s.ExpireInvitationDuration)
+	if tokenErr != nil {
+		return tokenErr
+	}
+
+	tokenHash, digestErr := s.DigestUtil.Digest(token)
+	if digestErr != nil {
+		return digestErr
+	}
+
+	reset := &users_models.ResetPassword{
+		UserID:    invitation.UserID,
+		IssuedAt:  time.Now(),
+		ExpiresAt: time.Now().Add(s.ExpireInvitationDuration),
+		TokenHash: tokenHash,
+		Redeemed:  false,
+	}
+
+	_, mailID, mailIDErr := s.IdentifierUtil.GenerateIdentifier()
+	if mailIDErr != nil {
+		return mailIDErr
+	}
+
+	templ, templErr := template.New("CreateUserEmail").Parse(templateToUse)
+	if templErr != nil {
+		return templErr
+	}
+
+	buff := new(bytes.Buffer)
+
+	templ.Execute(buff, map[string]string{
+		"SiteURL": os.Getenv("PSI_SITE_URL"),
+		"Token":   token,
+	})
+
+	mail := &mails_models.TransientMailMessage{
+		ID:          mailID,
+		FromAddress: "relacionamento@psi.com.br",
+		FromName:    "Relacionamento PSI",
+		To:          invitation.Email,
+		Cc:          "",
+		Cco:         "",
+		Subject:     "Bem-vindo ao PSI",
+		Html:        buff.String(),
+		Processed:   false,
+	}
+
+	result := s.OrmUtil.Db().Create(&mail)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", invitation.UserID).Delete(&users_models.ResetPassword{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	result = s.OrmUtil.Db().Create(&reset)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	invitation.Status = users_models.InvitationPending
+	invitation.ExpiresAt = reset.ExpiresAt
+
+	result = s.OrmUtil.Db().Save(&invitation)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -0,0 +1,59 @@
+package users_services
+
+import (
+	"errors"
+	"fmt"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// SetRolePermissionsService is a service that replaces the permissions granted to a role
+type SetRolePermissionsService struct {
+	OrmUtil orm.IOrmUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s SetRolePermissionsService) Execute(role users_models.Role, permissions []string) error {
+
+	roleErr := validateRole(s.OrmUtil, role)
+	if roleErr != nil {
+		return roleErr
+	}
+
+	granted := map[string]bool{}
+
+	for _, name := range permissions {
+		permission := users_models.Permission{}
+
+		result := s.OrmUtil.Db().Where("name = ?", name).Limit(1).Find(&permission)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if permission.Name == "" {
+			return fmt.Errorf("permission %s not found", name)
+		}
+
+		granted[name] = true
+	}
+
+	if role == users_models.Coordinator && !granted[users_models.ManagePermissionsPermission] {
+		return errors.New("coordinators cannot lose the permission to manage permissions")
+	}
+
+	result := s.OrmUtil.Db().Where("role = ?", role).Delete(&users_models.RolePermission{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	for name := range granted {
+		result = s.OrmUtil.Db().Create(&users_models.RolePermission{Role: role, Permission: name})
+		if result.Error != nil {
+			return result.Error
+		}
+	}
+
+	return nil
+
+}
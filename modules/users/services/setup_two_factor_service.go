@@ -0,0 +1,104 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/identifier"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/otp"
+)
+
+// SetupTwoFactorService is a service that creates a new one-time password secret and recovery codes for a user.
+// The second factor is only enforced after the user confirms it with a valid code
+type SetupTwoFactorService struct {
+	DigestUtil            digest.IDigestUtil
+	IdentifierUtil        identifier.IIdentifierUtil
+	OrmUtil               orm.IOrmUtil
+	OtpUtil               otp.IOtpUtil
+	RecoveryCodesQuantity int64
+}
+
+// Execute is the method that runs the business logic of the service
+func (s SetupTwoFactorService) Execute(userID string) (*users_models.TwoFactorSetup, error) {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if user.ID == "" {
+		return nil, errors.New("resource not found")
+	}
+
+	twoFactor := &users_models.TwoFactor{}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Limit(1).Find(&twoFactor)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if twoFactor.Enabled {
+		return nil, errors.New("two factor authentication is already enabled")
+	}
+
+	secret, secretErr := s.OtpUtil.GenerateSecret()
+	if secretErr != nil {
+		return nil, secretErr
+	}
+
+	twoFactor.UserID = userID
+	twoFactor.Secret = secret
+	twoFactor.Enabled = false
+	twoFactor.LastUsedStep = 0
+
+	result = s.OrmUtil.Db().Save(&twoFactor)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	result = s.OrmUtil.Db().Where("user_id = ?", userID).Delete(&users_models.TwoFactorRecoveryCode{})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	setup := &users_models.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: s.OtpUtil.GetProvisioningURI(secret, user.Email),
+		RecoveryCodes:   []string{},
+	}
+
+	for i := int64(0); i < s.RecoveryCodesQuantity; i++ {
+		code, codeErr := s.OtpUtil.GenerateRecoveryCode()
+		if codeErr != nil {
+			return nil, codeErr
+		}
+
+		codeHash, digestErr := s.DigestUtil.Digest(code)
+		if digestErr != nil {
+			return nil, digestErr
+		}
+
+		_, codeID, codeIDErr := s.IdentifierUtil.GenerateIdentifier()
+		if codeIDErr != nil {
+			return nil, codeIDErr
+		}
+
+		result = s.OrmUtil.Db().Create(&users_models.TwoFactorRecoveryCode{
+			ID:       codeID,
+			UserID:   userID,
+			CodeHash: codeHash,
+		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+
+		setup.RecoveryCodes = append(setup.RecoveryCodes, code)
+	}
+
+	return setup, nil
+
+}
@@ -0,0 +1,65 @@
+package users_services
+
+import (
+	"errors"
+	"time"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/oidc"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// StartOidcAuthenticationService is a service that creates the address where a user logs in with the OpenID Connect provider.
+// The authorization is bound to a random value kept by the client, which must be presented again when the provider returns
+type StartOidcAuthenticationService struct {
+	DigestUtil                      digest.IDigestUtil
+	OidcUtil                        oidc.IOidcUtil
+	OrmUtil                         orm.IOrmUtil
+	ExpireOidcAuthorizationDuration time.Duration
+}
+
+// Execute is the method that runs the business logic of the service
+func (s StartOidcAuthenticationService) Execute(binding string) (string, error) {
+
+	if len(binding) < 32 {
+		return "", errors.New("binding must have at least 32 characters")
+	}
+
+	bindingHash, digestErr := s.DigestUtil.Digest(binding)
+	if digestErr != nil {
+		return "", digestErr
+	}
+
+	request, requestErr := s.OidcUtil.GenerateAuthorizationRequest()
+	if requestErr != nil {
+		return "", requestErr
+	}
+
+	stateHash, digestErr := s.DigestUtil.Digest(request.State)
+	if digestErr != nil {
+		return "", digestErr
+	}
+
+	result := s.OrmUtil.Db().Where("expires_at < ?", time.Now()).Delete(&users_models.OidcAuthorization{})
+	if result.Error != nil {
+		return "", result.Error
+	}
+
+	authorization := &users_models.OidcAuthorization{
+		StateHash:    stateHash,
+		Nonce:        request.Nonce,
+		CodeVerifier: request.CodeVerifier,
+		BindingHash:  bindingHash,
+		IssuedAt:     time.Now(),
+		ExpiresAt:    time.Now().Add(s.ExpireOidcAuthorizationDuration),
+	}
+
+	result = s.OrmUtil.Db().Create(&authorization)
+	if result.Error != nil {
+		return "", result.Error
+	}
+
+	return request.URL, nil
+
+}
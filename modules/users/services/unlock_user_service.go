@@ -0,0 +1,44 @@
+package users_services
+
+import (
+	"errors"
+
+	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
+	cooldowns_services "github.com/guicostaarantes/psi-server/modules/cooldowns/services"
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// UnlockUserService is a service that allows a user to authenticate again after being locked due to failed attempts
+type UnlockUserService struct {
+	OrmUtil                orm.IOrmUtil
+	DeleteCooldownsService *cooldowns_services.DeleteCooldownsService
+}
+
+// Execute is the method that runs the business logic of the service
+func (s UnlockUserService) Execute(id string) error {
+
+	user := &users_models.User{}
+
+	result := s.OrmUtil.Db().Where("id = ?", id).Limit(1).Find(&user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if user.ID == "" {
+		return errors.New("resource not found")
+	}
+
+	deleteErr := s.DeleteCooldownsService.Execute(user.ID, cooldowns_models.User, cooldowns_models.LoginLocked)
+	if deleteErr != nil {
+		return deleteErr
+	}
+
+	deleteErr = s.DeleteCooldownsService.Execute(user.ID, cooldowns_models.User, cooldowns_models.LoginFailed)
+	if deleteErr != nil {
+		return deleteErr
+	}
+
+	return nil
+
+}
@@ -15,6 +15,11 @@ type UpdateUserService struct {
 // Execute is the method that runs the business logic of the service
 func (s UpdateUserService) Execute(userID string, input *users_models.UpdateUserInput) error {
 
+	roleErr := validateRole(s.OrmUtil, input.Role)
+	if roleErr != nil {
+		return roleErr
+	}
+
 	user := users_models.User{}
 
 	result := s.OrmUtil.Db().Where("id = ?", userID).Limit(1).Find(&user)
@@ -0,0 +1,26 @@
+package users_services
+
+import (
+	"errors"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+)
+
+// validateRole checks if a role is registered, so that users and permissions are not given to roles that do not exist
+func validateRole(ormUtil orm.IOrmUtil, role users_models.Role) error {
+
+	existingRole := users_models.RoleDefinition{}
+
+	result := ormUtil.Db().Where("name = ?", role).Limit(1).Find(&existingRole)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if existingRole.Name == "" {
+		return errors.New("role not found")
+	}
+
+	return nil
+
+}
@@ -0,0 +1,73 @@
+package users_services
+
+import (
+	"errors"
+	"strings"
+
+	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
+	"github.com/guicostaarantes/psi-server/utils/digest"
+	"github.com/guicostaarantes/psi-server/utils/orm"
+	"github.com/guicostaarantes/psi-server/utils/otp"
+)
+
+// ValidateTwoFactorCodeService is a service that checks a one-time password or a recovery code of a user,
+// consuming the recovery code if that is the case. One-time passwords cannot be used twice either
+type ValidateTwoFactorCodeService struct {
+	DigestUtil digest.IDigestUtil
+	OrmUtil    orm.IOrmUtil
+	OtpUtil    otp.IOtpUtil
+}
+
+// Execute is the method that runs the business logic of the service
+func (s ValidateTwoFactorCodeService) Execute(userID string, code string) error {
+
+	twoFactor := &users_models.TwoFactor{}
+
+	result := s.OrmUtil.Db().Where("user_id = ? AND enabled = ?", userID, true).Limit(1).Find(&twoFactor)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if twoFactor.UserID == "" {
+		return errors.New("invalid two factor code")
+	}
+
+	step, valid := s.OtpUtil.ValidateCode(code, twoFactor.Secret, twoFactor.LastUsedStep)
+	if valid {
+		// The step is only stored if no other request used a code of the same step in the meantime
+		result = s.OrmUtil.Db().Model(&users_models.TwoFactor{}).Where("user_id = ? AND last_used_step < ?", userID, step).Update("last_used_step", step)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return errors.New("invalid two factor code")
+		}
+
+		return nil
+	}
+
+	codeHash, digestErr := s.DigestUtil.Digest(strings.ToUpper(strings.TrimSpace(code)))
+	if digestErr != nil {
+		return digestErr
+	}
+
+	recoveryCode := &users_models.TwoFactorRecoveryCode{}
+
+	result = s.OrmUtil.Db().Where("user_id = ? AND code_hash = ?", userID, codeHash).Limit(1).Find(&recoveryCode)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if recoveryCode.ID == "" {
+		return errors.New("invalid two factor code")
+	}
+
+	result = s.OrmUtil.Db().Delete(&recoveryCode)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	return nil
+
+}
@@ -2,41 +2,60 @@ package users_services
 
 import (
 	"errors"
-	"time"
 
 	users_models "github.com/guicostaarantes/psi-server/modules/users/models"
 	"github.com/guicostaarantes/psi-server/utils/orm"
 	"github.com/guicostaarantes/psi-server/utils/serializing"
+	"github.com/guicostaarantes/psi-server/utils/token"
 )
 
-// ValidateUserTokenService is a service that checks the validity of a token
+// ValidateUserTokenService is a service that checks the validity of a token. Besides the signature, the session that issued
+// the token must still exist, so that revoked sessions lose access immediately instead of when their token expires
 type ValidateUserTokenService struct {
-	OrmUtil                 orm.IOrmUtil
-	SerializingUtil         serializing.ISerializingUtil
-	ExpireAuthTokenDuration time.Duration
+	OrmUtil         orm.IOrmUtil
+	SerializingUtil serializing.ISerializingUtil
+	TokenUtil       token.ITokenUtil
 }
 
 // Execute is the method that runs the business logic of the service
-func (s ValidateUserTokenService) Execute(token string) (string, error) {
+func (s ValidateUserTokenService) Execute(token string) (*users_models.AuthClaims, error) {
 
-	auth := users_models.Authentication{}
+	payload, verifyErr := s.TokenUtil.Verify(token)
+	if verifyErr != nil {
+		return nil, errors.New("invalid token")
+	}
 
-	result := s.OrmUtil.Db().Where("token = ?", token).Limit(1).Find(&auth)
-	if result.Error != nil {
-		return "", result.Error
+	claims := &users_models.AuthClaims{}
+
+	serializeErr := s.SerializingUtil.BytesToVariable([]byte(payload), claims)
+	if serializeErr != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.UserID == "" || claims.SessionID == "" {
+		return nil, errors.New("invalid token")
 	}
 
-	if auth.UserID == "" || auth.ExpiresAt.Before(time.Now()) {
-		return "", errors.New("invalid token")
+	sessionID := claims.SessionID
+	sessionUserID := claims.UserID
+
+	// impersonation tokens have no session of their own and last as long as the session of the impersonator
+	if claims.ImpersonatorID != "" {
+		sessionID = claims.ImpersonatorSessionID
+		sessionUserID = claims.ImpersonatorID
 	}
 
-	auth.ExpiresAt = time.Now().Add(s.ExpireAuthTokenDuration)
+	session := users_models.Authentication{}
 
-	result = s.OrmUtil.Db().Save(&auth)
+	result := s.OrmUtil.Db().Where("id = ? AND user_id = ?", sessionID, sessionUserID).Limit(1).Find(&session)
 	if result.Error != nil {
-		return "", result.Error
+		return nil, result.Error
+	}
+
+	if session.ID == "" {
+		return nil, errors.New("invalid token")
 	}
 
-	return auth.UserID, nil
+	return claims, nil
 
 }
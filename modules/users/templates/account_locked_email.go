@@ -0,0 +1,7 @@
+package users_templates
+
+// AccountLockedEmailTemplate is an email template used when a user's account is locked after too many failed authentication attempts
+var AccountLockedEmailTemplate = `<h2>Olá 😊</h2>
+<p>Identificamos muitas tentativas de acesso com senha incorreta na sua conta do PSI.</p>
+<p>Por segurança, o acesso à sua conta ficará bloqueado até {{ .LockedUntil }}.</p>
+<p>Se não foi você, recomendamos que redefina sua senha assim que o bloqueio terminar.</p>`
@@ -0,0 +1,7 @@
+package users_templates
+
+// ConfirmEmailChangeEmailTemplate is an email template used when a user wants to change their email
+var ConfirmEmailChangeEmailTemplate = `<h2>Olá 😊</h2>
+<p>Você recebeu esse email pois solicitou a troca do seu email de acesso ao PSI para este endereço.</p>
+<p>Clique no botão abaixo para confirmar a troca. Até lá, seu email anterior continuará sendo usado.</p>
+<a href="{{ .SiteURL }}/confirmar-email?token={{ .Token }}">Confirmar meu novo email</a>`
@@ -0,0 +1,7 @@
+package users_templates
+
+// DataExportReadyEmailTemplate is an email template used when the copy of the personal data requested by a user is ready
+var DataExportReadyEmailTemplate = `<h2>Olá 😊</h2>
+<p>Você recebeu esse email pois solicitou uma cópia dos seus dados pessoais no PSI.</p>
+<p>Clique no botão abaixo e entre na sua conta para baixar o arquivo. O link é válido até {{ .ExpiresAt }}.</p>
+<a href="{{ .SiteURL }}/meus-dados?token={{ .Token }}">Baixar meus dados</a>`
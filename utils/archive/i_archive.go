@@ -0,0 +1,6 @@
+package archive
+
+// IArchiveUtil is an abstraction for a utility that packs multiple files into a single one
+type IArchiveUtil interface {
+	Pack(files map[string][]byte) ([]byte, error)
+}
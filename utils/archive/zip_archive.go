@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+type ZipArchiveUtil struct {
+	LoggingUtil logging.ILoggingUtil
+}
+
+func (z ZipArchiveUtil) Pack(files map[string][]byte) ([]byte, error) {
+	names := []string{}
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buff := new(bytes.Buffer)
+	writer := zip.NewWriter(buff)
+
+	for _, name := range names {
+		fileWriter, createErr := writer.Create(name)
+		if createErr != nil {
+			z.LoggingUtil.Error("5be2a4c7", createErr)
+			return nil, errors.New("internal server error")
+		}
+
+		_, writeErr := fileWriter.Write(files[name])
+		if writeErr != nil {
+			z.LoggingUtil.Error("0d6f93a1", writeErr)
+			return nil, errors.New("internal server error")
+		}
+	}
+
+	closeErr := writer.Close()
+	if closeErr != nil {
+		z.LoggingUtil.Error("e4a8c215", closeErr)
+		return nil, errors.New("internal server error")
+	}
+
+	return buff.Bytes(), nil
+}
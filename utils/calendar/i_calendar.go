@@ -0,0 +1,16 @@
+package calendar
+
+import "time"
+
+// Event is a period described in a calendar file. End is exclusive, and all day events start and end at midnight in UTC
+type Event struct {
+	Summary string
+	Start   time.Time
+	End     time.Time
+	AllDay  bool
+}
+
+// ICalendarUtil is an abstraction for a utility that reads events from calendar files
+type ICalendarUtil interface {
+	Parse(data []byte) ([]Event, error)
+}
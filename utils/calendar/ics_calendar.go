@@ -0,0 +1,118 @@
+package calendar
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+type IcsCalendarUtil struct {
+	LoggingUtil logging.ILoggingUtil
+}
+
+func (i IcsCalendarUtil) Parse(data []byte) ([]Event, error) {
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	// long lines are folded into the next ones, which start with a space or a tab
+	content = strings.ReplaceAll(strings.ReplaceAll(content, "\n ", ""), "\n\t", "")
+
+	events := []Event{}
+	var current *Event
+	insideCalendar := false
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " ")
+		if line == "" {
+			continue
+		}
+
+		separator := strings.Index(line, ":")
+		if separator == -1 {
+			return nil, errors.New("invalid ics file")
+		}
+
+		params := strings.Split(line[:separator], ";")
+		name := strings.ToUpper(params[0])
+		value := line[separator+1:]
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			insideCalendar = true
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+		case name == "END" && value == "VEVENT":
+			if current == nil || current.Start.IsZero() {
+				return nil, errors.New("invalid ics file")
+			}
+			if current.End.IsZero() {
+				current.End = current.Start
+				if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+		case current != nil && (name == "DTSTART" || name == "DTEND"):
+			moment, allDay, parseErr := parseIcsTime(value, params[1:])
+			if parseErr != nil {
+				i.LoggingUtil.Error("8f2c61d0", parseErr)
+				return nil, errors.New("invalid ics file")
+			}
+			if name == "DTSTART" {
+				current.Start = moment
+				current.AllDay = allDay
+			} else {
+				current.End = moment
+			}
+		case current != nil && name == "SUMMARY":
+			current.Summary = strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+		}
+	}
+
+	if !insideCalendar {
+		return nil, errors.New("invalid ics file")
+	}
+
+	return events, nil
+}
+
+func parseIcsTime(value string, params []string) (time.Time, bool, error) {
+	location := time.UTC
+
+	for _, param := range params {
+		pair := strings.SplitN(param, "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+
+		switch strings.ToUpper(pair[0]) {
+		case "VALUE":
+			if strings.ToUpper(pair[1]) == "DATE" {
+				date, parseErr := time.Parse("20060102", value)
+				return date, true, parseErr
+			}
+		case "TZID":
+			tzLocation, locationErr := time.LoadLocation(pair[1])
+			if locationErr != nil {
+				return time.Time{}, false, locationErr
+			}
+			location = tzLocation
+		}
+	}
+
+	if len(value) == 8 {
+		date, parseErr := time.Parse("20060102", value)
+		return date, true, parseErr
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		moment, parseErr := time.Parse("20060102T150405Z", value)
+		return moment, false, parseErr
+	}
+
+	// times without an offset are read in the time zone of their TZID, or as UTC when they have none
+	moment, parseErr := time.ParseInLocation("20060102T150405", value, location)
+	return moment, false, parseErr
+}
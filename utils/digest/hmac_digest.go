@@ -0,0 +1,27 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+type HmacDigestUtil struct {
+	Key         string
+	LoggingUtil logging.ILoggingUtil
+}
+
+func (h HmacDigestUtil) Digest(plain string) (string, error) {
+	if h.Key == "" {
+		h.LoggingUtil.Error("4a7f0d36", errors.New("digest key is not configured"))
+		return "", errors.New("internal server error")
+	}
+
+	mac := hmac.New(sha256.New, []byte(h.Key))
+	mac.Write([]byte(plain))
+
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
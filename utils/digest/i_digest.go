@@ -0,0 +1,7 @@
+package digest
+
+// IDigestUtil is an abstraction for a utility that creates deterministic keyed digests of strings,
+// so that secrets (like tokens) can be looked up in storage without being kept in plaintext
+type IDigestUtil interface {
+	Digest(plain string) (string, error)
+}
@@ -32,7 +32,7 @@ func (u DiskFileStorageUtil) ReadFile(name string) ([]byte, error) {
 
 	data, readErr := os.ReadFile(fileLocation)
 	if readErr != nil {
-		if readErr.Error() == fmt.Sprintf(`open /data/files/%s: no such file or directory`, name) {
+		if os.IsNotExist(readErr) {
 			return []byte{}, nil
 		} else {
 			u.LoggingUtil.Error("486f275d", readErr)
@@ -42,3 +42,15 @@ func (u DiskFileStorageUtil) ReadFile(name string) ([]byte, error) {
 
 	return data, nil
 }
+
+func (u DiskFileStorageUtil) DeleteFile(name string) error {
+	fileLocation := filepath.Join(u.BaseFolder, name)
+
+	removeErr := os.Remove(fileLocation)
+	if removeErr != nil && !os.IsNotExist(removeErr) {
+		u.LoggingUtil.Error("c31f5e08", removeErr)
+		return removeErr
+	}
+
+	return nil
+}
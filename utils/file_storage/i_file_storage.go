@@ -4,4 +4,5 @@ package file_storage
 type IFileStorageUtil interface {
 	WriteFile(data []byte) (string, error)
 	ReadFile(name string) ([]byte, error)
+	DeleteFile(name string) error
 }
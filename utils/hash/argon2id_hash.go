@@ -0,0 +1,130 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2idHashUtil hashes with Argon2id, encoding the parameters in the hash string. Hashes created by
+// BcryptHashUtil are still accepted by Compare, and reported by NeedsRehash so that they can be upgraded
+type Argon2idHashUtil struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+	LoggingUtil logging.ILoggingUtil
+}
+
+type argon2idHash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (a Argon2idHashUtil) Hash(plain string) (string, error) {
+	salt := make([]byte, a.SaltLength)
+
+	_, randErr := rand.Read(salt)
+	if randErr != nil {
+		a.LoggingUtil.Error("9c41d7e2", randErr)
+		return "", errors.New("internal server error")
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, a.Iterations, a.Memory, a.Parallelism, a.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		a.Memory,
+		a.Iterations,
+		a.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (a Argon2idHashUtil) Compare(plain string, hashed string) error {
+	if isBcryptHash(hashed) {
+		return BcryptHashUtil{LoggingUtil: a.LoggingUtil}.Compare(plain, hashed)
+	}
+
+	decoded, decodeErr := decodeArgon2idHash(hashed)
+	if decodeErr != nil {
+		// Users without a password, such as the ones that were invited or erased, have an empty hash
+		return errors.New(a.GetWrongPasswordError())
+	}
+
+	key := argon2.IDKey([]byte(plain), decoded.salt, decoded.iterations, decoded.memory, decoded.parallelism, uint32(len(decoded.key)))
+
+	if subtle.ConstantTimeCompare(key, decoded.key) != 1 {
+		return errors.New(a.GetWrongPasswordError())
+	}
+
+	return nil
+}
+
+func (a Argon2idHashUtil) NeedsRehash(hashed string) bool {
+	decoded, decodeErr := decodeArgon2idHash(hashed)
+	if decodeErr != nil {
+		return true
+	}
+
+	return decoded.memory != a.Memory ||
+		decoded.iterations != a.Iterations ||
+		decoded.parallelism != a.Parallelism ||
+		uint32(len(decoded.salt)) != a.SaltLength ||
+		uint32(len(decoded.key)) != a.KeyLength
+}
+
+func (a Argon2idHashUtil) GetWrongPasswordError() string {
+	return "hashedPassword is not the hash of the given password"
+}
+
+func isBcryptHash(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+func decodeArgon2idHash(hashed string) (*argon2idHash, error) {
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	_, scanErr := fmt.Sscanf(parts[2], "v=%d", &version)
+	if scanErr != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2id version")
+	}
+
+	decoded := &argon2idHash{}
+
+	_, scanErr = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &decoded.memory, &decoded.iterations, &decoded.parallelism)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+
+	salt, saltErr := base64.RawStdEncoding.DecodeString(parts[4])
+	if saltErr != nil {
+		return nil, saltErr
+	}
+
+	key, keyErr := base64.RawStdEncoding.DecodeString(parts[5])
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	decoded.salt = salt
+	decoded.key = key
+
+	return decoded, nil
+}
@@ -36,6 +36,14 @@ func (h BcryptHashUtil) Compare(plain string, hashed string) error {
 	return nil
 }
 
+func (h BcryptHashUtil) NeedsRehash(hashed string) bool {
+	cost, costErr := bcrypt.Cost([]byte(hashed))
+	if costErr != nil {
+		return true
+	}
+	return cost != h.Cost
+}
+
 func (h BcryptHashUtil) GetWrongPasswordError() string {
 	return "hashedPassword is not the hash of the given password"
 }
@@ -4,5 +4,6 @@ package hash
 type IHashUtil interface {
 	Hash(plain string) (string, error)
 	Compare(plain string, hashed string) error
+	NeedsRehash(hashed string) bool
 	GetWrongPasswordError() string
 }
@@ -1,7 +1,57 @@
 package match
 
+import "strings"
+
 // IMatchUtil is an abstraction for a utility that checks requirements of strings
 type IMatchUtil interface {
-	IsPasswordStrong(password string) error
+	IsPasswordStrong(password string, email string) error
 	IsEmailValid(email string) error
 }
+
+// PasswordPolicy holds the rules that a password must follow to be considered strong
+type PasswordPolicy struct {
+	MinLength             int
+	RequireUppercase      bool
+	RequireLowercase      bool
+	RequireDigit          bool
+	RequireSymbol         bool
+	MaxRepeatedCharacters int
+	ForbidEmail           bool
+	Blocklist             map[string]bool
+}
+
+// Rules of the password policy, informed to the user when their password does not follow them
+const (
+	MinLengthRule             = "MIN_LENGTH"
+	UppercaseRule             = "UPPERCASE"
+	LowercaseRule             = "LOWERCASE"
+	DigitRule                 = "DIGIT"
+	SymbolRule                = "SYMBOL"
+	MaxRepeatedCharactersRule = "MAX_REPEATED_CHARACTERS"
+	EmailRule                 = "EMAIL"
+	BlocklistRule             = "BLOCKLIST"
+)
+
+// WeakPasswordError is the error returned when a password does not follow the password policy
+type WeakPasswordError struct {
+	FailedRules []string
+}
+
+func (e *WeakPasswordError) Error() string {
+	return "weak password"
+}
+
+// ParseBlocklist reads a list of forbidden passwords, one per line, ignoring empty lines and lines starting with #
+func ParseBlocklist(content string) map[string]bool {
+	blocklist := map[string]bool{}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocklist[strings.ToLower(line)] = true
+	}
+
+	return blocklist
+}
@@ -3,29 +3,85 @@ package match
 import (
 	"errors"
 	"regexp"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/guicostaarantes/psi-server/utils/logging"
 )
 
 type RegexpMatchUtil struct {
-	LoggingUtil logging.ILoggingUtil
+	PasswordPolicy PasswordPolicy
+	LoggingUtil    logging.ILoggingUtil
 }
 
-func (r RegexpMatchUtil) IsPasswordStrong(password string) error {
-	checks := []string{"[A-Z]", "[a-z]", "[0-9]", "[~!@#$%^&*()_+]", ".{8,}"}
+func (r RegexpMatchUtil) IsPasswordStrong(password string, email string) error {
+	policy := r.PasswordPolicy
+	failedRules := []string{}
+
+	if utf8.RuneCountInString(password) < policy.MinLength {
+		failedRules = append(failedRules, MinLengthRule)
+	}
+
+	checks := []struct {
+		required bool
+		pattern  string
+		rule     string
+	}{
+		{policy.RequireUppercase, "\\p{Lu}", UppercaseRule},
+		{policy.RequireLowercase, "\\p{Ll}", LowercaseRule},
+		{policy.RequireDigit, "\\p{Nd}", DigitRule},
+		{policy.RequireSymbol, "[^\\p{L}\\p{N}\\s]", SymbolRule},
+	}
 
 	for _, check := range checks {
-		match, matchErr := regexp.MatchString(check, password)
+		if !check.required {
+			continue
+		}
+
+		match, matchErr := regexp.MatchString(check.pattern, password)
 		if matchErr != nil {
 			r.LoggingUtil.Error("78dbbb35", matchErr)
 			return errors.New("internal server error")
 		}
 
 		if !match {
-			return errors.New("weak password")
+			failedRules = append(failedRules, check.rule)
+		}
+	}
+
+	if policy.MaxRepeatedCharacters > 0 {
+		var previous rune
+		repeated := 0
+		for _, character := range password {
+			if character == previous {
+				repeated++
+			} else {
+				previous = character
+				repeated = 1
+			}
+			if repeated > policy.MaxRepeatedCharacters {
+				failedRules = append(failedRules, MaxRepeatedCharactersRule)
+				break
+			}
+		}
+	}
+
+	if policy.ForbidEmail && email != "" {
+		lowerPassword := strings.ToLower(password)
+		localPart := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+		if strings.Contains(lowerPassword, strings.ToLower(email)) || (len(localPart) >= 3 && strings.Contains(lowerPassword, localPart)) {
+			failedRules = append(failedRules, EmailRule)
 		}
 	}
 
+	if policy.Blocklist[strings.ToLower(password)] {
+		failedRules = append(failedRules, BlocklistRule)
+	}
+
+	if len(failedRules) > 0 {
+		return &WeakPasswordError{FailedRules: failedRules}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,350 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+// HttpOidcUtil implements the authorization code flow with PKCE against a provider that supports discovery,
+// verifying RS256 signed id tokens with the keys published by the provider
+type HttpOidcUtil struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	HttpClient   *http.Client
+	LoggingUtil  logging.ILoggingUtil
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+type oidcJwks struct {
+	Keys []struct {
+		KeyType string `json:"kty"`
+		KeyID   string `json:"kid"`
+		N       string `json:"n"`
+		E       string `json:"e"`
+	} `json:"keys"`
+}
+
+type oidcTokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+type oidcIDTokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+type oidcIDTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      json.RawMessage `json:"aud"`
+	ExpiresAt     int64           `json:"exp"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified interface{}     `json:"email_verified"`
+}
+
+func (o HttpOidcUtil) GenerateAuthorizationRequest() (*AuthorizationRequest, error) {
+	if o.IssuerURL == "" {
+		return nil, errors.New("oidc is not configured")
+	}
+
+	discovery, discoveryErr := o.discover()
+	if discoveryErr != nil {
+		return nil, discoveryErr
+	}
+
+	state, stateErr := o.randomString(32)
+	if stateErr != nil {
+		return nil, stateErr
+	}
+
+	nonce, nonceErr := o.randomString(32)
+	if nonceErr != nil {
+		return nil, nonceErr
+	}
+
+	codeVerifier, verifierErr := o.randomString(48)
+	if verifierErr != nil {
+		return nil, verifierErr
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", o.ClientID)
+	query.Set("redirect_uri", o.RedirectURL)
+	query.Set("scope", "openid email")
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return &AuthorizationRequest{
+		URL:          discovery.AuthorizationEndpoint + separator + query.Encode(),
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+	}, nil
+}
+
+func (o HttpOidcUtil) ExchangeCode(code string, codeVerifier string, nonce string) (*Identity, error) {
+	if o.IssuerURL == "" {
+		return nil, errors.New("oidc is not configured")
+	}
+
+	discovery, discoveryErr := o.discover()
+	if discoveryErr != nil {
+		return nil, discoveryErr
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", o.RedirectURL)
+	form.Set("client_id", o.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	request, requestErr := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if requestErr != nil {
+		o.LoggingUtil.Error("4b7e91d3", requestErr)
+		return nil, errors.New("internal server error")
+	}
+
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if o.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+	}
+
+	response, responseErr := o.client().Do(request)
+	if responseErr != nil {
+		o.LoggingUtil.Error("c20f5a68", responseErr)
+		return nil, errors.New("internal server error")
+	}
+	defer response.Body.Close()
+
+	// The provider answers with a client error when the code was already used, has expired or does not match the verifier
+	if response.StatusCode >= 400 && response.StatusCode < 500 {
+		return nil, errors.New("invalid authorization code")
+	}
+
+	if response.StatusCode != http.StatusOK {
+		o.LoggingUtil.Error("e95a2c07", fmt.Errorf("token endpoint answered with status %d", response.StatusCode))
+		return nil, errors.New("internal server error")
+	}
+
+	tokenResponse := oidcTokenResponse{}
+
+	decodeErr := json.NewDecoder(response.Body).Decode(&tokenResponse)
+	if decodeErr != nil {
+		o.LoggingUtil.Error("7d13b4fa", decodeErr)
+		return nil, errors.New("internal server error")
+	}
+
+	claims, verifyErr := o.verifyIDToken(discovery, tokenResponse.IDToken)
+	if verifyErr != nil {
+		return nil, verifyErr
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("invalid id token")
+	}
+
+	// Some providers send email_verified as a string instead of a boolean
+	emailVerified := claims.EmailVerified == true || claims.EmailVerified == "true"
+
+	return &Identity{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: emailVerified,
+	}, nil
+}
+
+func (o HttpOidcUtil) client() *http.Client {
+	if o.HttpClient != nil {
+		return o.HttpClient
+	}
+	return http.DefaultClient
+}
+
+func (o HttpOidcUtil) getJSON(address string, receiver interface{}) error {
+	response, responseErr := o.client().Get(address)
+	if responseErr != nil {
+		o.LoggingUtil.Error("1a6c8e40", responseErr)
+		return errors.New("internal server error")
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		o.LoggingUtil.Error("f3d9072b", fmt.Errorf("%s answered with status %d", address, response.StatusCode))
+		return errors.New("internal server error")
+	}
+
+	decodeErr := json.NewDecoder(response.Body).Decode(receiver)
+	if decodeErr != nil {
+		o.LoggingUtil.Error("86b2d51e", decodeErr)
+		return errors.New("internal server error")
+	}
+
+	return nil
+}
+
+func (o HttpOidcUtil) discover() (*oidcDiscovery, error) {
+	issuer := strings.TrimSuffix(o.IssuerURL, "/")
+
+	discovery := &oidcDiscovery{}
+
+	getErr := o.getJSON(issuer+"/.well-known/openid-configuration", discovery)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		o.LoggingUtil.Error("5e0a3c97", fmt.Errorf("discovery document informs issuer %s", discovery.Issuer))
+		return nil, errors.New("internal server error")
+	}
+
+	return discovery, nil
+}
+
+func (o HttpOidcUtil) verifyIDToken(discovery *oidcDiscovery, idToken string) (*oidcIDTokenClaims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid id token")
+	}
+
+	headerBytes, headerErr := base64.RawURLEncoding.DecodeString(parts[0])
+	if headerErr != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	header := oidcIDTokenHeader{}
+
+	unmarshalErr := json.Unmarshal(headerBytes, &header)
+	if unmarshalErr != nil || header.Algorithm != "RS256" {
+		return nil, errors.New("invalid id token")
+	}
+
+	signature, signatureErr := base64.RawURLEncoding.DecodeString(parts[2])
+	if signatureErr != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	key, keyErr := o.getSigningKey(discovery, header.KeyID)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+
+	verifyErr := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	if verifyErr != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	claimsBytes, claimsErr := base64.RawURLEncoding.DecodeString(parts[1])
+	if claimsErr != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	claims := &oidcIDTokenClaims{}
+
+	unmarshalErr = json.Unmarshal(claimsBytes, claims)
+	if unmarshalErr != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	if claims.Issuer != discovery.Issuer || claims.Subject == "" || time.Now().Unix() >= claims.ExpiresAt {
+		return nil, errors.New("invalid id token")
+	}
+
+	// The audience may be a single client id or a list of them
+	audiences := []string{}
+	singleAudience := ""
+	if json.Unmarshal(claims.Audience, &singleAudience) == nil {
+		audiences = append(audiences, singleAudience)
+	} else if json.Unmarshal(claims.Audience, &audiences) != nil {
+		return nil, errors.New("invalid id token")
+	}
+
+	for _, audience := range audiences {
+		if audience == o.ClientID {
+			return claims, nil
+		}
+	}
+
+	return nil, errors.New("invalid id token")
+}
+
+func (o HttpOidcUtil) getSigningKey(discovery *oidcDiscovery, keyID string) (*rsa.PublicKey, error) {
+	jwks := oidcJwks{}
+
+	getErr := o.getJSON(discovery.JwksURI, &jwks)
+	if getErr != nil {
+		return nil, getErr
+	}
+
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "RSA" || (keyID != "" && jwk.KeyID != keyID) {
+			continue
+		}
+
+		n, nErr := base64.RawURLEncoding.DecodeString(jwk.N)
+		if nErr != nil {
+			continue
+		}
+
+		e, eErr := base64.RawURLEncoding.DecodeString(jwk.E)
+		if eErr != nil {
+			continue
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	}
+
+	return nil, errors.New("invalid id token")
+}
+
+func (o HttpOidcUtil) randomString(size int) (string, error) {
+	bytes := make([]byte, size)
+
+	_, randErr := rand.Read(bytes)
+	if randErr != nil {
+		o.LoggingUtil.Error("b3f61c2d", randErr)
+		return "", errors.New("internal server error")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
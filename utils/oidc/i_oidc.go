@@ -0,0 +1,23 @@
+package oidc
+
+// AuthorizationRequest is the information needed to send a user to the identity provider and later validate their return
+type AuthorizationRequest struct {
+	URL          string
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// Identity is the information about a user asserted by the identity provider
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// IOidcUtil is an abstraction for a utility that authenticates users with an external OpenID Connect identity provider
+type IOidcUtil interface {
+	GenerateAuthorizationRequest() (*AuthorizationRequest, error)
+	ExchangeCode(code string, codeVerifier string, nonce string) (*Identity, error)
+}
@@ -5,6 +5,7 @@ import (
 
 	agreements_models "github.com/guicostaarantes/psi-server/modules/agreements/models"
 	appointments_models "github.com/guicostaarantes/psi-server/modules/appointments/models"
+	audits_models "github.com/guicostaarantes/psi-server/modules/audits/models"
 	characteristics_models "github.com/guicostaarantes/psi-server/modules/characteristics/models"
 	cooldowns_models "github.com/guicostaarantes/psi-server/modules/cooldowns/models"
 	mails_models "github.com/guicostaarantes/psi-server/modules/mails/models"
@@ -30,10 +31,17 @@ func (p *PostgresOrmUtil) Connect(dsn string) error {
 
 		if err == nil {
 			p.dbConn = db
+			// Sessions created before refresh tokens existed cannot be renewed, so the legacy table is discarded and users just need to log in again
+			if db.Migrator().HasTable(&users_models.Authentication{}) && !db.Migrator().HasColumn(&users_models.Authentication{}, "refresh_token") && !db.Migrator().HasColumn(&users_models.Authentication{}, "RefreshTokenHash") {
+				db.Migrator().DropTable(&users_models.Authentication{})
+			}
 			migrateErr := db.AutoMigrate(
 				&agreements_models.Agreement{},
 				&agreements_models.Term{},
+				&appointments_models.Absence{},
 				&appointments_models.Appointment{},
+				&appointments_models.Holiday{},
+				&audits_models.AuditEntry{},
 				&characteristics_models.Affinity{},
 				&characteristics_models.Characteristic{},
 				&characteristics_models.CharacteristicChoice{},
@@ -43,11 +51,22 @@ func (p *PostgresOrmUtil) Connect(dsn string) error {
 				&profiles_models.Patient{},
 				&profiles_models.Psychologist{},
 				&translations_models.Translation{},
+				&treatments_models.AvailabilityWindow{},
 				&treatments_models.Treatment{},
 				&treatments_models.TreatmentPriceRange{},
 				&treatments_models.TreatmentPriceRangeOffering{},
 				&users_models.Authentication{},
+				&users_models.DataExport{},
+				&users_models.EmailChange{},
+				&users_models.Invitation{},
+				&users_models.OidcAuthorization{},
+				&users_models.OidcIdentity{},
+				&users_models.Permission{},
 				&users_models.ResetPassword{},
+				&users_models.RoleDefinition{},
+				&users_models.RolePermission{},
+				&users_models.TwoFactor{},
+				&users_models.TwoFactorRecoveryCode{},
 				&users_models.User{},
 			)
 			if migrateErr != nil {
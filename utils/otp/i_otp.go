@@ -0,0 +1,12 @@
+package otp
+
+import "time"
+
+// IOtpUtil is an abstraction for a utility that creates and checks one-time passwords used as a second authentication factor
+type IOtpUtil interface {
+	GenerateSecret() (string, error)
+	GenerateRecoveryCode() (string, error)
+	GenerateCode(secret string, moment time.Time) (string, error)
+	GetProvisioningURI(secret string, accountName string) string
+	ValidateCode(code string, secret string, lastStep int64) (int64, bool)
+}
@@ -0,0 +1,112 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+// TotpOtpUtil implements time-based one-time passwords as described in RFC 6238,
+// using HMAC-SHA1, 6 digits and steps of 30 seconds, which is what most authenticator apps expect
+type TotpOtpUtil struct {
+	Issuer      string
+	LoggingUtil logging.ILoggingUtil
+}
+
+const totpDigits = 6
+const totpPeriod = 30
+const recoveryCodeRunes = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (t TotpOtpUtil) GenerateSecret() (string, error) {
+	bytes := make([]byte, 20)
+
+	_, randErr := rand.Read(bytes)
+	if randErr != nil {
+		t.LoggingUtil.Error("b81c4e25", randErr)
+		return "", errors.New("internal server error")
+	}
+
+	return totpEncoding.EncodeToString(bytes), nil
+}
+
+func (t TotpOtpUtil) GenerateRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+
+	_, randErr := rand.Read(bytes)
+	if randErr != nil {
+		t.LoggingUtil.Error("6f9a03d7", randErr)
+		return "", errors.New("internal server error")
+	}
+
+	for i, b := range bytes {
+		bytes[i] = recoveryCodeRunes[b%byte(len(recoveryCodeRunes))]
+	}
+
+	return string(bytes[:5]) + "-" + string(bytes[5:]), nil
+}
+
+func (t TotpOtpUtil) GenerateCode(secret string, moment time.Time) (string, error) {
+	key, decodeErr := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if decodeErr != nil {
+		return "", errors.New("invalid secret")
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(moment.Unix()/totpPeriod))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+func (t TotpOtpUtil) GetProvisioningURI(secret string, accountName string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", t.Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(t.Issuer) + ":" + url.PathEscape(accountName)
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// ValidateCode accepts codes from the previous and the next steps too, to make up for clock drift between devices.
+// Codes from steps up to lastStep were already used and are rejected, so that a code cannot be replayed. The step of
+// the accepted code is returned so that it can be stored as the new lastStep
+func (t TotpOtpUtil) ValidateCode(code string, secret string, lastStep int64) (int64, bool) {
+	currentStep := time.Now().Unix() / totpPeriod
+
+	for _, step := range []int64{currentStep - 1, currentStep, currentStep + 1} {
+		if step <= lastStep {
+			continue
+		}
+		expected, generateErr := t.GenerateCode(secret, time.Unix(step*totpPeriod, 0))
+		if generateErr != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
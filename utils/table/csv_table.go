@@ -0,0 +1,26 @@
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+type CsvTableUtil struct {
+	LoggingUtil logging.ILoggingUtil
+}
+
+func (c CsvTableUtil) Parse(data []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, readErr := reader.ReadAll()
+	if readErr != nil {
+		return nil, errors.New("invalid csv file")
+	}
+
+	return rows, nil
+}
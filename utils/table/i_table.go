@@ -0,0 +1,6 @@
+package table
+
+// ITableUtil is an abstraction for a utility that reads rows of values from a file
+type ITableUtil interface {
+	Parse(data []byte) ([][]string, error)
+}
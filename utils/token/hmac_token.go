@@ -0,0 +1,133 @@
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/guicostaarantes/psi-server/utils/logging"
+)
+
+// HmacTokenUtil creates JWT-like tokens signed with HMAC-SHA256. Keys are indexed by a key id
+// that goes in the header of the token, so old keys can still verify tokens while CurrentKeyID signs new ones
+type HmacTokenUtil struct {
+	Keys         map[string]string
+	CurrentKeyID string
+	LoggingUtil  logging.ILoggingUtil
+}
+
+type hmacTokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+type hmacTokenClaims struct {
+	ID        string `json:"jti"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	Payload   string `json:"pld"`
+}
+
+func (h HmacTokenUtil) sign(keyID string, content string) ([]byte, error) {
+	key, ok := h.Keys[keyID]
+	if !ok || key == "" {
+		return nil, errors.New("invalid token")
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(content))
+
+	return mac.Sum(nil), nil
+}
+
+func (h HmacTokenUtil) GenerateToken(payload string, secondsToExpire time.Duration) (string, error) {
+	nonce := make([]byte, 16)
+
+	_, randErr := rand.Read(nonce)
+	if randErr != nil {
+		h.LoggingUtil.Error("5b0e8c1f", randErr)
+		return "", errors.New("internal server error")
+	}
+
+	header, headerErr := json.Marshal(hmacTokenHeader{
+		Algorithm: "HS256",
+		KeyID:     h.CurrentKeyID,
+		Type:      "JWT",
+	})
+	if headerErr != nil {
+		h.LoggingUtil.Error("9d41a7c2", headerErr)
+		return "", errors.New("internal server error")
+	}
+
+	claims, claimsErr := json.Marshal(hmacTokenClaims{
+		ID:        base64.RawURLEncoding.EncodeToString(nonce),
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(secondsToExpire).Unix(),
+		Payload:   payload,
+	})
+	if claimsErr != nil {
+		h.LoggingUtil.Error("e6f23b90", claimsErr)
+		return "", errors.New("internal server error")
+	}
+
+	content := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	signature, signErr := h.sign(h.CurrentKeyID, content)
+	if signErr != nil {
+		h.LoggingUtil.Error("27c8d5e4", errors.New("current signing key is not configured"))
+		return "", errors.New("internal server error")
+	}
+
+	return content + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (h HmacTokenUtil) Verify(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("invalid token")
+	}
+
+	headerBytes, headerErr := base64.RawURLEncoding.DecodeString(parts[0])
+	if headerErr != nil {
+		return "", errors.New("invalid token")
+	}
+
+	header := hmacTokenHeader{}
+	headerErr = json.Unmarshal(headerBytes, &header)
+	if headerErr != nil || header.Algorithm != "HS256" {
+		return "", errors.New("invalid token")
+	}
+
+	signature, signatureErr := base64.RawURLEncoding.DecodeString(parts[2])
+	if signatureErr != nil {
+		return "", errors.New("invalid token")
+	}
+
+	expected, signErr := h.sign(header.KeyID, parts[0]+"."+parts[1])
+	if signErr != nil || !hmac.Equal(signature, expected) {
+		return "", errors.New("invalid token")
+	}
+
+	claimsBytes, claimsErr := base64.RawURLEncoding.DecodeString(parts[1])
+	if claimsErr != nil {
+		return "", errors.New("invalid token")
+	}
+
+	claims := hmacTokenClaims{}
+	claimsErr = json.Unmarshal(claimsBytes, &claims)
+	if claimsErr != nil {
+		return "", errors.New("invalid token")
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return "", errors.New("invalid token")
+	}
+
+	return claims.Payload, nil
+}
@@ -6,4 +6,5 @@ import "time"
 // later be verified to have been created by this utility
 type ITokenUtil interface {
 	GenerateToken(payload string, secondsToExpire time.Duration) (string, error)
+	Verify(token string) (string, error)
 }